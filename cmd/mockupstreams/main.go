@@ -0,0 +1,124 @@
+// Command mockupstreams serves recorded upstream responses so that MetaChan
+// can run without network access. Fixtures are the cassettes written with
+// HTTP_MODE=record, or hand-written files in the same format, and requests
+// are matched on method, URL and body. The fixtures directory shipped with
+// the repository is served by default.
+//
+// An upstream is reached under its host name, so pointing MetaChan at the
+// mock means prefixing each base URL with the mock's address:
+//
+//	JIKAN_BASE_URL=http://localhost:4000/api.jikan.moe/v4
+//	MAL_BASE_URL=http://localhost:4000/myanimelist.net
+package main
+
+import (
+	"flag"
+	"io/fs"
+	"metachan/utils/cassette"
+	"metachan/utils/logger"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// bodyLimit leaves room for large recorded responses such as the mapping list.
+const bodyLimit = 64 * 1024 * 1024
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:4000", "address to listen on")
+	fixtures := flag.String("fixtures", "fixtures", "directory holding the fixture cassettes")
+	flag.Parse()
+
+	logger.Init()
+
+	index, err := loadFixtures(*fixtures)
+	if err != nil {
+		logger.Fatalf("MockUpstreams", "Failed to load fixtures from %s: %v", *fixtures, err)
+	}
+	logger.Infof("MockUpstreams", "Loaded %d fixtures from %s", len(index), *fixtures)
+
+	app := fiber.New(fiber.Config{
+		DisableStartupMessage: true,
+		BodyLimit:             bodyLimit,
+	})
+	app.All("/*", func(c *fiber.Ctx) error {
+		return serveFixture(c, index)
+	})
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		if err := app.Listen(*addr); err != nil {
+			logger.Fatalf("MockUpstreams", "Failed to start the server on %s: %v", *addr, err)
+		}
+	}()
+
+	logger.Successf("MockUpstreams", "Serving mock upstreams on %s", *addr)
+
+	<-quit
+	if err := app.Shutdown(); err != nil {
+		logger.Errorf("MockUpstreams", "Error during server shutdown: %v", err)
+	}
+}
+
+// loadFixtures indexes every cassette under dir by the request it answers.
+// The index is keyed by cassette.Path, so that files may be named freely.
+func loadFixtures(dir string) (map[string]*cassette.Cassette, error) {
+	index := make(map[string]*cassette.Cassette)
+
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+
+		fixture, err := cassette.Load(path)
+		if err != nil {
+			logger.Warnf("MockUpstreams", "Skipping fixture: %v", err)
+			return nil
+		}
+
+		request := fixture.Request
+		index[cassette.Path("", request.Method, request.URL, []byte(request.Body))] = fixture
+		return nil
+	})
+
+	return index, err
+}
+
+// serveFixture turns /<host>/<path> back into the upstream URL and answers
+// with the fixture recorded for it.
+func serveFixture(c *fiber.Ctx, index map[string]*cassette.Cassette) error {
+	target := "https://" + strings.TrimPrefix(c.OriginalURL(), "/")
+
+	fixture, ok := index[cassette.Path("", c.Method(), target, c.Body())]
+	if !ok {
+		logger.Warnf("MockUpstreams", "No fixture for %s %s", c.Method(), target)
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "no fixture for " + c.Method() + " " + target})
+	}
+
+	body, err := fixture.ResponseBody()
+	if err != nil {
+		logger.Errorf("MockUpstreams", "Failed to decode fixture for %s: %v", target, err)
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+
+	for name, values := range fixture.Response.Header {
+		if name == fiber.HeaderContentLength {
+			continue
+		}
+		for _, value := range values {
+			c.Response().Header.Add(name, value)
+		}
+	}
+
+	logger.Debugf("MockUpstreams", "Serving %s %s", c.Method(), target)
+	return c.Status(fixture.Response.Status).Send(body)
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"metachan/enums"
 	"metachan/utils/env"
 	"metachan/utils/logger"
 
@@ -11,7 +12,9 @@ var (
 	Server   server
 	Database database
 	Sync     sync
+	Cache    cache
 	API      api
+	Upstream upstream
 )
 
 func init() {
@@ -33,10 +36,18 @@ func init() {
 		logger.Fatalf("Config", "Failed to parse sync config: %v", err)
 	}
 
+	if err := env.Parse(&Cache); err != nil {
+		logger.Fatalf("Config", "Failed to parse cache config: %v", err)
+	}
+
 	if err := env.Parse(&API); err != nil {
 		logger.Fatalf("Config", "Failed to parse API config: %v", err)
 	}
 
+	if err := env.Parse(&Upstream); err != nil {
+		logger.Fatalf("Config", "Failed to parse upstream config: %v", err)
+	}
+
 	if Server.Debug {
 		logger.SetDebug(true)
 	}
@@ -45,5 +56,9 @@ func init() {
 		logger.Fatalf("Config", "Configuration verification failed: %v", err)
 	}
 
+	if enums.HTTPMode(Upstream.Mode) == enums.HTTPReplay {
+		fillReplayKeys()
+	}
+
 	logger.Successf("Config", "Configuration loaded successfully")
 }
@@ -1,9 +1,14 @@
 package config
 
+import "time"
+
 type server struct {
 	Host  string `env:"HOST" default:"0.0.0.0"`
 	Port  int    `env:"PORT" default:"3000"`
 	Debug bool   `env:"DEBUG" default:"false"`
+
+	// AdminToken enables the /admin routes when set.
+	AdminToken string `env:"ADMIN_TOKEN" default:""`
 }
 
 type database struct {
@@ -11,8 +16,43 @@ type database struct {
 	DSN    string `env:"DSN" default:"metachan.db"`
 }
 
+// The tasks that scrape the whole catalogue are off unless asked for.
 type sync struct {
-	AniSync bool `env:"ANISYNC" default:"false"`
+	AnimeFetch    bool `env:"ANIME_FETCH" default:"true"`
+	AniSync       bool `env:"ANISYNC" default:"false"`
+	AnimeUpdate   bool `env:"ANIME_UPDATE" default:"true"`
+	GenreSync     bool `env:"GENRE_SYNC" default:"true"`
+	ProducerSync  bool `env:"PRODUCER_SYNC" default:"true"`
+	CharacterSync bool `env:"CHARACTER_SYNC" default:"false"`
+	PersonSync    bool `env:"PERSON_SYNC" default:"false"`
+
+	// Each task can be given a cron expression that replaces its interval,
+	// and a jitter that delays every scheduled run by a random amount up to
+	// that long.
+	AnimeFetchSchedule    string        `env:"ANIME_FETCH_SCHEDULE" default:""`
+	AnimeFetchJitter      time.Duration `env:"ANIME_FETCH_JITTER" default:"0s"`
+	AniSyncSchedule       string        `env:"ANISYNC_SCHEDULE" default:""`
+	AniSyncJitter         time.Duration `env:"ANISYNC_JITTER" default:"0s"`
+	AnimeUpdateSchedule   string        `env:"ANIME_UPDATE_SCHEDULE" default:""`
+	AnimeUpdateJitter     time.Duration `env:"ANIME_UPDATE_JITTER" default:"0s"`
+	GenreSyncSchedule     string        `env:"GENRE_SYNC_SCHEDULE" default:""`
+	GenreSyncJitter       time.Duration `env:"GENRE_SYNC_JITTER" default:"0s"`
+	ProducerSyncSchedule  string        `env:"PRODUCER_SYNC_SCHEDULE" default:""`
+	ProducerSyncJitter    time.Duration `env:"PRODUCER_SYNC_JITTER" default:"0s"`
+	CharacterSyncSchedule string        `env:"CHARACTER_SYNC_SCHEDULE" default:""`
+	CharacterSyncJitter   time.Duration `env:"CHARACTER_SYNC_JITTER" default:"0s"`
+	PersonSyncSchedule    string        `env:"PERSON_SYNC_SCHEDULE" default:""`
+	PersonSyncJitter      time.Duration `env:"PERSON_SYNC_JITTER" default:"0s"`
+}
+
+type cache struct {
+	AiringTTL   time.Duration `env:"CACHE_TTL_AIRING" default:"24h"`
+	UpcomingTTL time.Duration `env:"CACHE_TTL_UPCOMING" default:"72h"`
+	FinishedTTL time.Duration `env:"CACHE_TTL_FINISHED" default:"720h"`
+
+	// Upstream turns the upstream response cache kept in UpstreamDir on.
+	Upstream    bool   `env:"CACHE_UPSTREAM" default:"true"`
+	UpstreamDir string `env:"CACHE_UPSTREAM_DIR" default:"cache/upstream"`
 }
 
 type api struct {
@@ -20,3 +60,23 @@ type api struct {
 	TMDBReadToken string `env:"TMDB_READ_ACCESS_TOKEN" default:""`
 	TVDBKey       string `env:"TVDB_API_KEY" default:""`
 }
+
+type upstream struct {
+	// Mode is one of live, record and replay. Cassettes are read from and
+	// written to CassetteDir.
+	Mode        string `env:"HTTP_MODE" default:"live"`
+	CassetteDir string `env:"HTTP_CASSETTE_DIR" default:"cassettes"`
+
+	// Base URLs of every upstream, to point MetaChan at a local stand-in
+	// such as cmd/mockupstreams.
+	JikanURL        string `env:"JIKAN_BASE_URL" default:"https://api.jikan.moe/v4"`
+	AnilistURL      string `env:"ANILIST_BASE_URL" default:"https://graphql.anilist.co"`
+	AniskipURL      string `env:"ANISKIP_BASE_URL" default:"https://api.aniskip.com/v2"`
+	MALsyncURL      string `env:"MALSYNC_BASE_URL" default:"https://api.malsync.moe/mal"`
+	TMDBURL         string `env:"TMDB_BASE_URL" default:"https://api.themoviedb.org/3"`
+	TVDBURL         string `env:"TVDB_BASE_URL" default:"https://api4.thetvdb.com/v4"`
+	AllAnimeURL     string `env:"ALLANIME_BASE_URL" default:"https://api.allanime.day/api"`
+	AllAnimeSiteURL string `env:"ALLANIME_SITE_URL" default:"https://allanime.day"`
+	MALURL          string `env:"MAL_BASE_URL" default:"https://myanimelist.net"`
+	MappingsURL     string `env:"MAPPINGS_URL" default:"https://raw.githubusercontent.com/Fribb/anime-lists/master/anime-list-full.json"`
+}
@@ -2,25 +2,93 @@ package controllers
 
 import (
 	"errors"
+	"metachan/repositories"
+	"metachan/services"
 	"metachan/utils/mal"
 	"metachan/utils/meta"
-	"strconv"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 func GetAnime(c *fiber.Ctx) error {
-	idString := meta.Request(c).MustHave().Param("id")
+	id := meta.Request(c).MustHave().Param("id")
+	source := meta.Request(c).Default("cache").Query("source")
 
-	malID, parseErr := strconv.Atoi(idString)
-	if parseErr != nil {
-		return BadRequest(c, errors.New("invalid MAL ID"))
+	provider, err := parseProvider(c)
+	if err != nil {
+		return BadRequest(c, err)
 	}
 
-	anime, fetchErr := mal.GetAnimeByMALID(malID)
-	if fetchErr != nil {
-		return NotFound(c, fetchErr)
+	switch source {
+	case "cache", "scrape":
+	default:
+		return BadRequest(c, errors.New("invalid source"))
 	}
 
+	mapping, err := repositories.GetAnimeMapping(provider, id)
+	if err != nil {
+		return NotFound(c, err)
+	}
+
+	if source == "scrape" {
+		anime, fetchErr := mal.GetAnimeByMALID(c.UserContext(), mapping.MAL)
+		if fetchErr != nil {
+			return NotFound(c, fetchErr)
+		}
+		return c.JSON(anime)
+	}
+
+	anime, cache, err := services.GetAnime(c.UserContext(), &mapping)
+	if err != nil {
+		return InternalServerError(c, err)
+	}
+
+	setCacheHeaders(c, cache)
+
 	return c.JSON(anime)
 }
+
+func GetAnimeRelations(c *fiber.Ctx) error {
+	id := meta.Request(c).MustHave().Param("id")
+	provider, err := parseProvider(c)
+	if err != nil {
+		return BadRequest(c, err)
+	}
+
+	mapping, err := repositories.GetAnimeMapping(provider, id)
+	if err != nil {
+		return NotFound(c, err)
+	}
+
+	// Relations are stored with the anime, so load it first.
+	if _, _, err := services.GetAnime(c.UserContext(), &mapping); err != nil {
+		return InternalServerError(c, err)
+	}
+
+	relations, err := repositories.GetAnimeRelations(provider, id)
+	if err != nil {
+		return NotFound(c, err)
+	}
+
+	return c.JSON(relations)
+}
+
+func GetAnimeFranchise(c *fiber.Ctx) error {
+	id := meta.Request(c).MustHave().Param("id")
+	provider, err := parseProvider(c)
+	if err != nil {
+		return BadRequest(c, err)
+	}
+
+	mapping, err := repositories.GetAnimeMapping(provider, id)
+	if err != nil {
+		return NotFound(c, err)
+	}
+
+	franchise, err := services.GetFranchise(c.UserContext(), &mapping)
+	if err != nil {
+		return InternalServerError(c, err)
+	}
+
+	return c.JSON(franchise)
+}
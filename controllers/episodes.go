@@ -1,8 +1,6 @@
 package controllers
 
 import (
-	"errors"
-	"metachan/enums"
 	"metachan/repositories"
 	"metachan/utils/meta"
 
@@ -11,15 +9,12 @@ import (
 
 func GetAnimeEpisodes(c *fiber.Ctx) error {
 	id := meta.Request(c).MustHave().Param("id")
-	provider := meta.Request(c).Default("mal").Query("provider")
-
-	switch provider {
-	case "mal", "anilist":
-	default:
-		return BadRequest(c, errors.New("invalid provider"))
+	provider, err := parseProvider(c)
+	if err != nil {
+		return BadRequest(c, err)
 	}
 
-	episodes, err := repositories.GetAnimeEpisodes(enums.MappingType(provider), id)
+	episodes, err := repositories.GetAnimeEpisodes(provider, id)
 	if err != nil {
 		return NotFound(c, err)
 	}
@@ -30,15 +25,12 @@ func GetAnimeEpisodes(c *fiber.Ctx) error {
 func GetAnimeEpisode(c *fiber.Ctx) error {
 	id := meta.Request(c).MustHave().Param("id")
 	episodeID := meta.Request(c).MustHave().Param("episodeId")
-	provider := meta.Request(c).Default("mal").Query("provider")
-
-	switch provider {
-	case "mal", "anilist":
-	default:
-		return BadRequest(c, errors.New("invalid provider"))
+	provider, err := parseProvider(c)
+	if err != nil {
+		return BadRequest(c, err)
 	}
 
-	episode, err := repositories.GetAnimeEpisode(enums.MappingType(provider), id, episodeID)
+	episode, err := repositories.GetAnimeEpisode(provider, id, episodeID)
 	if err != nil {
 		return NotFound(c, err)
 	}
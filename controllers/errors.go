@@ -30,12 +30,24 @@ func NotFound(c *fiber.Ctx, err error) error {
 	}).As(fiber.StatusNotFound)
 }
 
+func Conflict(c *fiber.Ctx, err error) error {
+	return shortcuts.Response(c, fiber.Map{
+		"error": err.Error(),
+	}).As(fiber.StatusConflict)
+}
+
 func InternalServerError(c *fiber.Ctx, err error) error {
 	return shortcuts.Response(c, fiber.Map{
 		"error": "Internal Server Error",
 	}).As(fiber.StatusInternalServerError)
 }
 
+func ServiceUnavailable(c *fiber.Ctx, err error) error {
+	return shortcuts.Response(c, fiber.Map{
+		"error": err.Error(),
+	}).As(fiber.StatusServiceUnavailable)
+}
+
 func DefaultError(c *fiber.Ctx, err error) error {
 	return shortcuts.Response(c, fiber.Map{
 		"error": err.Error(),
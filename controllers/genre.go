@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"errors"
+	"metachan/entities"
+	"metachan/enums"
+	"metachan/repositories"
+	"metachan/services"
+	"metachan/types"
+	"metachan/utils/meta"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func GetGenres(c *fiber.Ctx) error {
+	genres, err := repositories.GetGenres()
+	if err != nil {
+		return InternalServerError(c, err)
+	}
+
+	response := types.GenreListResponse{
+		Genres:         []entities.Genre{},
+		ExplicitGenres: []entities.Genre{},
+		Themes:         []entities.Genre{},
+		Demographics:   []entities.Genre{},
+	}
+
+	for _, genre := range genres {
+		switch genre.Type {
+		case enums.GenreTypeExplicitGenre:
+			response.ExplicitGenres = append(response.ExplicitGenres, genre)
+		case enums.GenreTypeTheme:
+			response.Themes = append(response.Themes, genre)
+		case enums.GenreTypeDemographic:
+			response.Demographics = append(response.Demographics, genre)
+		default:
+			response.Genres = append(response.Genres, genre)
+		}
+	}
+
+	return c.JSON(response)
+}
+
+func GetAnimeByGenre(c *fiber.Ctx) error {
+	genreID, err := strconv.Atoi(meta.Request(c).MustHave().Param("id"))
+	if err != nil {
+		return BadRequest(c, errors.New("id must be a numeric MAL genre ID"))
+	}
+
+	sort, err := parseAnimeSort(c)
+	if err != nil {
+		return BadRequest(c, err)
+	}
+
+	page, limit, err := parsePagination(c)
+	if err != nil {
+		return BadRequest(c, err)
+	}
+
+	anime, err := services.GetAnimeByGenre(c.UserContext(), genreID, sort, page, limit)
+	if err != nil {
+		return InternalServerError(c, err)
+	}
+
+	return c.JSON(anime)
+}
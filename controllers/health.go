@@ -2,9 +2,13 @@ package controllers
 
 import (
 	"metachan/database"
+	"metachan/enums"
 	"metachan/tasks"
 	"metachan/types"
+	"metachan/utils/mal"
+	"metachan/utils/ratelimit"
 	"metachan/utils/stats"
+	"metachan/utils/upstream"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -16,17 +20,40 @@ func HealthStatus(c *fiber.Ctx) error {
 
 	taskStatuses := tasks.GlobalTaskManager.GetAllTaskStatuses()
 
+	upstreams := upstream.BreakerStatuses()
+
+	parsers := mal.ParseHealth()
+
 	statusString := map[bool]string{
 		true:  "healthy",
 		false: "unhealthy",
 	}[databaseStatus]
+	if databaseStatus {
+		for _, status := range upstreams {
+			if status.State != enums.BreakerClosed {
+				statusString = "degraded"
+				break
+			}
+		}
+		for _, parser := range parsers {
+			if parser.Drifting {
+				statusString = "degraded"
+				break
+			}
+		}
+	}
+
 	healthStatus := types.HealthStatus{
-		Status:    statusString,
-		Timestamp: stats.GetCurrentTimestamp(),
-		Uptime:    stats.GetUptime(),
-		Memory:    memoryStats,
-		Database:  types.DatabaseStatus{Connected: databaseStatus, LastChecked: stats.GetCurrentTimestamp()},
-		Tasks:     taskStatuses,
+		Status:        statusString,
+		Timestamp:     stats.GetCurrentTimestamp(),
+		Uptime:        stats.GetUptime(),
+		Memory:        memoryStats,
+		Database:      types.DatabaseStatus{Connected: databaseStatus, LastChecked: stats.GetCurrentTimestamp()},
+		Tasks:         taskStatuses,
+		Upstreams:     upstreams,
+		Limiters:      ratelimit.AllStats(),
+		ResponseCache: upstream.CacheStats(),
+		Parsers:       parsers,
 	}
 	return c.JSON(healthStatus)
 }
@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"metachan/enums"
+	"metachan/types"
+	"metachan/utils/meta"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultPageLimit = 25
+	maxPageLimit     = 100
+)
+
+func parsePagination(c *fiber.Ctx) (int, int, error) {
+	page, err := strconv.Atoi(meta.Request(c).Default("1").Query("page"))
+	if err != nil || page < 1 {
+		return 0, 0, errors.New("page must be a positive integer")
+	}
+
+	limit, err := strconv.Atoi(meta.Request(c).Default(strconv.Itoa(defaultPageLimit)).Query("limit"))
+	if err != nil || limit < 1 {
+		return 0, 0, errors.New("limit must be a positive integer")
+	}
+
+	return page, min(limit, maxPageLimit), nil
+}
+
+func parseAnimeSort(c *fiber.Ctx) (enums.AnimeSort, error) {
+	sort := enums.AnimeSort(meta.Request(c).Default(string(enums.SortByScore)).Query("sort"))
+
+	switch sort {
+	case enums.SortByScore, enums.SortByPopularity, enums.SortByYear:
+		return sort, nil
+	default:
+		return "", errors.New("sort must be one of score, popularity or year")
+	}
+}
+
+func parseProvider(c *fiber.Ctx) (enums.MappingType, error) {
+	return parseMappingType(meta.Request(c).Default(string(enums.MAL)).Query("provider"))
+}
+
+func parseMappingType(value string) (enums.MappingType, error) {
+	provider := enums.MappingType(strings.ToLower(value))
+
+	switch provider {
+	case enums.AniDB, enums.Anilist, enums.AnimeCountdown, enums.AnimePlanet, enums.AniSearch,
+		enums.IMDB, enums.Kitsu, enums.LiveChart, enums.MAL, enums.NotifyMoe,
+		enums.Simkl, enums.TMDB, enums.TVDB:
+		return provider, nil
+	default:
+		return "", errors.New("invalid provider")
+	}
+}
+
+// setCacheHeaders describes how a cached entity was served using the
+// Cache-Status header from RFC 9211. A negative ttl marks a stale response
+// whose refresh is already under way.
+func setCacheHeaders(c *fiber.Ctx, cache types.CacheInfo) {
+	switch cache.Status {
+	case enums.CacheHit:
+		c.Set("Cache-Status", fmt.Sprintf("metachan; hit; ttl=%d", int((cache.TTL-cache.Age).Seconds())))
+		c.Set(fiber.HeaderAge, strconv.Itoa(int(cache.Age.Seconds())))
+	case enums.CacheStale:
+		c.Set("Cache-Status", fmt.Sprintf("metachan; hit; ttl=%d; detail=revalidating", int((cache.TTL-cache.Age).Seconds())))
+		c.Set(fiber.HeaderAge, strconv.Itoa(int(cache.Age.Seconds())))
+	case enums.CacheMiss:
+		c.Set("Cache-Status", "metachan; fwd=uri-miss; stored")
+	}
+}
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"errors"
+	"metachan/entities"
+	"metachan/enums"
+	"metachan/repositories"
+	"metachan/types"
+	"metachan/utils/meta"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetJobs lists queued sync jobs, most recently touched first. Filtering on
+// ?status=dead shows the items that keep failing.
+func GetJobs(c *fiber.Ctx) error {
+	page, limit, err := parsePagination(c)
+	if err != nil {
+		return BadRequest(c, err)
+	}
+
+	kind := enums.JobKind(meta.Request(c).Default("").Query("kind"))
+	switch kind {
+	case "", enums.JobAnime, enums.JobCharacter, enums.JobPerson, enums.JobProducer:
+	default:
+		return BadRequest(c, errors.New("kind must be one of anime, character, person or producer"))
+	}
+
+	status := enums.JobStatus(meta.Request(c).Default("").Query("status"))
+	switch status {
+	case "", enums.JobPending, enums.JobRunning, enums.JobDone, enums.JobDead:
+	default:
+		return BadRequest(c, errors.New("status must be one of pending, running, done or dead"))
+	}
+
+	jobs, total, err := repositories.GetJobs(kind, status, page, limit)
+	if err != nil {
+		return InternalServerError(c, err)
+	}
+
+	return c.JSON(types.PaginatedResponse[entities.SyncJob]{
+		Pagination: types.NewPagination(page, limit, total),
+		Data:       jobs,
+	})
+}
+
+func RetryJob(c *fiber.Ctx) error {
+	key := meta.Request(c).MustHave().Param("key")
+
+	job, err := repositories.RetryJob(key)
+	if err != nil {
+		return NotFound(c, err)
+	}
+
+	return c.JSON(job)
+}
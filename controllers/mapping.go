@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"metachan/repositories"
+	"metachan/types"
+	"metachan/utils/meta"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const maxResolveIDs = 100
+
+func GetMapping(c *fiber.Ctx) error {
+	provider, err := parseMappingType(meta.Request(c).MustHave().Param("provider"))
+	if err != nil {
+		return BadRequest(c, err)
+	}
+
+	mappings, err := repositories.GetMappings(provider, meta.Request(c).MustHave().Param("id"))
+	if errors.Is(err, repositories.ErrInvalidMappingID) {
+		return BadRequest(c, err)
+	}
+	if err != nil {
+		return NotFound(c, err)
+	}
+
+	return c.JSON(mappings)
+}
+
+// ResolveMappings accepts {"provider": "tvdb", "ids": [81797, "tt0388629"]}.
+// IDs may be sent as JSON numbers or strings since providers differ.
+func ResolveMappings(c *fiber.Ctx) error {
+	var request struct {
+		Provider string `json:"provider"`
+		IDs      []any  `json:"ids"`
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(c.Body()))
+	decoder.UseNumber()
+	if err := decoder.Decode(&request); err != nil {
+		return BadRequest(c, errors.New("request body must be a JSON object with provider and ids"))
+	}
+
+	provider, err := parseMappingType(request.Provider)
+	if err != nil {
+		return BadRequest(c, err)
+	}
+
+	if len(request.IDs) == 0 || len(request.IDs) > maxResolveIDs {
+		return BadRequest(c, errors.New("ids must contain between 1 and 100 IDs"))
+	}
+
+	ids := make([]string, 0, len(request.IDs))
+	for _, value := range request.IDs {
+		switch id := value.(type) {
+		case json.Number:
+			ids = append(ids, id.String())
+		case string:
+			ids = append(ids, strings.TrimSpace(id))
+		default:
+			return BadRequest(c, errors.New("ids must be numbers or strings"))
+		}
+	}
+
+	results, err := repositories.ResolveMappings(provider, ids)
+	if errors.Is(err, repositories.ErrInvalidMappingID) {
+		return BadRequest(c, err)
+	}
+	if err != nil {
+		return InternalServerError(c, err)
+	}
+
+	return c.JSON(types.MappingResolveResponse{
+		Provider: provider,
+		Results:  results,
+	})
+}
@@ -2,8 +2,8 @@ package controllers
 
 import (
 	"errors"
-	"metachan/enums"
 	"metachan/repositories"
+	"metachan/services"
 	"metachan/utils/meta"
 	"strconv"
 
@@ -12,15 +12,12 @@ import (
 
 func GetAnimeCharacters(c *fiber.Ctx) error {
 	id := meta.Request(c).MustHave().Param("id")
-	provider := meta.Request(c).Default("mal").Query("provider")
-
-	switch provider {
-	case "mal", "anilist":
-	default:
-		return BadRequest(c, errors.New("invalid provider"))
+	provider, err := parseProvider(c)
+	if err != nil {
+		return BadRequest(c, err)
 	}
 
-	characters, err := repositories.GetAnimeCharacters(enums.MappingType(provider), id)
+	characters, err := repositories.GetAnimeCharacters(provider, id)
 	if err != nil {
 		return NotFound(c, err)
 	}
@@ -49,15 +46,12 @@ func GetAnimeCharacter(c *fiber.Ctx) error {
 
 func GetAnimePeople(c *fiber.Ctx) error {
 	id := meta.Request(c).MustHave().Param("id")
-	provider := meta.Request(c).Default("mal").Query("provider")
-
-	switch provider {
-	case "mal", "anilist":
-	default:
-		return BadRequest(c, errors.New("invalid provider"))
+	provider, err := parseProvider(c)
+	if err != nil {
+		return BadRequest(c, err)
 	}
 
-	people, err := repositories.GetAnimePeople(enums.MappingType(provider), id)
+	people, err := repositories.GetAnimePeople(provider, id)
 	if err != nil {
 		return NotFound(c, err)
 	}
@@ -65,6 +59,31 @@ func GetAnimePeople(c *fiber.Ctx) error {
 	return c.JSON(people)
 }
 
+func GetAnimeStaff(c *fiber.Ctx) error {
+	id := meta.Request(c).MustHave().Param("id")
+	provider, err := parseProvider(c)
+	if err != nil {
+		return BadRequest(c, err)
+	}
+
+	mapping, err := repositories.GetAnimeMapping(provider, id)
+	if err != nil {
+		return NotFound(c, err)
+	}
+
+	// The staff list is stored with the anime, so load it first.
+	if _, _, err := services.GetAnime(c.UserContext(), &mapping); err != nil {
+		return InternalServerError(c, err)
+	}
+
+	staff, err := repositories.GetAnimeStaff(provider, id)
+	if err != nil {
+		return NotFound(c, err)
+	}
+
+	return c.JSON(staff)
+}
+
 func GetPerson(c *fiber.Ctx) error {
 	personID, ok := meta.Request(c).Param("personId")
 	if !ok {
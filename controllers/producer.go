@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"errors"
+	"metachan/entities"
+	"metachan/enums"
+	"metachan/repositories"
+	"metachan/types"
+	"metachan/utils/meta"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func GetProducers(c *fiber.Ctx) error {
+	page, limit, err := parsePagination(c)
+	if err != nil {
+		return BadRequest(c, err)
+	}
+
+	producers, total, err := repositories.SearchProducers(meta.Request(c).Default("").Query("q"), page, limit)
+	if err != nil {
+		return InternalServerError(c, err)
+	}
+
+	return c.JSON(types.PaginatedResponse[entities.Producer]{
+		Pagination: types.NewPagination(page, limit, total),
+		Data:       producers,
+	})
+}
+
+func GetProducer(c *fiber.Ctx) error {
+	malID, err := strconv.Atoi(meta.Request(c).MustHave().Param("id"))
+	if err != nil {
+		return BadRequest(c, errors.New("id must be a numeric MAL ID"))
+	}
+
+	producer, err := repositories.GetProducer(malID)
+	if err != nil {
+		return NotFound(c, err)
+	}
+
+	return c.JSON(producer)
+}
+
+func GetAnimeByProducer(c *fiber.Ctx) error {
+	malID, err := strconv.Atoi(meta.Request(c).MustHave().Param("id"))
+	if err != nil {
+		return BadRequest(c, errors.New("id must be a numeric MAL ID"))
+	}
+
+	var roles []enums.ProducerRole
+	if role, ok := meta.Request(c).Query("role"); ok {
+		switch enums.ProducerRole(role) {
+		case enums.ProducerRoleProducer, enums.ProducerRoleStudio, enums.ProducerRoleLicensor:
+			roles = append(roles, enums.ProducerRole(role))
+		default:
+			return BadRequest(c, errors.New("role must be one of producer, studio or licensor"))
+		}
+	}
+
+	sort, err := parseAnimeSort(c)
+	if err != nil {
+		return BadRequest(c, err)
+	}
+
+	page, limit, err := parsePagination(c)
+	if err != nil {
+		return BadRequest(c, err)
+	}
+
+	producer, err := repositories.GetProducer(malID)
+	if err != nil {
+		return NotFound(c, err)
+	}
+
+	anime, total, err := repositories.GetAnimeByProducer(producer.ID, roles, sort, page, limit)
+	if err != nil {
+		return InternalServerError(c, err)
+	}
+
+	return c.JSON(types.PaginatedResponse[entities.Anime]{
+		Pagination: types.NewPagination(page, limit, total),
+		Data:       anime,
+	})
+}
@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"metachan/entities"
+	"metachan/repositories"
+	"metachan/services"
+	"metachan/utils/ical"
+	"metachan/utils/meta"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultUpcomingHours = 24
+	maxUpcomingHours     = 7 * 24
+	maxCalendarAnime     = 100
+)
+
+func GetSchedule(c *fiber.Ctx) error {
+	location, err := time.LoadLocation(meta.Request(c).Default("UTC").Query("tz"))
+	if err != nil {
+		return BadRequest(c, errors.New("invalid timezone"))
+	}
+
+	schedule, err := services.GetWeeklySchedule(location)
+	if err != nil {
+		return InternalServerError(c, err)
+	}
+
+	return c.JSON(schedule)
+}
+
+func GetUpcomingSchedule(c *fiber.Ctx) error {
+	location, err := time.LoadLocation(meta.Request(c).Default("UTC").Query("tz"))
+	if err != nil {
+		return BadRequest(c, errors.New("invalid timezone"))
+	}
+
+	hours, err := strconv.Atoi(meta.Request(c).Default(strconv.Itoa(defaultUpcomingHours)).Query("hours"))
+	if err != nil || hours < 1 || hours > maxUpcomingHours {
+		return BadRequest(c, fmt.Errorf("hours must be between 1 and %d", maxUpcomingHours))
+	}
+
+	schedule, err := services.GetUpcomingSchedule(hours, location)
+	if err != nil {
+		return InternalServerError(c, err)
+	}
+
+	return c.JSON(schedule)
+}
+
+func GetScheduleCalendar(c *fiber.Ctx) error {
+	idsString, ok := meta.Request(c).Query("ids")
+	if !ok || idsString == "" {
+		return BadRequest(c, errors.New("ids is required"))
+	}
+
+	var malIDs []int
+	for _, idString := range strings.Split(idsString, ",") {
+		malID, err := strconv.Atoi(strings.TrimSpace(idString))
+		if err != nil {
+			return BadRequest(c, errors.New("ids must be a comma separated list of MAL IDs"))
+		}
+		malIDs = append(malIDs, malID)
+	}
+
+	if len(malIDs) > maxCalendarAnime {
+		return BadRequest(c, fmt.Errorf("at most %d ids are allowed", maxCalendarAnime))
+	}
+
+	anime, err := repositories.GetAnimeWithScheduleByMALIDs(malIDs)
+	if err != nil {
+		return InternalServerError(c, err)
+	}
+
+	return sendCalendar(c, services.BuildScheduleCalendar("Anime Schedule", anime))
+}
+
+func GetAnimeScheduleCalendar(c *fiber.Ctx) error {
+	id := meta.Request(c).MustHave().Param("id")
+	provider, err := parseProvider(c)
+	if err != nil {
+		return BadRequest(c, err)
+	}
+
+	anime, err := repositories.GetAnime(provider, id)
+	if err != nil {
+		return NotFound(c, err)
+	}
+
+	name := anime.Title.English
+	if name == "" {
+		name = anime.Title.Romaji
+	}
+
+	return sendCalendar(c, services.BuildScheduleCalendar(name, []entities.Anime{anime}))
+}
+
+func sendCalendar(c *fiber.Ctx, calendar ical.Calendar) error {
+	c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, `inline; filename="schedule.ics"`)
+	return c.SendString(calendar.String())
+}
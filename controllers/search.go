@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"errors"
+	"metachan/entities"
+	"metachan/repositories"
+	"metachan/types"
+	"metachan/utils/meta"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SearchAnime(c *fiber.Ctx) error {
+	query := strings.TrimSpace(meta.Request(c).Default("").Query("q"))
+	if query == "" {
+		return BadRequest(c, errors.New("q is required"))
+	}
+
+	page, limit, err := parsePagination(c)
+	if err != nil {
+		return BadRequest(c, err)
+	}
+
+	filters := types.AnimeSearchFilters{
+		Type:   meta.Request(c).Default("").Query("type"),
+		Status: meta.Request(c).Default("").Query("status"),
+		Season: meta.Request(c).Default("").Query("season"),
+		Genre:  meta.Request(c).Default("").Query("genre"),
+	}
+
+	if yearString, ok := meta.Request(c).Query("year"); ok {
+		year, parseErr := strconv.Atoi(yearString)
+		if parseErr != nil {
+			return BadRequest(c, errors.New("invalid year"))
+		}
+		filters.Year = year
+	}
+
+	results, total, err := repositories.SearchAnime(query, filters, page, limit)
+	if err != nil {
+		return InternalServerError(c, err)
+	}
+
+	return c.JSON(types.PaginatedResponse[entities.Anime]{
+		Pagination: types.NewPagination(page, limit, total),
+		Data:       results,
+	})
+}
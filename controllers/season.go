@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"metachan/enums"
+	"metachan/services"
+	"metachan/types"
+	"metachan/utils/meta"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	minSeasonYear = 1900
+	// seasonYearsAhead allows next year's announced seasons and nothing
+	// further, so that made-up years are not sent on to Jikan.
+	seasonYearsAhead = 1
+)
+
+func GetSeason(c *fiber.Ctx) error {
+	maxYear := time.Now().UTC().Year() + seasonYearsAhead
+	year, err := strconv.Atoi(meta.Request(c).MustHave().Param("year"))
+	if err != nil || year < minSeasonYear || year > maxYear {
+		return BadRequest(c, fmt.Errorf("year must be between %d and %d", minSeasonYear, maxYear))
+	}
+
+	season := enums.AnimeSeason(strings.ToLower(meta.Request(c).MustHave().Param("season")))
+	switch season {
+	case enums.Winter, enums.Spring, enums.Summer, enums.Fall:
+	default:
+		return BadRequest(c, errors.New("season must be one of winter, spring, summer or fall"))
+	}
+
+	return sendSeasonChart(c, year, season)
+}
+
+func GetCurrentSeason(c *fiber.Ctx) error {
+	year, season := services.CurrentSeason(time.Now().UTC())
+	return sendSeasonChart(c, year, season)
+}
+
+func GetNextSeason(c *fiber.Ctx) error {
+	year, season := services.NextSeason(services.CurrentSeason(time.Now().UTC()))
+	return sendSeasonChart(c, year, season)
+}
+
+func sendSeasonChart(c *fiber.Ctx, year int, season enums.AnimeSeason) error {
+	options := types.SeasonChartOptions{
+		Type: meta.Request(c).Default("").Query("type"),
+	}
+
+	switch meta.Request(c).Default("include").Query("continuing") {
+	case "include":
+		options.IncludeContinuing = true
+	case "exclude":
+		options.IncludeContinuing = false
+	default:
+		return BadRequest(c, errors.New("continuing must be either include or exclude"))
+	}
+
+	options.Sort = enums.AnimeSort(meta.Request(c).Default(string(enums.SortByMembers)).Query("sort"))
+	switch options.Sort {
+	case enums.SortByMembers, enums.SortByScore, enums.SortByStartDate:
+	default:
+		return BadRequest(c, errors.New("sort must be one of members, score or start_date"))
+	}
+
+	page, limit, err := parsePagination(c)
+	if err != nil {
+		return BadRequest(c, err)
+	}
+	options.Page, options.Limit = page, limit
+
+	chart, err := services.GetSeasonChart(c.UserContext(), year, season, options)
+	if err != nil {
+		return InternalServerError(c, err)
+	}
+
+	return c.JSON(chart)
+}
@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"metachan/entities"
+	"metachan/tasks"
+	"metachan/types"
+	"metachan/utils/meta"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// progressKeepAlive is how often an idle progress stream sends a comment, so
+// that proxies keep the connection open and closed clients are noticed.
+const progressKeepAlive = 15 * time.Second
+
+func GetTasks(c *fiber.Ctx) error {
+	return c.JSON(tasks.GlobalTaskManager.GetAllTaskStatuses())
+}
+
+func GetTaskRuns(c *fiber.Ctx) error {
+	name := meta.Request(c).MustHave().Param("name")
+
+	page, limit, err := parsePagination(c)
+	if err != nil {
+		return BadRequest(c, err)
+	}
+
+	runs, total, err := tasks.GlobalTaskManager.GetTaskRuns(name, page, limit)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.JSON(types.PaginatedResponse[entities.TaskLog]{
+		Pagination: types.NewPagination(page, limit, total),
+		Data:       runs,
+	})
+}
+
+// StreamTaskProgress sends the progress of running tasks as Server-Sent
+// Events. The runs already in progress are sent first, then every update
+// until the client disconnects. ?task= limits the stream to one task.
+func StreamTaskProgress(c *fiber.Ctx) error {
+	name := meta.Request(c).Default("").Query("task")
+	if name != "" && !tasks.GlobalTaskManager.GetTaskStatus(name).Registered {
+		return NotFound(c, tasks.ErrTaskNotFound)
+	}
+
+	updates, unsubscribe := tasks.GlobalTaskManager.Subscribe()
+	running := tasks.GlobalTaskManager.RunningProgress()
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+
+		for _, progress := range running {
+			if name != "" && progress.Task != name {
+				continue
+			}
+			if err := writeProgressEvent(w, progress); err != nil {
+				return
+			}
+		}
+
+		keepAlive := time.NewTicker(progressKeepAlive)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case progress, open := <-updates:
+				if !open {
+					return
+				}
+				if name != "" && progress.Task != name {
+					continue
+				}
+				if err := writeProgressEvent(w, progress); err != nil {
+					return
+				}
+			case <-keepAlive.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	})
+
+	return nil
+}
+
+func writeProgressEvent(w *bufio.Writer, progress types.TaskProgress) error {
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "event: progress\ndata: %s\n\n", data)
+	return w.Flush()
+}
+
+func RunTask(c *fiber.Ctx) error {
+	name := meta.Request(c).MustHave().Param("name")
+
+	runID, err := tasks.GlobalTaskManager.TriggerNow(name)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(types.TaskRunResponse{
+		Task:  name,
+		RunID: runID,
+	})
+}
+
+func PauseTask(c *fiber.Ctx) error {
+	name := meta.Request(c).MustHave().Param("name")
+
+	if err := tasks.GlobalTaskManager.Pause(name); err != nil {
+		return taskError(c, err)
+	}
+
+	return c.JSON(tasks.GlobalTaskManager.GetTaskStatus(name))
+}
+
+func ResumeTask(c *fiber.Ctx) error {
+	name := meta.Request(c).MustHave().Param("name")
+
+	if err := tasks.GlobalTaskManager.Resume(name); err != nil {
+		return taskError(c, err)
+	}
+
+	return c.JSON(tasks.GlobalTaskManager.GetTaskStatus(name))
+}
+
+func taskError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, tasks.ErrTaskNotFound):
+		return NotFound(c, err)
+	case errors.Is(err, tasks.ErrTaskRunning):
+		return Conflict(c, err)
+	case errors.Is(err, tasks.ErrShuttingDown):
+		return ServiceUnavailable(c, err)
+	default:
+		return InternalServerError(c, err)
+	}
+}
@@ -9,6 +9,7 @@ func migrate() {
 	err := DB.AutoMigrate(
 		&entities.TaskLog{},
 		&entities.TaskStatus{},
+		&entities.SyncJob{},
 		&entities.Mapping{},
 		&entities.ExternalURL{},
 		&entities.SimpleTitle{},
@@ -22,10 +23,15 @@ func migrate() {
 		&entities.StreamingSource{},
 		&entities.EpisodeSchedule{},
 		&entities.Season{},
+		&entities.AnimeThemeSong{},
+		&entities.AnimeVideo{},
+		&entities.AnimeLink{},
+		&entities.AnimeRelation{},
 		&entities.Character{},
 		&entities.Person{},
 		&entities.AnimeCharacter{},
 		&entities.CharacterVoiceActor{},
+		&entities.AnimeStaff{},
 		&entities.CharacterAnimeAppearance{},
 		&entities.PersonVoiceRole{},
 		&entities.PersonAnimeCredit{},
@@ -35,5 +41,7 @@ func migrate() {
 		logger.Fatalf("Database", "Error during database migration: %v", err)
 	}
 
+	migrateSearch()
+
 	logger.Successf("Database", "Database migration completed successfully")
 }
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"metachan/config"
+	"metachan/enums"
+	"metachan/utils/logger"
+)
+
+const (
+	SearchTable = "anime_search"
+
+	// SearchDocument must stay identical to the expression used by repositories
+	// when querying, otherwise Postgres will not use the GIN index.
+	SearchDocument = "to_tsvector('simple', coalesce(title_romaji, '') || ' ' || coalesce(title_english, '') || ' ' || coalesce(title_japanese, '') || ' ' || coalesce(title_synonyms, ''))"
+)
+
+// SearchEngine is the full-text backend selected for the configured driver.
+// Drivers without native support, and SQLite builds compiled without FTS5,
+// fall back to the in-process title index.
+var SearchEngine = enums.SearchMemory
+
+func migrateSearch() {
+	switch enums.DatabaseDriver(config.Database.Driver) {
+	case enums.SQLite:
+		migrateFTS5()
+	case enums.Postgres:
+		migrateTSVector()
+	}
+
+	logger.Infof("Database", "Anime search engine: %s", SearchEngine)
+}
+
+func migrateFTS5() {
+	err := DB.Exec("CREATE VIRTUAL TABLE IF NOT EXISTS " + SearchTable + " USING fts5(romaji, english, japanese, synonyms, tokenize = 'unicode61 remove_diacritics 2')").Error
+	if err != nil {
+		logger.Warnf("Database", "SQLite FTS5 unavailable (build with -tags sqlite_fts5), using in-process search index: %v", err)
+		return
+	}
+
+	// Titles are indexed by the repositories on save, but removals are left to
+	// triggers so that every way of deleting an anime drops its row, soft
+	// deletes included.
+	for _, trigger := range []string{
+		"CREATE TRIGGER IF NOT EXISTS " + SearchTable + "_delete AFTER DELETE ON animes BEGIN DELETE FROM " + SearchTable + " WHERE rowid = old.id; END",
+		"CREATE TRIGGER IF NOT EXISTS " + SearchTable + "_soft_delete AFTER UPDATE OF deleted_at ON animes WHEN new.deleted_at IS NOT NULL BEGIN DELETE FROM " + SearchTable + " WHERE rowid = new.id; END",
+	} {
+		if err := DB.Exec(trigger).Error; err != nil {
+			logger.Warnf("Database", "Failed to create anime search trigger: %v", err)
+			return
+		}
+	}
+
+	var indexed, total int64
+	DB.Table(SearchTable).Count(&indexed)
+	DB.Table("animes").Where("deleted_at IS NULL").Count(&total)
+
+	if indexed != total {
+		logger.Infof("Database", "Rebuilding anime search index (%d indexed, %d anime)", indexed, total)
+		if err := DB.Exec("DELETE FROM " + SearchTable).Error; err != nil {
+			logger.Warnf("Database", "Failed to clear anime search index: %v", err)
+			return
+		}
+		if err := DB.Exec("INSERT INTO " + SearchTable + " (rowid, romaji, english, japanese, synonyms) SELECT id, coalesce(title_romaji, ''), coalesce(title_english, ''), coalesce(title_japanese, ''), coalesce(title_synonyms, '') FROM animes WHERE deleted_at IS NULL").Error; err != nil {
+			logger.Warnf("Database", "Failed to rebuild anime search index: %v", err)
+			return
+		}
+	}
+
+	SearchEngine = enums.SearchFTS5
+}
+
+func migrateTSVector() {
+	if err := DB.Exec("CREATE INDEX IF NOT EXISTS idx_animes_title_search ON animes USING GIN (" + SearchDocument + ")").Error; err != nil {
+		logger.Warnf("Database", "Failed to create tsvector index, using in-process search index: %v", err)
+		return
+	}
+
+	SearchEngine = enums.SearchTSVector
+}
@@ -1,6 +1,7 @@
 package entities
 
 import (
+	"metachan/enums"
 	"time"
 )
 
@@ -81,6 +82,10 @@ type Anime struct {
 	LastUpdated time.Time  `json:"-"`
 	EnrichedAt  *time.Time `json:"-"`
 
+	// MissingSources lists the upstreams that failed during the last fetch.
+	// An anime with any is partial and is refreshed on its next request.
+	MissingSources []enums.Upstream `gorm:"serializer:json" json:"missing_sources,omitempty"`
+
 	Title     AnimeTitle     `gorm:"embedded;embeddedPrefix:title_" json:"titles"`
 	Scores    AnimeScores    `gorm:"embedded;embeddedPrefix:score_" json:"scores"`
 	Images    AnimeImages    `gorm:"embedded;embeddedPrefix:image_" json:"images"`
@@ -100,5 +105,10 @@ type Anime struct {
 	Licensors    []Producer        `gorm:"many2many:anime_licensors;" json:"licensors,omitempty"`
 	Episodes     []Episode         `gorm:"foreignKey:AnimeID" json:"episodes,omitempty"`
 	Characters   []Character       `gorm:"-" json:"characters,omitempty"`
+	Staff        []AnimeStaff      `gorm:"-" json:"-"`
 	Schedule     []EpisodeSchedule `gorm:"foreignKey:AnimeID;constraint:OnDelete:CASCADE" json:"airing_schedule,omitempty"`
+	ThemeSongs   []AnimeThemeSong  `gorm:"foreignKey:AnimeID;constraint:OnDelete:CASCADE" json:"theme_songs,omitempty"`
+	Videos       []AnimeVideo      `gorm:"foreignKey:AnimeID;constraint:OnDelete:CASCADE" json:"videos,omitempty"`
+	Links        []AnimeLink       `gorm:"foreignKey:AnimeID;constraint:OnDelete:CASCADE" json:"links,omitempty"`
+	Relations    []AnimeRelation   `gorm:"foreignKey:AnimeID;constraint:OnDelete:CASCADE" json:"relations,omitempty"`
 }
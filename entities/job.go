@@ -0,0 +1,22 @@
+package entities
+
+import (
+	"metachan/enums"
+	"time"
+)
+
+// SyncJob is one item of work for a sync task, such as fetching an anime.
+// IdempotencyKey is "<kind>:<MAL ID>" and unique, so queueing the same item twice is a
+// no-op. A failed job goes back to pending with NextAttemptAt pushed out, and
+// is dead-lettered once it runs out of attempts.
+type SyncJob struct {
+	BaseModel
+	IdempotencyKey string          `gorm:"uniqueIndex;not null" json:"key"`
+	Kind           enums.JobKind   `gorm:"index:idx_sync_jobs_queue,priority:1;not null" json:"kind"`
+	MALID          int             `json:"mal_id"`
+	Status         enums.JobStatus `gorm:"index:idx_sync_jobs_queue,priority:2;not null" json:"status"`
+	Attempts       int             `json:"attempts"`
+	LastError      string          `json:"last_error,omitempty"`
+	NextAttemptAt  time.Time       `gorm:"index:idx_sync_jobs_queue,priority:3" json:"next_attempt_at"`
+	CompletedAt    *time.Time      `json:"completed_at,omitempty"`
+}
@@ -0,0 +1,41 @@
+package entities
+
+import (
+	"metachan/enums"
+)
+
+type ThemeSongLink struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+type AnimeThemeSong struct {
+	BaseModel
+	AnimeID       uint                `gorm:"index" json:"-"`
+	Type          enums.ThemeSongType `json:"type"`
+	Position      int                 `json:"position"`
+	Title         string              `json:"title,omitempty"`
+	TitleJapanese string              `json:"title_japanese,omitempty"`
+	Artist        string              `json:"artist,omitempty"`
+	EpisodeStart  int                 `json:"episode_start,omitempty"`
+	EpisodeEnd    int                 `json:"episode_end,omitempty"`
+	Links         []ThemeSongLink     `gorm:"serializer:json" json:"links,omitempty"`
+}
+
+type AnimeVideo struct {
+	BaseModel
+	AnimeID   uint                 `gorm:"index" json:"-"`
+	Type      enums.AnimeVideoType `json:"type"`
+	Title     string               `json:"title,omitempty"`
+	Artist    string               `json:"artist,omitempty"`
+	URL       string               `json:"url"`
+	Thumbnail AnimeImages          `gorm:"embedded;embeddedPrefix:thumbnail_" json:"thumbnail"`
+}
+
+type AnimeLink struct {
+	BaseModel
+	AnimeID uint                `gorm:"index" json:"-"`
+	Type    enums.AnimeLinkType `json:"type"`
+	Name    string              `json:"name"`
+	URL     string              `json:"url"`
+}
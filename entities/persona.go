@@ -95,3 +95,13 @@ type CharacterVoiceActor struct {
 	Language    string  `json:"language,omitempty"`
 	Person      *Person `gorm:"foreignKey:PersonID;references:ID" json:"person,omitempty"`
 }
+
+// AnimeStaff credits a person on an anime with every position MAL lists for
+// them there, in the order of the anime's staff page.
+type AnimeStaff struct {
+	BaseModel
+	AnimeID   uint     `gorm:"index" json:"-"`
+	PersonID  uint     `gorm:"index" json:"-"`
+	Positions []string `gorm:"serializer:json" json:"positions,omitempty"`
+	Person    *Person  `gorm:"foreignKey:PersonID;references:ID" json:"person,omitempty"`
+}
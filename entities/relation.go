@@ -0,0 +1,20 @@
+package entities
+
+import (
+	"metachan/enums"
+)
+
+// AnimeRelation is an entry MAL lists under Related Entries. MALID belongs to
+// the related anime or manga, as told by Media, and Format is the type MAL
+// shows for it, such as Movie or Light Novel.
+type AnimeRelation struct {
+	BaseModel
+	AnimeID  uint                    `gorm:"index" json:"-"`
+	Relation enums.AnimeRelationType `json:"relation"`
+	Media    enums.RelatedMediaType  `json:"media"`
+	MALID    int                     `gorm:"index" json:"mal_id"`
+	Title    string                  `json:"title,omitempty"`
+	Format   string                  `json:"format,omitempty"`
+	URL      string                  `json:"url,omitempty"`
+	Image    AnimeImages             `gorm:"embedded;embeddedPrefix:image_" json:"image"`
+}
@@ -1,20 +1,32 @@
 package entities
 
 import (
+	"metachan/enums"
 	"time"
 )
 
+// TaskLog records one run of a task. It is written when the run starts and
+// updated when it ends, at which point ExecutedAt moves to the finish time.
+// The progress counters are saved periodically while the run is going.
 type TaskLog struct {
 	BaseModel
-	TaskName   string    `gorm:"index" json:"task_name,omitempty"`
-	Status     string    `json:"status,omitempty"`
-	Message    string    `json:"message,omitempty"`
-	ExecutedAt time.Time `json:"executed_at,omitempty"`
+	RunID       string              `gorm:"index" json:"run_id,omitempty"`
+	TaskName    string              `gorm:"index" json:"task_name,omitempty"`
+	Trigger     enums.TaskTrigger   `json:"trigger,omitempty"`
+	Status      enums.TaskRunStatus `json:"status,omitempty"`
+	Message     string              `json:"message,omitempty"`
+	Processed   int                 `json:"processed"`
+	Total       int                 `json:"total"`
+	Failed      int                 `json:"failed"`
+	CurrentItem string              `json:"current_item,omitempty"`
+	StartedAt   time.Time           `json:"started_at,omitempty"`
+	ExecutedAt  time.Time           `json:"executed_at,omitempty"`
 }
 
 type TaskStatus struct {
 	BaseModel
 	TaskName    string    `gorm:"uniqueIndex;not null" json:"task_name"`
 	IsCompleted bool      `gorm:"default:false" json:"is_completed,omitempty"`
+	IsPaused    bool      `gorm:"default:false" json:"is_paused,omitempty"`
 	LastRunAt   time.Time `json:"last_run_at,omitempty"`
 }
@@ -0,0 +1,46 @@
+package enums
+
+type ThemeSongType string
+
+const (
+	Opening ThemeSongType = "opening"
+	Ending  ThemeSongType = "ending"
+)
+
+type AnimeVideoType string
+
+const (
+	PromotionalVideo AnimeVideoType = "promotional"
+	MusicVideo       AnimeVideoType = "music"
+)
+
+type AnimeLinkType string
+
+const (
+	ExternalLink  AnimeLinkType = "external"
+	StreamingLink AnimeLinkType = "streaming"
+)
+
+type AnimeRelationType string
+
+const (
+	Sequel             AnimeRelationType = "sequel"
+	Prequel            AnimeRelationType = "prequel"
+	AlternativeSetting AnimeRelationType = "alternative_setting"
+	AlternativeVersion AnimeRelationType = "alternative_version"
+	SideStory          AnimeRelationType = "side_story"
+	ParentStory        AnimeRelationType = "parent_story"
+	Summary            AnimeRelationType = "summary"
+	FullStory          AnimeRelationType = "full_story"
+	SpinOff            AnimeRelationType = "spin_off"
+	Adaptation         AnimeRelationType = "adaptation"
+	CharacterRelation  AnimeRelationType = "character"
+	OtherRelation      AnimeRelationType = "other"
+)
+
+type RelatedMediaType string
+
+const (
+	RelatedAnime RelatedMediaType = "anime"
+	RelatedManga RelatedMediaType = "manga"
+)
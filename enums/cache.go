@@ -0,0 +1,9 @@
+package enums
+
+type CacheStatus string
+
+const (
+	CacheHit   CacheStatus = "hit"
+	CacheStale CacheStatus = "stale"
+	CacheMiss  CacheStatus = "miss"
+)
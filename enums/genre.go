@@ -0,0 +1,10 @@
+package enums
+
+type GenreType string
+
+const (
+	GenreTypeGenre         GenreType = "genre"
+	GenreTypeExplicitGenre GenreType = "explicit_genre"
+	GenreTypeTheme         GenreType = "theme"
+	GenreTypeDemographic   GenreType = "demographic"
+)
@@ -0,0 +1,19 @@
+package enums
+
+type JobKind string
+
+const (
+	JobAnime     JobKind = "anime"
+	JobCharacter JobKind = "character"
+	JobPerson    JobKind = "person"
+	JobProducer  JobKind = "producer"
+)
+
+type JobStatus string
+
+const (
+	JobPending JobStatus = "pending"
+	JobRunning JobStatus = "running"
+	JobDone    JobStatus = "done"
+	JobDead    JobStatus = "dead"
+)
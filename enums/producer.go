@@ -0,0 +1,9 @@
+package enums
+
+type ProducerRole string
+
+const (
+	ProducerRoleProducer ProducerRole = "producer"
+	ProducerRoleStudio   ProducerRole = "studio"
+	ProducerRoleLicensor ProducerRole = "licensor"
+)
@@ -0,0 +1,9 @@
+package enums
+
+type SearchEngine string
+
+const (
+	SearchFTS5     SearchEngine = "fts5"
+	SearchTSVector SearchEngine = "tsvector"
+	SearchMemory   SearchEngine = "memory"
+)
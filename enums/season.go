@@ -0,0 +1,10 @@
+package enums
+
+type AnimeSeason string
+
+const (
+	Winter AnimeSeason = "winter"
+	Spring AnimeSeason = "spring"
+	Summer AnimeSeason = "summer"
+	Fall   AnimeSeason = "fall"
+)
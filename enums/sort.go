@@ -0,0 +1,11 @@
+package enums
+
+type AnimeSort string
+
+const (
+	SortByScore      AnimeSort = "score"
+	SortByPopularity AnimeSort = "popularity"
+	SortByYear       AnimeSort = "year"
+	SortByMembers    AnimeSort = "members"
+	SortByStartDate  AnimeSort = "start_date"
+)
@@ -0,0 +1,21 @@
+package enums
+
+type TaskRunStatus string
+
+const (
+	TaskRunRunning   TaskRunStatus = "running"
+	TaskRunSuccess   TaskRunStatus = "success"
+	TaskRunError     TaskRunStatus = "error"
+	TaskRunCancelled TaskRunStatus = "cancelled"
+)
+
+type TaskTrigger string
+
+const (
+	TaskTriggerSchedule   TaskTrigger = "schedule"
+	TaskTriggerManual     TaskTrigger = "manual"
+	TaskTriggerDependency TaskTrigger = "dependency"
+	// TaskTriggerResume marks a run of OnResume that picks up the work left
+	// by the previous process. It does not count as a run of the task itself.
+	TaskTriggerResume TaskTrigger = "resume"
+)
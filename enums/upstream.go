@@ -0,0 +1,34 @@
+package enums
+
+// Upstream names a provider that anime data is fetched from. It labels the
+// circuit breakers and the sources a partially fetched anime is missing.
+type Upstream string
+
+const (
+	UpstreamJikan     Upstream = "jikan"
+	UpstreamAnilist   Upstream = "anilist"
+	UpstreamMAL       Upstream = "mal"
+	UpstreamMALsync   Upstream = "malsync"
+	UpstreamTMDB      Upstream = "tmdb"
+	UpstreamTVDB      Upstream = "tvdb"
+	UpstreamAniskip   Upstream = "aniskip"
+	UpstreamStreaming Upstream = "streaming"
+)
+
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+// HTTPMode decides whether upstream clients talk to the network, record what
+// they receive as cassettes, or answer only from recorded cassettes.
+type HTTPMode string
+
+const (
+	HTTPLive   HTTPMode = "live"
+	HTTPRecord HTTPMode = "record"
+	HTTPReplay HTTPMode = "replay"
+)
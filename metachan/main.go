@@ -1,25 +1,34 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"metachan/config"
 	"metachan/database"
 	"metachan/middleware"
 	"metachan/router"
+	"metachan/services"
 	"metachan/tasks"
-	"metachan/utils/api/aniskip"
-	"metachan/utils/mal"
 	"metachan/utils/logger"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/helmet"
 )
 
+// shutdownTimeout bounds how long in-flight requests and task runs get to
+// finish once a stop signal arrives.
+const shutdownTimeout = 30 * time.Second
+
 func main() {
+	if err := config.VerifyAPIKeys(); err != nil {
+		logger.Fatalf("Config", "Configuration verification failed: %v", err)
+	}
+
 	tasks.GlobalTaskManager.StartAllTasks()
 
 	app := fiber.New(fiber.Config{
@@ -51,13 +60,24 @@ func main() {
 	<-quit
 	logger.Infof("Main", "Shutting down gracefully...")
 
-	if err := app.Shutdown(); err != nil {
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	// Tasks stop first so that progress streams end and do not hold the
+	// server open until the deadline.
+	if err := tasks.GlobalTaskManager.StopAllTasks(shutdownCtx); err != nil {
+		logger.Warnf("Main", "Tasks did not stop within %v: %v", shutdownTimeout, err)
+	}
+
+	if err := app.ShutdownWithContext(shutdownCtx); err != nil {
 		logger.Errorf("Main", "Error during server shutdown: %v", err)
 	}
 
-	tasks.GlobalTaskManager.StopAllTasks()
-	mal.StopRateLimiters()
-	aniskip.StopRateLimiters()
+	// Anime fetches that outlived their requests still write to the
+	// database, so they have to finish before it is closed.
+	if err := services.Shutdown(shutdownCtx); err != nil {
+		logger.Warnf("Main", "Background fetches did not stop within %v: %v", shutdownTimeout, err)
+	}
 
 	if sqlDB, err := database.DB.DB(); err == nil {
 		sqlDB.Close()
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"errors"
+	"metachan/config"
+	"metachan/controllers"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AdminAuth protects the admin routes with the ADMIN_TOKEN bearer token. The
+// routes are disabled altogether while no token is configured.
+func AdminAuth() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if config.Server.AdminToken == "" {
+			return controllers.Forbidden(c, errors.New("admin API is disabled, set ADMIN_TOKEN to enable it"))
+		}
+
+		token, found := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(token), []byte(config.Server.AdminToken)) != 1 {
+			return controllers.Unauthorized(c, errors.New("invalid admin token"))
+		}
+
+		return c.Next()
+	}
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"metachan/utils/meta"
+	"metachan/utils/ratelimit"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -12,6 +13,7 @@ func request() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		req := meta.BuildRequest(c)
 		c.Locals(requestKey, req)
+		c.SetUserContext(ratelimit.WithPriority(c.UserContext(), ratelimit.Interactive))
 		return c.Next()
 	}
 }
@@ -45,6 +45,12 @@ func GetAnime[T idType](maptype enums.MappingType, id T) (entities.Anime, error)
 		Preload("Episodes.StreamInfo.DubSources").
 		Preload("Schedule").
 		Preload("Seasons").
+		Preload("ThemeSongs", func(db *gorm.DB) *gorm.DB {
+			return db.Order("type, position")
+		}).
+		Preload("Videos").
+		Preload("Links").
+		Preload("Relations").
 		Where("mapping_id = ?", mapping.ID).
 		First(&anime)
 
@@ -77,12 +83,18 @@ func CreateOrUpdateAnime(anime *entities.Anime) error {
 
 	result = DB.Session(&gorm.Session{FullSaveAssociations: true}).Clauses(clause.OnConflict{
 		UpdateAll: true,
-	}).Omit("Characters", "Episodes").Save(anime)
+	}).Omit("Characters", "Episodes", "Schedule", "ThemeSongs", "Videos", "Links", "Relations").Save(anime)
 
 	if result.Error != nil {
 		return fmt.Errorf("failed to save anime: %w", result.Error)
 	}
 
+	if err := ReplaceAnimeSchedule(anime.ID, anime.Schedule); err != nil {
+		logger.Warnf("Anime", "Failed to save schedule for anime (MAL ID: %d): %v", anime.MALID, err)
+	}
+
+	IndexAnimeTitles(anime)
+
 	logger.Infof("Anime", "Saved anime (MAL ID: %d) with %d episodes, %d characters", anime.MALID, len(anime.Episodes), len(anime.Characters))
 	return nil
 }
@@ -202,7 +214,7 @@ func SaveEpisodeStreamInfo(animeID uint, episodeID string, info *entities.Stream
 
 func GetAllAnimeStubs() ([]animeStub, error) {
 	var stubs []animeStub
-	if err := DB.Model(&entities.Anime{}).Select("mal_id, updated_at, enriched_at").Scan(&stubs).Error; err != nil {
+	if err := DB.Model(&entities.Anime{}).Select("mal_id, airing, status, updated_at, enriched_at").Scan(&stubs).Error; err != nil {
 		return nil, err
 	}
 	return stubs, nil
@@ -228,3 +240,19 @@ func GetAiringAnime() ([]entities.Anime, error) {
 
 	return anime, nil
 }
+
+// GetAnimeByMALIDs returns the anime stored for malIDs, without their
+// associations.
+func GetAnimeByMALIDs(malIDs []int) ([]entities.Anime, error) {
+	var anime []entities.Anime
+	if len(malIDs) == 0 {
+		return anime, nil
+	}
+
+	if err := DB.Where("mal_id IN ?", malIDs).Find(&anime).Error; err != nil {
+		logger.Errorf("Anime", "Failed to get %d anime by MAL ID: %v", len(malIDs), err)
+		return nil, errors.New("failed to fetch anime")
+	}
+
+	return anime, nil
+}
@@ -3,15 +3,26 @@ package repositories
 import (
 	"errors"
 	"metachan/entities"
+	"metachan/enums"
+	"metachan/types"
 	"metachan/utils/logger"
+	"strconv"
 
+	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
 func CreateOrUpdateGenre(genre *entities.Genre) error {
+	// Genres attached to an anime carry no count, so only the genre sync may
+	// overwrite it.
+	columns := []string{"name", "url", "type"}
+	if genre.Count > 0 {
+		columns = append(columns, "count")
+	}
+
 	result := DB.Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "genre_id"}},
-		DoUpdates: clause.AssignmentColumns([]string{"name", "url", "count"}),
+		DoUpdates: clause.AssignmentColumns(columns),
 	}).Create(genre)
 
 	if result.Error != nil {
@@ -21,3 +32,41 @@ func CreateOrUpdateGenre(genre *entities.Genre) error {
 
 	return nil
 }
+
+func GetGenres() ([]entities.Genre, error) {
+	var genres []entities.Genre
+
+	result := DB.Order("name").Find(&genres)
+	if result.Error != nil {
+		logger.Errorf("Genre", "Failed to fetch genres: %v", result.Error)
+		return nil, errors.New("failed to fetch genres")
+	}
+
+	return genres, nil
+}
+
+func GetAnimeByGenre(genreID int, sort enums.AnimeSort, page, limit int) ([]entities.Anime, int64, error) {
+	query := filterAnime(DB.Model(&entities.Anime{}), types.AnimeSearchFilters{Genre: strconv.Itoa(genreID)}).
+		Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logger.Errorf("Genre", "Failed to count anime for genre %d: %v", genreID, err)
+		return nil, 0, errors.New("failed to fetch anime by genre")
+	}
+
+	var anime []entities.Anime
+	if err := orderAnime(query, sort).
+		Preload("Mapping").
+		Preload("Genres").
+		Preload("Themes").
+		Preload("Demographics").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&anime).Error; err != nil {
+		logger.Errorf("Genre", "Failed to fetch anime for genre %d: %v", genreID, err)
+		return nil, 0, errors.New("failed to fetch anime by genre")
+	}
+
+	return anime, total, nil
+}
@@ -0,0 +1,222 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"metachan/entities"
+	"metachan/enums"
+	"metachan/utils/logger"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const jobBatchSize = 500
+
+func JobKey(kind enums.JobKind, malID int) string {
+	return fmt.Sprintf("%s:%d", kind, malID)
+}
+
+// EnqueueJobs queues one job per MAL ID. IDs that already have a pending or
+// running job are left alone. Finished and dead-lettered jobs are queued again
+// with fresh attempts, so that a later pass recovers items lost to an outage.
+func EnqueueJobs(kind enums.JobKind, malIDs []int) error {
+	if len(malIDs) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	seen := make(map[int]bool, len(malIDs))
+	jobs := make([]entities.SyncJob, 0, len(malIDs))
+	keys := make([]string, 0, len(malIDs))
+	for _, malID := range malIDs {
+		if seen[malID] {
+			continue
+		}
+		seen[malID] = true
+
+		key := JobKey(kind, malID)
+		keys = append(keys, key)
+		jobs = append(jobs, entities.SyncJob{
+			IdempotencyKey: key,
+			Kind:           kind,
+			MALID:          malID,
+			Status:         enums.JobPending,
+			NextAttemptAt:  now,
+		})
+	}
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "idempotency_key"}},
+			DoNothing: true,
+		}).CreateInBatches(&jobs, jobBatchSize).Error; err != nil {
+			return err
+		}
+
+		for start := 0; start < len(keys); start += jobBatchSize {
+			batch := keys[start:min(start+jobBatchSize, len(keys))]
+			if err := tx.Model(&entities.SyncJob{}).
+				Where("idempotency_key IN ? AND status IN ?", batch, []enums.JobStatus{enums.JobDone, enums.JobDead}).
+				Updates(map[string]any{
+					"status":          enums.JobPending,
+					"attempts":        0,
+					"last_error":      "",
+					"next_attempt_at": now,
+					"completed_at":    nil,
+				}).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		logger.Errorf("Jobs", "Failed to enqueue %s jobs: %v", kind, err)
+		return errors.New("failed to enqueue jobs")
+	}
+
+	return nil
+}
+
+func CountDueJobs(kind enums.JobKind) (int64, error) {
+	var count int64
+	if err := DB.Model(&entities.SyncJob{}).
+		Where("kind = ? AND status = ? AND next_attempt_at <= ?", kind, enums.JobPending, time.Now()).
+		Count(&count).Error; err != nil {
+		logger.Errorf("Jobs", "Failed to count due %s jobs: %v", kind, err)
+		return 0, errors.New("failed to count jobs")
+	}
+
+	return count, nil
+}
+
+func GetDueJobs(kind enums.JobKind, limit int) ([]entities.SyncJob, error) {
+	var jobs []entities.SyncJob
+	if err := DB.
+		Where("kind = ? AND status = ? AND next_attempt_at <= ?", kind, enums.JobPending, time.Now()).
+		Order("next_attempt_at").
+		Order("id").
+		Limit(limit).
+		Find(&jobs).Error; err != nil {
+		logger.Errorf("Jobs", "Failed to fetch due %s jobs: %v", kind, err)
+		return nil, errors.New("failed to fetch jobs")
+	}
+
+	return jobs, nil
+}
+
+// ClaimJob marks a pending job as running and counts the attempt. It reports
+// false when another consumer claimed the job first.
+func ClaimJob(job *entities.SyncJob) (bool, error) {
+	result := DB.Model(&entities.SyncJob{}).
+		Where("id = ? AND status = ?", job.ID, enums.JobPending).
+		Updates(map[string]any{
+			"status":   enums.JobRunning,
+			"attempts": gorm.Expr("attempts + 1"),
+		})
+	if result.Error != nil {
+		logger.Errorf("Jobs", "Failed to claim job %s: %v", job.IdempotencyKey, result.Error)
+		return false, errors.New("failed to claim job")
+	}
+
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	job.Status = enums.JobRunning
+	job.Attempts++
+	return true, nil
+}
+
+// UpdateJob stores the outcome of a claimed job.
+func UpdateJob(job *entities.SyncJob) error {
+	result := DB.Model(job).
+		Select("status", "attempts", "last_error", "next_attempt_at", "completed_at").
+		Updates(job)
+	if result.Error != nil {
+		logger.Errorf("Jobs", "Failed to update job %s: %v", job.IdempotencyKey, result.Error)
+		return errors.New("failed to update job")
+	}
+
+	return nil
+}
+
+// RequeueInterruptedJobs returns jobs left running by a previous process to
+// the queue. The attempt they used is not refunded, so an item that crashes
+// the process is still dead-lettered eventually.
+func RequeueInterruptedJobs() error {
+	result := DB.Model(&entities.SyncJob{}).
+		Where("status = ?", enums.JobRunning).
+		Update("status", enums.JobPending)
+	if result.Error != nil {
+		logger.Errorf("Jobs", "Failed to requeue interrupted jobs: %v", result.Error)
+		return errors.New("failed to requeue interrupted jobs")
+	}
+
+	if result.RowsAffected > 0 {
+		logger.Warnf("Jobs", "Requeued %d interrupted jobs", result.RowsAffected)
+	}
+
+	return nil
+}
+
+// RetryJob queues a job again from scratch, typically one that was
+// dead-lettered.
+func RetryJob(key string) (entities.SyncJob, error) {
+	result := DB.Model(&entities.SyncJob{}).
+		Where("idempotency_key = ? AND status <> ?", key, enums.JobRunning).
+		Updates(map[string]any{
+			"status":          enums.JobPending,
+			"attempts":        0,
+			"last_error":      "",
+			"next_attempt_at": time.Now(),
+			"completed_at":    nil,
+		})
+	if result.Error != nil {
+		logger.Errorf("Jobs", "Failed to retry job %s: %v", key, result.Error)
+		return entities.SyncJob{}, errors.New("failed to retry job")
+	}
+
+	if result.RowsAffected == 0 {
+		return entities.SyncJob{}, errors.New("job not found or running")
+	}
+
+	var job entities.SyncJob
+	if err := DB.Where("idempotency_key = ?", key).First(&job).Error; err != nil {
+		logger.Errorf("Jobs", "Failed to load job %s: %v", key, err)
+		return entities.SyncJob{}, errors.New("failed to retry job")
+	}
+
+	return job, nil
+}
+
+func GetJobs(kind enums.JobKind, status enums.JobStatus, page, limit int) ([]entities.SyncJob, int64, error) {
+	query := DB.Model(&entities.SyncJob{})
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logger.Errorf("Jobs", "Failed to count jobs: %v", err)
+		return nil, 0, errors.New("failed to fetch jobs")
+	}
+
+	var jobs []entities.SyncJob
+	if err := query.
+		Order("updated_at DESC").
+		Order("id DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&jobs).Error; err != nil {
+		logger.Errorf("Jobs", "Failed to fetch jobs: %v", err)
+		return nil, 0, errors.New("failed to fetch jobs")
+	}
+
+	return jobs, total, nil
+}
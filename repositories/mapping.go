@@ -6,14 +6,68 @@ import (
 	"metachan/entities"
 	"metachan/enums"
 	"metachan/utils/logger"
+	"strconv"
+	"strings"
 
 	"gorm.io/gorm/clause"
 )
 
+// mappingColumns maps each provider to its column on the mappings table. The
+// names differ for some providers, e.g. AniDB is stored in ani_db.
+var mappingColumns = map[enums.MappingType]string{
+	enums.AniDB:          "ani_db",
+	enums.Anilist:        "anilist",
+	enums.AnimeCountdown: "anime_countdown",
+	enums.AnimePlanet:    "anime_planet",
+	enums.AniSearch:      "ani_search",
+	enums.IMDB:           "imdb",
+	enums.Kitsu:          "kitsu",
+	enums.LiveChart:      "live_chart",
+	enums.MAL:            "mal",
+	enums.NotifyMoe:      "notify_moe",
+	enums.Simkl:          "simkl",
+	enums.TMDB:           "tmdb",
+	enums.TVDB:           "tvdb",
+}
+
+// ErrInvalidMappingID is returned for an ID that no anime can have.
+var ErrInvalidMappingID = errors.New("invalid mapping ID")
+
+// parseMappingID returns id as the provider's column stores it, so that
+// "081797" finds TVDB 81797. Unset IDs are stored as 0 or "", so empty and
+// non-positive IDs are rejected instead of matching every unmapped row.
+func parseMappingID(maptype enums.MappingType, id string) (any, error) {
+	id = strings.TrimSpace(id)
+
+	switch maptype {
+	case enums.AnimePlanet, enums.IMDB, enums.NotifyMoe:
+		if id == "" {
+			return nil, ErrInvalidMappingID
+		}
+		return id, nil
+	default:
+		number, err := strconv.Atoi(id)
+		if err != nil || number <= 0 {
+			return nil, ErrInvalidMappingID
+		}
+		return number, nil
+	}
+}
+
 func GetAnimeMapping[T idType](maptype enums.MappingType, id T) (entities.Mapping, error) {
 	var mapping entities.Mapping
 
-	result := DB.Where(fmt.Sprintf("%s = ?", maptype), id).First(&mapping)
+	column, exists := mappingColumns[maptype]
+	if !exists {
+		return entities.Mapping{}, errors.New("unsupported mapping provider")
+	}
+
+	value, err := parseMappingID(maptype, fmt.Sprint(id))
+	if err != nil {
+		return entities.Mapping{}, err
+	}
+
+	result := DB.Where(fmt.Sprintf("%s = ?", column), value).Order("mal").First(&mapping)
 
 	if result.Error != nil {
 		logger.Errorf("Mapping", "Failed to get mapping for %s with ID %v: %v", maptype, id, result.Error)
@@ -52,3 +106,105 @@ func GetAllMappings() ([]entities.Mapping, error) {
 
 	return mappings, nil
 }
+
+// GetMappings returns every mapping row for a provider ID. TVDB, TMDB and IMDb
+// IDs identify a whole series, so they usually match one row per season.
+func GetMappings(maptype enums.MappingType, id string) ([]entities.Mapping, error) {
+	column, exists := mappingColumns[maptype]
+	if !exists {
+		return nil, errors.New("unsupported mapping provider")
+	}
+
+	value, err := parseMappingID(maptype, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var mappings []entities.Mapping
+	result := DB.Where(fmt.Sprintf("%s = ?", column), value).Order("mal").Find(&mappings)
+	if result.Error != nil {
+		logger.Errorf("Mapping", "Failed to get mappings for %s with ID %s: %v", maptype, id, result.Error)
+		return nil, errors.New("failed to fetch mappings")
+	}
+
+	if len(mappings) == 0 {
+		return nil, errors.New("mapping not found")
+	}
+
+	return mappings, nil
+}
+
+// ResolveMappings looks up a batch of provider IDs in a single query and
+// groups the matching rows by the requested ID. IDs without a match map to an
+// empty slice.
+func ResolveMappings(maptype enums.MappingType, ids []string) (map[string][]entities.Mapping, error) {
+	column, exists := mappingColumns[maptype]
+	if !exists {
+		return nil, errors.New("unsupported mapping provider")
+	}
+
+	values := make([]any, len(ids))
+	for i, id := range ids {
+		value, err := parseMappingID(maptype, id)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", err, id)
+		}
+		values[i] = value
+	}
+
+	var mappings []entities.Mapping
+	result := DB.Where(fmt.Sprintf("%s IN ?", column), values).Order("mal").Find(&mappings)
+	if result.Error != nil {
+		logger.Errorf("Mapping", "Failed to resolve %d %s mappings: %v", len(ids), maptype, result.Error)
+		return nil, errors.New("failed to resolve mappings")
+	}
+
+	matches := make(map[string][]entities.Mapping, len(mappings))
+	for _, mapping := range mappings {
+		id := mappingID(&mapping, maptype)
+		matches[id] = append(matches[id], mapping)
+	}
+
+	// Results are keyed by the ID as requested, which may differ from the
+	// stored one, e.g. "081797" for TVDB 81797.
+	resolved := make(map[string][]entities.Mapping, len(ids))
+	for i, id := range ids {
+		resolved[id] = matches[fmt.Sprint(values[i])]
+		if resolved[id] == nil {
+			resolved[id] = []entities.Mapping{}
+		}
+	}
+
+	return resolved, nil
+}
+
+func mappingID(mapping *entities.Mapping, maptype enums.MappingType) string {
+	switch maptype {
+	case enums.AniDB:
+		return strconv.Itoa(mapping.AniDB)
+	case enums.Anilist:
+		return strconv.Itoa(mapping.Anilist)
+	case enums.AnimeCountdown:
+		return strconv.Itoa(mapping.AnimeCountdown)
+	case enums.AnimePlanet:
+		return mapping.AnimePlanet
+	case enums.AniSearch:
+		return strconv.Itoa(mapping.AniSearch)
+	case enums.IMDB:
+		return mapping.IMDB
+	case enums.Kitsu:
+		return strconv.Itoa(mapping.Kitsu)
+	case enums.LiveChart:
+		return strconv.Itoa(mapping.LiveChart)
+	case enums.NotifyMoe:
+		return mapping.NotifyMoe
+	case enums.Simkl:
+		return strconv.Itoa(mapping.Simkl)
+	case enums.TMDB:
+		return strconv.Itoa(mapping.TMDB)
+	case enums.TVDB:
+		return strconv.Itoa(mapping.TVDB)
+	default:
+		return strconv.Itoa(mapping.MAL)
+	}
+}
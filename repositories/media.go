@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"errors"
+	"metachan/entities"
+	"metachan/utils/logger"
+
+	"gorm.io/gorm"
+)
+
+// ReplaceAnimeMedia swaps the theme songs, videos and links of an anime for
+// the given sets. Rows are replaced wholesale because MAL offers no stable
+// identifiers for any of them.
+func ReplaceAnimeMedia(animeID uint, themeSongs []entities.AnimeThemeSong, videos []entities.AnimeVideo, links []entities.AnimeLink) error {
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("anime_id = ?", animeID).Delete(&entities.AnimeThemeSong{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("anime_id = ?", animeID).Delete(&entities.AnimeVideo{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("anime_id = ?", animeID).Delete(&entities.AnimeLink{}).Error; err != nil {
+			return err
+		}
+
+		for i := range themeSongs {
+			themeSongs[i].ID = 0
+			themeSongs[i].AnimeID = animeID
+		}
+		for i := range videos {
+			videos[i].ID = 0
+			videos[i].AnimeID = animeID
+		}
+		for i := range links {
+			links[i].ID = 0
+			links[i].AnimeID = animeID
+		}
+
+		if len(themeSongs) > 0 {
+			if err := tx.Create(&themeSongs).Error; err != nil {
+				return err
+			}
+		}
+		if len(videos) > 0 {
+			if err := tx.Create(&videos).Error; err != nil {
+				return err
+			}
+		}
+		if len(links) > 0 {
+			if err := tx.Create(&links).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		logger.Errorf("Anime", "Failed to replace media for anime %d: %v", animeID, err)
+		return errors.New("failed to save anime media")
+	}
+
+	return nil
+}
@@ -328,6 +328,10 @@ func UpdatePersonDetails(
 			}
 		}
 
+		animeCredits, err := withStaffCredits(tx, p.ID, animeCredits)
+		if err != nil {
+			return err
+		}
 		tx.Where("person_id = ?", p.ID).Delete(&entities.PersonAnimeCredit{})
 		for i := range animeCredits {
 			animeCredits[i].PersonID = p.ID
@@ -3,12 +3,21 @@ package repositories
 import (
 	"errors"
 	"metachan/entities"
+	"metachan/enums"
 	"metachan/utils/logger"
+	"strings"
 	"time"
 
+	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
+var producerRoleTables = map[enums.ProducerRole]string{
+	enums.ProducerRoleProducer: "anime_producers",
+	enums.ProducerRoleStudio:   "anime_studios",
+	enums.ProducerRoleLicensor: "anime_licensors",
+}
+
 func CreateOrUpdateProducer(producer *entities.Producer) error {
 	for i := range producer.Titles {
 		t := &producer.Titles[i]
@@ -21,11 +30,16 @@ func CreateOrUpdateProducer(producer *entities.Producer) error {
 		}
 	}
 
+	// Producers attached to an anime only carry a MAL ID, URL and name, so
+	// the enriched columns are left alone unless this producer has them.
+	columns := []string{"url"}
+	if producer.EnrichedAt != nil {
+		columns = append(columns, "favorites", "count", "established", "about", "image_id")
+	}
+
 	result := DB.Clauses(clause.OnConflict{
-		Columns: []clause.Column{{Name: "mal_id"}},
-		DoUpdates: clause.AssignmentColumns([]string{
-			"url", "favorites", "count", "established", "about", "image_id",
-		}),
+		Columns:   []clause.Column{{Name: "mal_id"}},
+		DoUpdates: clause.AssignmentColumns(columns),
 	}).Omit("Titles").Create(producer)
 
 	if result.Error != nil {
@@ -126,3 +140,100 @@ func SetProducerEnriched(id uint) error {
 	now := time.Now()
 	return DB.Model(&entities.Producer{}).Where("id = ?", id).Update("enriched_at", now).Error
 }
+
+// likeEscaper makes a search query match literally inside a LIKE pattern.
+// The escape character is "!" rather than a backslash, which MySQL would read
+// as a string escape, and "[" is escaped for SQL Server's character classes.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_", "[", "![")
+
+func SearchProducers(query string, page, limit int) ([]entities.Producer, int64, error) {
+	search := DB.Model(&entities.Producer{}).Session(&gorm.Session{})
+	if query = strings.TrimSpace(query); query != "" {
+		search = search.Where(
+			"id IN (?)",
+			DB.Table("producer_titles").
+				Select("producer_titles.producer_id").
+				Joins("JOIN simple_titles ON simple_titles.id = producer_titles.simple_title_id").
+				Where("LOWER(simple_titles.title) LIKE ? ESCAPE '!'", "%"+likeEscaper.Replace(strings.ToLower(query))+"%"),
+		)
+	}
+
+	var total int64
+	if err := search.Count(&total).Error; err != nil {
+		logger.Errorf("Producer", "Failed to count producers: %v", err)
+		return nil, 0, errors.New("failed to search producers")
+	}
+
+	var producers []entities.Producer
+	if err := search.
+		Preload("Image").
+		Preload("Titles").
+		Order("favorites DESC").
+		Order("id").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&producers).Error; err != nil {
+		logger.Errorf("Producer", "Failed to search producers: %v", err)
+		return nil, 0, errors.New("failed to search producers")
+	}
+
+	return producers, total, nil
+}
+
+func GetProducer(malID int) (entities.Producer, error) {
+	var producer entities.Producer
+
+	result := DB.
+		Preload("Image").
+		Preload("Titles").
+		Preload("ExternalURLs").
+		Where("mal_id = ?", malID).
+		First(&producer)
+
+	if result.Error != nil {
+		logger.Errorf("Producer", "Failed to get producer %d: %v", malID, result.Error)
+		return entities.Producer{}, errors.New("producer not found")
+	}
+
+	return producer, nil
+}
+
+// GetAnimeByProducer lists the anime a producer worked on in any of the given
+// roles, or in every role when none are given.
+func GetAnimeByProducer(producerID uint, roles []enums.ProducerRole, sort enums.AnimeSort, page, limit int) ([]entities.Anime, int64, error) {
+	if len(roles) == 0 {
+		roles = []enums.ProducerRole{enums.ProducerRoleProducer, enums.ProducerRoleStudio, enums.ProducerRoleLicensor}
+	}
+
+	conditions := make([]string, 0, len(roles))
+	subqueries := make([]interface{}, 0, len(roles))
+	for _, role := range roles {
+		conditions = append(conditions, "id IN (?)")
+		subqueries = append(subqueries, DB.Table(producerRoleTables[role]).Select("anime_id").Where("producer_id = ?", producerID))
+	}
+
+	query := DB.Model(&entities.Anime{}).
+		Where("("+strings.Join(conditions, " OR ")+")", subqueries...).
+		Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logger.Errorf("Producer", "Failed to count anime for producer %d: %v", producerID, err)
+		return nil, 0, errors.New("failed to fetch anime by producer")
+	}
+
+	var anime []entities.Anime
+	if err := orderAnime(query, sort).
+		Preload("Mapping").
+		Preload("Genres").
+		Preload("Studios").
+		Preload("Studios.Titles").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&anime).Error; err != nil {
+		logger.Errorf("Producer", "Failed to fetch anime for producer %d: %v", producerID, err)
+		return nil, 0, errors.New("failed to fetch anime by producer")
+	}
+
+	return anime, total, nil
+}
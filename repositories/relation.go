@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"errors"
+	"metachan/entities"
+	"metachan/enums"
+	"metachan/types"
+	"metachan/utils/logger"
+
+	"gorm.io/gorm"
+)
+
+// ReplaceAnimeRelations swaps the relations of an anime for the given set,
+// which always holds everything MAL lists for it.
+func ReplaceAnimeRelations(animeID uint, relations []entities.AnimeRelation) error {
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("anime_id = ?", animeID).Delete(&entities.AnimeRelation{}).Error; err != nil {
+			return err
+		}
+
+		for i := range relations {
+			relations[i].ID = 0
+			relations[i].AnimeID = animeID
+		}
+
+		if len(relations) > 0 {
+			if err := tx.Create(&relations).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		logger.Errorf("Anime", "Failed to replace relations for anime %d: %v", animeID, err)
+		return errors.New("failed to save anime relations")
+	}
+
+	return nil
+}
+
+// GetAnimeRelations returns the relations of an anime in the order MAL lists
+// them.
+func GetAnimeRelations[T idType](maptype enums.MappingType, id T) ([]entities.AnimeRelation, error) {
+	mapping, err := GetAnimeMapping(maptype, id)
+	if err != nil {
+		return nil, errors.New("anime not found")
+	}
+
+	var anime entities.Anime
+	if err := DB.Where("mapping_id = ?", mapping.ID).Select("id").First(&anime).Error; err != nil {
+		return nil, errors.New("anime not found")
+	}
+
+	relations := []entities.AnimeRelation{}
+	if err := DB.Where("anime_id = ?", anime.ID).Order("id").Find(&relations).Error; err != nil {
+		logger.Errorf("Anime", "Failed to get relations for anime %d: %v", anime.ID, err)
+		return nil, errors.New("failed to get anime relations")
+	}
+
+	return relations, nil
+}
+
+// GetRelationEdges returns the relations between anime stored for any of
+// malIDs, whichever side of the relation they are on.
+func GetRelationEdges(malIDs []int) ([]types.FranchiseEdge, error) {
+	if len(malIDs) == 0 {
+		return []types.FranchiseEdge{}, nil
+	}
+
+	var rows []relationEdgeRow
+	result := DB.Table("anime_relations").
+		Select("animes.mal_id AS from_mal_id, anime_relations.mal_id AS to_mal_id, anime_relations.relation").
+		Joins("JOIN animes ON animes.id = anime_relations.anime_id AND animes.deleted_at IS NULL").
+		Where("anime_relations.deleted_at IS NULL AND anime_relations.media = ?", enums.RelatedAnime).
+		Where("animes.mal_id IN ? OR anime_relations.mal_id IN ?", malIDs, malIDs).
+		Scan(&rows)
+
+	if result.Error != nil {
+		logger.Errorf("Anime", "Failed to get relation edges: %v", result.Error)
+		return nil, errors.New("failed to get anime relations")
+	}
+
+	edges := make([]types.FranchiseEdge, len(rows))
+	for i, row := range rows {
+		edges[i] = types.FranchiseEdge{From: row.FromMALID, To: row.ToMALID, Via: enums.MAL, Relation: row.Relation}
+	}
+	return edges, nil
+}
+
+// GetRelationsTo returns the stored relations that point at any of malIDs,
+// which is all that is known of anime that have not been synced.
+func GetRelationsTo(malIDs []int) ([]entities.AnimeRelation, error) {
+	var relations []entities.AnimeRelation
+	if len(malIDs) == 0 {
+		return relations, nil
+	}
+
+	result := DB.Where("media = ? AND mal_id IN ?", enums.RelatedAnime, malIDs).Order("id").Find(&relations)
+	if result.Error != nil {
+		logger.Errorf("Anime", "Failed to get relations to %d anime: %v", len(malIDs), result.Error)
+		return nil, errors.New("failed to get anime relations")
+	}
+
+	return relations, nil
+}
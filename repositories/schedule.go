@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"errors"
+	"metachan/entities"
+	"metachan/utils/logger"
+
+	"gorm.io/gorm"
+)
+
+// ReplaceAnimeSchedule swaps the airing schedule of an anime. AniList returns
+// the whole schedule on every fetch, so appending would duplicate episodes.
+func ReplaceAnimeSchedule(animeID uint, schedule []entities.EpisodeSchedule) error {
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("anime_id = ?", animeID).Delete(&entities.EpisodeSchedule{}).Error; err != nil {
+			return err
+		}
+
+		if len(schedule) == 0 {
+			return nil
+		}
+
+		for i := range schedule {
+			schedule[i].ID = 0
+			schedule[i].AnimeID = animeID
+		}
+
+		return tx.Create(&schedule).Error
+	})
+
+	if err != nil {
+		logger.Errorf("Schedule", "Failed to replace schedule for anime %d: %v", animeID, err)
+		return errors.New("failed to save airing schedule")
+	}
+
+	return nil
+}
+
+func GetScheduleBetween(from, to int64) ([]entities.EpisodeSchedule, error) {
+	var schedule []entities.EpisodeSchedule
+
+	result := DB.
+		Where("airing_at >= ? AND airing_at < ?", from, to).
+		Order("airing_at").
+		Find(&schedule)
+
+	if result.Error != nil {
+		logger.Errorf("Schedule", "Failed to fetch schedule between %d and %d: %v", from, to, result.Error)
+		return nil, errors.New("failed to fetch airing schedule")
+	}
+
+	return schedule, nil
+}
+
+func GetAnimeByIDs(ids []uint) ([]entities.Anime, error) {
+	var anime []entities.Anime
+	if len(ids) == 0 {
+		return anime, nil
+	}
+
+	result := DB.Where("id IN ?", ids).Find(&anime)
+	if result.Error != nil {
+		logger.Errorf("Schedule", "Failed to fetch anime by IDs: %v", result.Error)
+		return nil, errors.New("failed to fetch anime")
+	}
+
+	return anime, nil
+}
+
+func GetAnimeWithScheduleByMALIDs(malIDs []int) ([]entities.Anime, error) {
+	var anime []entities.Anime
+	if len(malIDs) == 0 {
+		return anime, nil
+	}
+
+	result := DB.
+		Preload("Schedule", func(db *gorm.DB) *gorm.DB {
+			return db.Order("airing_at")
+		}).
+		Where("mal_id IN ?", malIDs).
+		Find(&anime)
+
+	if result.Error != nil {
+		logger.Errorf("Schedule", "Failed to fetch anime schedules: %v", result.Error)
+		return nil, errors.New("failed to fetch anime")
+	}
+
+	return anime, nil
+}
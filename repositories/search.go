@@ -0,0 +1,315 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"metachan/database"
+	"metachan/entities"
+	"metachan/enums"
+	"metachan/types"
+	"metachan/utils/logger"
+	"metachan/utils/search"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+// searchFilterBatch is how many in-process hits are checked against the
+// filters per query, which keeps the IN list within every driver's limit.
+const searchFilterBatch = 500
+
+var (
+	searchIndex   = search.NewIndex()
+	searchIndexMu sync.Mutex
+	// searchIndexLoaded is set once the in-process index holds every anime, so
+	// that saves made from then on keep it current.
+	searchIndexLoaded atomic.Bool
+)
+
+func SearchAnime(query string, filters types.AnimeSearchFilters, page, limit int) ([]entities.Anime, int64, error) {
+	tokens := search.Tokenize(query)
+	if len(tokens) == 0 {
+		return []entities.Anime{}, 0, nil
+	}
+
+	offset := (page - 1) * limit
+
+	var hits []search.Hit
+	var total int64
+	var err error
+
+	// Filters are applied by the engine itself, so that a common title with a
+	// narrow filter still pages through every match.
+	switch database.SearchEngine {
+	case enums.SearchFTS5:
+		hits, total, err = searchFTS5(tokens, filters, offset, limit)
+	case enums.SearchTSVector:
+		hits, total, err = searchTSVector(tokens, filters, offset, limit)
+	}
+
+	if err != nil {
+		logger.Warnf("Search", "%s search failed, falling back to in-process index: %v", database.SearchEngine, err)
+	}
+
+	// The database engines only match whole tokens and prefixes, so a query
+	// with a typo returns nothing. The in-process index tolerates small edits.
+	if err != nil || total == 0 {
+		hits, total, err = searchIndexed(query, filters, offset, limit)
+		if err != nil {
+			logger.Errorf("Search", "In-process search failed: %v", err)
+			return nil, 0, errors.New("failed to search anime")
+		}
+	}
+
+	if len(hits) == 0 {
+		return []entities.Anime{}, total, nil
+	}
+
+	pageIDs := make([]uint, len(hits))
+	for i, hit := range hits {
+		pageIDs[i] = hit.ID
+	}
+
+	var anime []entities.Anime
+	if err := DB.
+		Preload("Mapping").
+		Preload("Genres").
+		Preload("Themes").
+		Preload("Demographics").
+		Where("id IN ?", pageIDs).
+		Find(&anime).Error; err != nil {
+		logger.Errorf("Search", "Failed to load search results: %v", err)
+		return nil, 0, errors.New("failed to search anime")
+	}
+
+	position := make(map[uint]int, len(pageIDs))
+	for i, id := range pageIDs {
+		position[id] = i
+	}
+	sort.Slice(anime, func(i, j int) bool {
+		return position[anime[i].ID] < position[anime[j].ID]
+	})
+
+	return anime, total, nil
+}
+
+// IndexAnimeTitles keeps the full-text index in step with a saved anime. The
+// Postgres GIN index is maintained by the database itself.
+func IndexAnimeTitles(anime *entities.Anime) {
+	if anime == nil || anime.ID == 0 {
+		return
+	}
+
+	if database.SearchEngine == enums.SearchFTS5 {
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec("DELETE FROM "+database.SearchTable+" WHERE rowid = ?", anime.ID).Error; err != nil {
+				return err
+			}
+			return tx.Exec(
+				"INSERT INTO "+database.SearchTable+" (rowid, romaji, english, japanese, synonyms) VALUES (?, ?, ?, ?, ?)",
+				anime.ID, anime.Title.Romaji, anime.Title.English, anime.Title.Japanese, strings.Join(anime.Title.Synonyms, " "),
+			).Error
+		})
+		if err != nil {
+			logger.Warnf("Search", "Failed to index titles for anime %d: %v", anime.MALID, err)
+		}
+	}
+
+	if searchIndexLoaded.Load() {
+		searchIndex.Add(anime.ID, animeTitles(anime)...)
+	}
+}
+
+func searchFTS5(tokens []string, filters types.AnimeSearchFilters, offset, limit int) ([]search.Hit, int64, error) {
+	terms := make([]string, len(tokens))
+	for i, token := range tokens {
+		terms[i] = `"` + token + `"*`
+	}
+
+	matching := filterAnime(DB.Model(&entities.Anime{}), filters).Select("id")
+	query := DB.Table(database.SearchTable).
+		Where(database.SearchTable+" MATCH ? AND rowid IN (?)", strings.Join(terms, " "), matching).
+		Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if int64(offset) >= total {
+		return nil, total, nil
+	}
+
+	var hits []search.Hit
+	err := query.
+		Select("rowid AS id, -bm25(" + database.SearchTable + ") AS score").
+		Order("score DESC").
+		Order("rowid").
+		Offset(offset).
+		Limit(limit).
+		Scan(&hits).Error
+
+	return hits, total, err
+}
+
+func searchTSVector(tokens []string, filters types.AnimeSearchFilters, offset, limit int) ([]search.Hit, int64, error) {
+	terms := make([]string, len(tokens))
+	for i, token := range tokens {
+		terms[i] = token + ":*"
+	}
+	tsquery := strings.Join(terms, " & ")
+
+	query := filterAnime(DB.Model(&entities.Anime{}), filters).
+		Where(database.SearchDocument+" @@ to_tsquery('simple', ?)", tsquery).
+		Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if int64(offset) >= total {
+		return nil, total, nil
+	}
+
+	var hits []search.Hit
+	err := query.
+		Select("id, ts_rank("+database.SearchDocument+", to_tsquery('simple', ?)) AS score", tsquery).
+		Order("score DESC").
+		Order("id").
+		Offset(offset).
+		Limit(limit).
+		Scan(&hits).Error
+
+	return hits, total, err
+}
+
+// searchIndexed ranks every title in the in-process index and checks the hits
+// against the filters in batches, keeping their order.
+func searchIndexed(query string, filters types.AnimeSearchFilters, offset, limit int) ([]search.Hit, int64, error) {
+	if err := loadSearchIndex(); err != nil {
+		return nil, 0, err
+	}
+
+	hits := searchIndex.Search(query, 0)
+
+	if filters != (types.AnimeSearchFilters{}) {
+		matched := make([]search.Hit, 0, len(hits))
+		for start := 0; start < len(hits); start += searchFilterBatch {
+			batch := hits[start:min(start+searchFilterBatch, len(hits))]
+
+			ids := make([]uint, len(batch))
+			for i, hit := range batch {
+				ids[i] = hit.ID
+			}
+
+			var matchedIDs []uint
+			if err := filterAnime(DB.Model(&entities.Anime{}), filters).
+				Where("id IN ?", ids).
+				Pluck("id", &matchedIDs).Error; err != nil {
+				return nil, 0, err
+			}
+
+			kept := make(map[uint]bool, len(matchedIDs))
+			for _, id := range matchedIDs {
+				kept[id] = true
+			}
+			for _, hit := range batch {
+				if kept[hit.ID] {
+					matched = append(matched, hit)
+				}
+			}
+		}
+		hits = matched
+	}
+
+	total := int64(len(hits))
+	if offset >= len(hits) {
+		return nil, total, nil
+	}
+
+	return hits[offset:min(offset+limit, len(hits))], total, nil
+}
+
+// loadSearchIndex fills the in-process index on first use. A failed load is
+// tried again by the next search.
+func loadSearchIndex() error {
+	if searchIndexLoaded.Load() {
+		return nil
+	}
+
+	searchIndexMu.Lock()
+	defer searchIndexMu.Unlock()
+
+	if searchIndexLoaded.Load() {
+		return nil
+	}
+
+	var anime []entities.Anime
+	if err := DB.Select("id, title_romaji, title_english, title_japanese, title_synonyms").Find(&anime).Error; err != nil {
+		return fmt.Errorf("failed to load anime titles for search index: %w", err)
+	}
+
+	for i := range anime {
+		searchIndex.Add(anime[i].ID, animeTitles(&anime[i])...)
+	}
+	searchIndexLoaded.Store(true)
+
+	logger.Infof("Search", "Loaded %d anime into in-process search index", searchIndex.Len())
+	return nil
+}
+
+func animeTitles(anime *entities.Anime) []string {
+	titles := []string{anime.Title.Romaji, anime.Title.English, anime.Title.Japanese}
+	return append(titles, anime.Title.Synonyms...)
+}
+
+func filterAnime(query *gorm.DB, filters types.AnimeSearchFilters) *gorm.DB {
+	if filters.Type != "" {
+		query = query.Where("LOWER(type) = ?", strings.ToLower(filters.Type))
+	}
+
+	if filters.Status != "" {
+		query = query.Where("LOWER(status) = ?", strings.ToLower(filters.Status))
+	}
+
+	if filters.Season != "" {
+		query = query.Where("LOWER(season) = ?", strings.ToLower(filters.Season))
+	}
+
+	if filters.Year > 0 {
+		query = query.Where("year = ?", filters.Year)
+	}
+
+	if filters.Genre != "" {
+		genres := DB.Model(&entities.Genre{}).Select("id")
+		if genreID, err := strconv.Atoi(filters.Genre); err == nil {
+			genres = genres.Where("genre_id = ?", genreID)
+		} else {
+			genres = genres.Where("LOWER(name) = ?", strings.ToLower(filters.Genre))
+		}
+
+		query = query.Where(
+			"(id IN (?) OR id IN (?) OR id IN (?))",
+			DB.Table("anime_genres").Select("anime_id").Where("genre_id IN (?)", genres),
+			DB.Table("anime_themes").Select("anime_id").Where("genre_id IN (?)", genres),
+			DB.Table("anime_demographics").Select("anime_id").Where("genre_id IN (?)", genres),
+		)
+	}
+
+	return query
+}
+
+func orderAnime(query *gorm.DB, sort enums.AnimeSort) *gorm.DB {
+	switch sort {
+	case enums.SortByPopularity:
+		// MAL popularity is a rank where 1 is the most popular and 0 is unranked.
+		return query.Order("CASE WHEN score_popularity = 0 THEN 1 ELSE 0 END").Order("score_popularity").Order("id")
+	case enums.SortByYear:
+		return query.Order("year DESC").Order("score_score DESC").Order("id")
+	default:
+		return query.Order("score_score DESC").Order("id")
+	}
+}
@@ -1,8 +1,11 @@
 package repositories
 
 import (
+	"errors"
 	"metachan/entities"
+	"metachan/enums"
 	"metachan/utils/logger"
+	"time"
 )
 
 func GetRelatedAnimeByTVDB(tvdbID int, excludeMALID int) ([]entities.Mapping, error) {
@@ -30,3 +33,27 @@ func GetRelatedAnimeByTMDB(tmdbID int, excludeMALID int) ([]entities.Mapping, er
 	logger.Debugf("Seasons", "Found %d related anime via TMDB ID %d", len(mappings), tmdbID)
 	return mappings, nil
 }
+
+// GetSeasonAnime returns the anime that premiered in the given season and,
+// when includeContinuing is set, those that started earlier and were still
+// airing when the season began.
+func GetSeasonAnime(year int, season enums.AnimeSeason, seasonStart time.Time, includeContinuing bool) ([]entities.Anime, error) {
+	var anime []entities.Anime
+
+	query := DB.Preload("Mapping").Preload("Genres").Preload("Studios").Preload("Studios.Titles")
+	if includeContinuing {
+		query = query.Where(
+			"(LOWER(season) = ? AND year = ?) OR (aired_from < ? AND ((aired_to IS NOT NULL AND aired_to >= ?) OR (aired_to IS NULL AND airing = ?)))",
+			string(season), year, seasonStart, seasonStart, true,
+		)
+	} else {
+		query = query.Where("LOWER(season) = ? AND year = ?", string(season), year)
+	}
+
+	if err := query.Find(&anime).Error; err != nil {
+		logger.Errorf("Seasons", "Failed to get anime for %s %d: %v", season, year, err)
+		return nil, errors.New("failed to fetch season anime")
+	}
+
+	return anime, nil
+}
@@ -0,0 +1,192 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"metachan/config"
+	"metachan/entities"
+	"metachan/enums"
+	"metachan/types"
+	"metachan/utils/logger"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReplaceAnimeStaff swaps the staff of an anime for anime.Staff, saving a stub
+// for anyone not seen before so that PersonSync picks them up. The anime
+// credits of people on the anime are rewritten from the same list, so their
+// pages agree with the anime's.
+func ReplaceAnimeStaff(anime *entities.Anime) error {
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		staff := make([]entities.AnimeStaff, 0, len(anime.Staff))
+		byPersonID := make(map[uint]int, len(anime.Staff))
+		for _, member := range anime.Staff {
+			person := member.Person
+			if person == nil || person.MALID == 0 {
+				continue
+			}
+
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "mal_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"url", "image", "name"}),
+			}).Create(person).Error; err != nil {
+				return err
+			}
+			if person.ID == 0 {
+				if err := tx.Where("mal_id = ?", person.MALID).First(person).Error; err != nil {
+					return err
+				}
+			}
+
+			if i, ok := byPersonID[person.ID]; ok {
+				staff[i].Positions = append(staff[i].Positions, member.Positions...)
+				continue
+			}
+			byPersonID[person.ID] = len(staff)
+			staff = append(staff, entities.AnimeStaff{
+				AnimeID:   anime.ID,
+				PersonID:  person.ID,
+				Positions: member.Positions,
+			})
+		}
+
+		if err := tx.Unscoped().Where("anime_id = ?", anime.ID).Delete(&entities.AnimeStaff{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("anime_mal_id = ?", anime.MALID).Delete(&entities.PersonAnimeCredit{}).Error; err != nil {
+			return err
+		}
+		if len(staff) == 0 {
+			return nil
+		}
+
+		if err := tx.Omit("Person").Create(&staff).Error; err != nil {
+			return err
+		}
+
+		credits := make([]entities.PersonAnimeCredit, len(staff))
+		for i, member := range staff {
+			credits[i] = staffCredit(*anime, member)
+		}
+		return tx.Create(&credits).Error
+	})
+
+	if err != nil {
+		logger.Errorf("Anime", "Failed to replace staff for anime %d: %v", anime.ID, err)
+		return errors.New("failed to save anime staff")
+	}
+
+	return nil
+}
+
+// staffCredit is the credit a staff member gets on their own page, with the
+// positions joined the way MAL shows them there.
+func staffCredit(anime entities.Anime, member entities.AnimeStaff) entities.PersonAnimeCredit {
+	return entities.PersonAnimeCredit{
+		PersonID:      member.PersonID,
+		AnimeMALID:    anime.MALID,
+		Position:      strings.Join(member.Positions, ", "),
+		AnimeTitle:    anime.Title.Romaji,
+		AnimeURL:      fmt.Sprintf("%s/anime/%d", config.Upstream.MALURL, anime.MALID),
+		AnimeImageURL: anime.Images.Original,
+	}
+}
+
+// withStaffCredits reconciles the anime credits from a person's page with the
+// staff lists stored for their anime. Where an anime has a staff list it wins,
+// and credits on anime without one are kept as they are.
+func withStaffCredits(tx *gorm.DB, personID uint, credits []entities.PersonAnimeCredit) ([]entities.PersonAnimeCredit, error) {
+	var staff []entities.AnimeStaff
+	if err := tx.Where("person_id = ?", personID).Order("id").Find(&staff).Error; err != nil {
+		return nil, err
+	}
+
+	malIDs := make([]int, len(credits))
+	for i, credit := range credits {
+		malIDs[i] = credit.AnimeMALID
+	}
+	var staffed []int
+	if len(malIDs) > 0 {
+		if err := tx.Model(&entities.Anime{}).
+			Joins("JOIN anime_staffs ON anime_staffs.anime_id = animes.id AND anime_staffs.deleted_at IS NULL").
+			Where("animes.mal_id IN ?", malIDs).
+			Distinct().
+			Pluck("animes.mal_id", &staffed).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	animeByID := make(map[uint]entities.Anime, len(staff))
+	if len(staff) > 0 {
+		animeIDs := make([]uint, len(staff))
+		for i, member := range staff {
+			animeIDs[i] = member.AnimeID
+		}
+		var anime []entities.Anime
+		if err := tx.Select("id", "mal_id", "title_romaji", "image_original").Where("id IN ?", animeIDs).Find(&anime).Error; err != nil {
+			return nil, err
+		}
+		for _, series := range anime {
+			animeByID[series.ID] = series
+		}
+	}
+
+	skip := make(map[int]bool, len(staffed)+len(staff))
+	reconciled := make([]entities.PersonAnimeCredit, 0, len(credits)+len(staff))
+	for _, member := range staff {
+		if series, ok := animeByID[member.AnimeID]; ok && !skip[series.MALID] {
+			skip[series.MALID] = true
+			reconciled = append(reconciled, staffCredit(series, member))
+		}
+	}
+	for _, malID := range staffed {
+		skip[malID] = true
+	}
+	for _, credit := range credits {
+		if !skip[credit.AnimeMALID] {
+			reconciled = append(reconciled, credit)
+		}
+	}
+	return reconciled, nil
+}
+
+// GetAnimeStaff groups the staff of an anime by position. Roles and the
+// people under them follow the order of the staff page, and anyone holding
+// several positions is listed under each.
+func GetAnimeStaff[T idType](maptype enums.MappingType, id T) ([]types.StaffRole, error) {
+	mapping, err := GetAnimeMapping(maptype, id)
+	if err != nil {
+		return nil, errors.New("anime not found")
+	}
+
+	var anime entities.Anime
+	if err := DB.Where("mapping_id = ?", mapping.ID).Select("id").First(&anime).Error; err != nil {
+		return nil, errors.New("anime not found")
+	}
+
+	var staff []entities.AnimeStaff
+	if err := DB.Preload("Person").Where("anime_id = ?", anime.ID).Order("id").Find(&staff).Error; err != nil {
+		logger.Errorf("Anime", "Failed to get staff for anime %d: %v", anime.ID, err)
+		return nil, errors.New("failed to get anime staff")
+	}
+
+	roles := []types.StaffRole{}
+	roleIndex := make(map[string]int)
+	for _, member := range staff {
+		if member.Person == nil {
+			continue
+		}
+		for _, position := range member.Positions {
+			i, ok := roleIndex[position]
+			if !ok {
+				i = len(roles)
+				roleIndex[position] = i
+				roles = append(roles, types.StaffRole{Role: position})
+			}
+			roles[i].People = append(roles[i].People, *member.Person)
+		}
+	}
+	return roles, nil
+}
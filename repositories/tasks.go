@@ -3,6 +3,7 @@ package repositories
 import (
 	"errors"
 	"metachan/entities"
+	"metachan/enums"
 	"metachan/utils/logger"
 
 	"gorm.io/gorm/clause"
@@ -46,6 +47,24 @@ func GetLatestTaskLog(taskName string) (*entities.TaskLog, error) {
 	return &taskLog, nil
 }
 
+// GetLatestFinishedTaskLog returns the newest run that succeeded or failed,
+// skipping runs still in progress, runs cut short by a shutdown and runs that
+// only resumed interrupted work.
+func GetLatestFinishedTaskLog(taskName string) (*entities.TaskLog, error) {
+	var taskLog entities.TaskLog
+
+	trigger := clause.Column{Name: "trigger"}
+	result := DB.Where("task_name = ? AND status IN ?", taskName, []enums.TaskRunStatus{enums.TaskRunSuccess, enums.TaskRunError}).
+		Where(clause.Or(clause.Eq{Column: trigger, Value: nil}, clause.Neq{Column: trigger, Value: enums.TaskTriggerResume})).
+		Order("executed_at desc").
+		First(&taskLog)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &taskLog, nil
+}
+
 func CreateTaskLog(taskLog *entities.TaskLog) error {
 	result := DB.Create(taskLog)
 	if result.Error != nil {
@@ -56,6 +75,83 @@ func CreateTaskLog(taskLog *entities.TaskLog) error {
 	return nil
 }
 
+func UpdateTaskLog(taskLog *entities.TaskLog) error {
+	result := DB.Model(taskLog).
+		Select("status", "message", "processed", "total", "failed", "current_item", "executed_at").
+		Updates(taskLog)
+	if result.Error != nil {
+		logger.Errorf("Task", "Failed to update task log %s: %v", taskLog.RunID, result.Error)
+		return errors.New("failed to update task log")
+	}
+
+	return nil
+}
+
+func UpdateTaskLogProgress(taskLog *entities.TaskLog) error {
+	result := DB.Model(taskLog).Select("processed", "total", "failed", "current_item").Updates(taskLog)
+	if result.Error != nil {
+		logger.Errorf("Task", "Failed to update progress of task log %s: %v", taskLog.RunID, result.Error)
+		return errors.New("failed to update task log progress")
+	}
+
+	return nil
+}
+
+func GetTaskLogs(taskName string, page, limit int) ([]entities.TaskLog, int64, error) {
+	query := DB.Model(&entities.TaskLog{}).Where("task_name = ?", taskName)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logger.Errorf("Task", "Failed to count task logs for %s: %v", taskName, err)
+		return nil, 0, errors.New("failed to fetch task runs")
+	}
+
+	var taskLogs []entities.TaskLog
+	if err := query.
+		Order("started_at DESC").
+		Order("id DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&taskLogs).Error; err != nil {
+		logger.Errorf("Task", "Failed to fetch task logs for %s: %v", taskName, err)
+		return nil, 0, errors.New("failed to fetch task runs")
+	}
+
+	return taskLogs, total, nil
+}
+
+// CancelInterruptedTaskLogs closes runs left in the running state by a
+// previous process that exited before they finished.
+func CancelInterruptedTaskLogs() error {
+	result := DB.Model(&entities.TaskLog{}).
+		Where("status = ?", enums.TaskRunRunning).
+		Updates(map[string]any{"status": enums.TaskRunCancelled, "message": "Interrupted before completion"})
+	if result.Error != nil {
+		logger.Errorf("Task", "Failed to close interrupted task logs: %v", result.Error)
+		return errors.New("failed to close interrupted task logs")
+	}
+
+	if result.RowsAffected > 0 {
+		logger.Warnf("Task", "Marked %d interrupted task runs as cancelled", result.RowsAffected)
+	}
+
+	return nil
+}
+
+func SetTaskPaused(taskName string, paused bool) error {
+	result := DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "task_name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"is_paused", "updated_at"}),
+	}).Create(&entities.TaskStatus{TaskName: taskName, IsPaused: paused})
+
+	if result.Error != nil {
+		logger.Errorf("Task", "Failed to set paused state for %s: %v", taskName, result.Error)
+		return errors.New("failed to set task paused state")
+	}
+
+	return nil
+}
+
 // -- Moved to database/tasks.go --
 // import (
 // 	"metachan/entities"
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"metachan/database"
+	"metachan/enums"
 	"time"
 
 	"gorm.io/gorm"
@@ -15,6 +16,8 @@ type idType interface {
 
 type animeStub struct {
 	MALID      int
+	Airing     bool
+	Status     string
 	UpdatedAt  time.Time
 	EnrichedAt *time.Time
 }
@@ -32,3 +35,9 @@ type personStub struct {
 	MALID      int
 	EnrichedAt *time.Time
 }
+
+type relationEdgeRow struct {
+	FromMALID int
+	ToMALID   int
+	Relation  enums.AnimeRelationType
+}
@@ -2,6 +2,7 @@ package router
 
 import (
 	"metachan/controllers"
+	"metachan/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -12,11 +13,49 @@ func Initialize(router *fiber.App) {
 
 	// Anime routes
 	animeRouter := router.Group("/anime")
+	animeRouter.Get("/search", controllers.SearchAnime)
 	animeRouter.Get("/:id", controllers.GetAnime)
 	animeRouter.Get("/:id/episodes", controllers.GetAnimeEpisodes)
 	animeRouter.Get("/:id/episodes/:episodeId", controllers.GetAnimeEpisode)
 	animeRouter.Get("/:id/characters", controllers.GetAnimeCharacters)
 	animeRouter.Get("/:id/people", controllers.GetAnimePeople)
+	animeRouter.Get("/:id/staff", controllers.GetAnimeStaff)
+	animeRouter.Get("/:id/relations", controllers.GetAnimeRelations)
+	animeRouter.Get("/:id/franchise", controllers.GetAnimeFranchise)
+	animeRouter.Get("/:id/schedule.ics", controllers.GetAnimeScheduleCalendar)
+
+	mappingRouter := router.Group("/mappings")
+	mappingRouter.Post("/resolve", controllers.ResolveMappings)
+	mappingRouter.Get("/:provider/:id", controllers.GetMapping)
+
+	scheduleRouter := router.Group("/schedule")
+	scheduleRouter.Get("/", controllers.GetSchedule)
+	scheduleRouter.Get("/upcoming", controllers.GetUpcomingSchedule)
+	scheduleRouter.Get("/calendar.ics", controllers.GetScheduleCalendar)
+
+	seasonRouter := router.Group("/seasons")
+	seasonRouter.Get("/current", controllers.GetCurrentSeason)
+	seasonRouter.Get("/next", controllers.GetNextSeason)
+	seasonRouter.Get("/:year/:season", controllers.GetSeason)
+
+	genreRouter := router.Group("/genres")
+	genreRouter.Get("/", controllers.GetGenres)
+	genreRouter.Get("/:id/anime", controllers.GetAnimeByGenre)
+
+	producerRouter := router.Group("/producers")
+	producerRouter.Get("/", controllers.GetProducers)
+	producerRouter.Get("/:id", controllers.GetProducer)
+	producerRouter.Get("/:id/anime", controllers.GetAnimeByProducer)
+
+	adminRouter := router.Group("/admin", middleware.AdminAuth())
+	adminRouter.Get("/tasks", controllers.GetTasks)
+	adminRouter.Get("/tasks/progress", controllers.StreamTaskProgress)
+	adminRouter.Get("/tasks/:name/runs", controllers.GetTaskRuns)
+	adminRouter.Post("/tasks/:name/run", controllers.RunTask)
+	adminRouter.Post("/tasks/:name/pause", controllers.PauseTask)
+	adminRouter.Post("/tasks/:name/resume", controllers.ResumeTask)
+	adminRouter.Get("/jobs", controllers.GetJobs)
+	adminRouter.Post("/jobs/:key/retry", controllers.RetryJob)
 
 	characterRouter := router.Group("/character")
 	characterRouter.Get("/:characterId", controllers.GetAnimeCharacter)
@@ -26,19 +65,11 @@ func Initialize(router *fiber.App) {
 
 	// Anime routes
 	// animeRouter := router.Group("/a")
-	// animeRouter.Get("/genres", controllers.GetGenres)
-	// animeRouter.Get("/genres/:id", controllers.GetAnimeByGenre)
 	// animeRouter.Get("/:id", controllers.GetAnime)
 	// animeRouter.Get("/:id/episodes", controllers.GetAnimeEpisodes)
 	// animeRouter.Get("/:id/episodes/:episodeId", controllers.GetAnimeEpisode)
 	// animeRouter.Get("/:id/characters", controllers.GetAnimeCharacters)
 
-	// // Producer routes
-	// producerRouter := router.Group("/producers")
-	// producerRouter.Get("/", controllers.GetProducers)
-	// producerRouter.Get("/:id", controllers.GetProducer)
-	// producerRouter.Get("/:id/anime", controllers.GetAnimeByProducer)
-
 	// // 404 Default
 	// router.Use(func(c *fiber.Ctx) error {
 	// 	return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
@@ -3,7 +3,10 @@ package services
 import (
 	"context"
 	"crypto/md5"
+	"errors"
 	"fmt"
+	"html"
+	"metachan/config"
 	"metachan/entities"
 	"metachan/enums"
 	"metachan/repositories"
@@ -16,67 +19,258 @@ import (
 	"metachan/utils/api/tmdb"
 	"metachan/utils/api/tvdb"
 	"metachan/utils/logger"
+	"metachan/utils/mal"
+	"metachan/utils/ratelimit"
+	"metachan/utils/upstream"
+	"regexp"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/sync/errgroup"
 	"golang.org/x/sync/singleflight"
 )
 
+const (
+	partialRetryBackoff    = 1 * time.Minute
+	partialRetryMaxBackoff = 6 * time.Hour
+)
+
 var flightGroup singleflight.Group
 
-func GetAnime(mapping *entities.Mapping) (*entities.Anime, error) {
+var animeFlights = struct {
+	mu    sync.Mutex
+	byKey map[string]*ratelimit.Escalation
+}{byKey: make(map[string]*ratelimit.Escalation)}
+
+var partialRetries = struct {
+	mu      sync.Mutex
+	byMALID map[int]partialRetry
+}{byMALID: make(map[int]partialRetry)}
+
+var malRelationTypes = map[mal.RelationType]enums.AnimeRelationType{
+	mal.RelationSequel:             enums.Sequel,
+	mal.RelationPrequel:            enums.Prequel,
+	mal.RelationAlternativeSetting: enums.AlternativeSetting,
+	mal.RelationAlternativeVersion: enums.AlternativeVersion,
+	mal.RelationSideStory:          enums.SideStory,
+	mal.RelationParentStory:        enums.ParentStory,
+	mal.RelationSummary:            enums.Summary,
+	mal.RelationFullStory:          enums.FullStory,
+	mal.RelationSpinOff:            enums.SpinOff,
+	mal.RelationAdaptation:         enums.Adaptation,
+	mal.RelationCharacter:          enums.CharacterRelation,
+	mal.RelationOther:              enums.OtherRelation,
+}
+
+type animeResult struct {
+	anime *entities.Anime
+	cache types.CacheInfo
+}
+
+// GetAnime returns the cached anime for a mapping. Entries older than their
+// TTL are still returned straight away while a refresh runs in the
+// background; only anime missing from the database are fetched inline. The
+// upstream calls for that fetch queue at the ratelimit.Priority carried by
+// ctx, which is interactive unless a task set it to background.
+//
+// Callers asking for the same anime share one fetch. It runs detached from
+// any one caller, so that a caller giving up does not fail the others, and
+// at the highest priority among them. Shutdown cancels it instead.
+func GetAnime(ctx context.Context, mapping *entities.Mapping) (*entities.Anime, types.CacheInfo, error) {
 	if mapping == nil {
 		logger.Errorf("AnimeService", "Mapping is nil")
-		return nil, fmt.Errorf("mapping is nil")
+		return nil, types.CacheInfo{}, fmt.Errorf("mapping is nil")
 	}
 
 	key := fmt.Sprintf("anime:%d", mapping.MAL)
-	result, err, _ := flightGroup.Do(key, func() (interface{}, error) {
-		return getAnimeInternal(mapping)
+	escalation := joinAnimeFlight(key, ratelimit.PriorityFrom(ctx))
+	flight := flightGroup.DoChan(key, func() (interface{}, error) {
+		defer leaveAnimeFlight(key, escalation)
+		fetchCtx, done, ok := background.start(ctx)
+		if !ok {
+			return nil, ErrShuttingDown
+		}
+		defer done()
+		return getAnimeInternal(ratelimit.WithEscalation(fetchCtx, escalation), mapping)
 	})
 
-	if err != nil {
-		return nil, err
+	select {
+	case <-ctx.Done():
+		return nil, types.CacheInfo{}, ctx.Err()
+	case result := <-flight:
+		if result.Err != nil {
+			return nil, types.CacheInfo{}, result.Err
+		}
+		return result.Val.(animeResult).anime, result.Val.(animeResult).cache, nil
+	}
+}
+
+// joinAnimeFlight returns the priority shared by the callers of a flight,
+// raised to priority.
+func joinAnimeFlight(key string, priority ratelimit.Priority) *ratelimit.Escalation {
+	animeFlights.mu.Lock()
+	defer animeFlights.mu.Unlock()
+
+	escalation, ok := animeFlights.byKey[key]
+	if !ok {
+		escalation = ratelimit.NewEscalation(priority)
+		animeFlights.byKey[key] = escalation
+	}
+	escalation.Raise(priority)
+	return escalation
+}
+
+func leaveAnimeFlight(key string, escalation *ratelimit.Escalation) {
+	animeFlights.mu.Lock()
+	defer animeFlights.mu.Unlock()
+
+	if animeFlights.byKey[key] == escalation {
+		delete(animeFlights.byKey, key)
+	}
+}
+
+// AnimeTTL is how long a cached anime is considered fresh. Airing shows
+// change weekly, finished ones rarely change at all.
+func AnimeTTL(airing bool, status string) time.Duration {
+	switch {
+	case airing || status == string(mal.StatusAiring):
+		return config.Cache.AiringTTL
+	case status == string(mal.StatusNotYetAired):
+		return config.Cache.UpcomingTTL
+	default:
+		return config.Cache.FinishedTTL
 	}
-	return result.(*entities.Anime), nil
 }
 
-func getAnimeInternal(mapping *entities.Mapping) (*entities.Anime, error) {
+func getAnimeInternal(ctx context.Context, mapping *entities.Mapping) (animeResult, error) {
 	malID := mapping.MAL
 	logger.Infof("AnimeService", "Fetching anime data for MAL ID: %d", malID)
 
 	existingAnime, err := repositories.GetAnime(enums.MAL, malID)
 	if err == nil {
-		if time.Since(existingAnime.UpdatedAt) < 7*24*time.Hour {
-			logger.Infof("AnimeService", "Returning cached anime (MAL ID: %d, age: %v)", malID, time.Since(existingAnime.UpdatedAt).Round(time.Second))
-			return &existingAnime, nil
+		cache := types.CacheInfo{
+			Status: enums.CacheHit,
+			Age:    time.Since(existingAnime.UpdatedAt),
+			TTL:    AnimeTTL(existingAnime.Airing, existingAnime.Status),
+		}
+
+		if cache.Age < cache.TTL {
+			// A partial anime retries the upstreams it is missing, but
+			// only once they are back and its backoff has passed.
+			if missing := existingAnime.MissingSources; len(missing) > 0 && partialRetryDue(malID, missing) {
+				logger.Infof("AnimeService", "Returning partial anime and retrying %v in background (MAL ID: %d)", missing, malID)
+				refreshAnimeInBackground(*mapping, missing)
+			} else {
+				logger.Infof("AnimeService", "Returning cached anime (MAL ID: %d, age: %v)", malID, cache.Age.Round(time.Second))
+			}
+			return animeResult{anime: &existingAnime, cache: cache}, nil
 		}
-		logger.Infof("AnimeService", "Cached anime is stale, refreshing (MAL ID: %d)", malID)
-		return fetchAnime(mapping, &existingAnime)
+
+		logger.Infof("AnimeService", "Cached anime is stale, returning it and refreshing in background (MAL ID: %d, age: %v)", malID, cache.Age.Round(time.Second))
+		cache.Status = enums.CacheStale
+		refreshAnimeInBackground(*mapping, nil)
+		return animeResult{anime: &existingAnime, cache: cache}, nil
 	}
 
 	logger.Infof("AnimeService", "Anime not found in database, creating new")
-	return fetchAnime(mapping, nil)
+	anime, err := fetchAnime(ctx, mapping, nil, nil)
+	if err != nil {
+		return animeResult{}, err
+	}
+	return animeResult{anime: anime, cache: types.CacheInfo{Status: enums.CacheMiss}}, nil
+}
+
+// refreshAnimeInBackground queues a refresh unless one is already running for
+// the same anime, so a burst of requests for a stale entry refetches it once.
+// The refresh outlives the request that noticed the entry was stale, until
+// Shutdown, and runs at background priority so that it does not hold up other requests. Sources
+// limits it to those upstreams, nil refreshes from all of them.
+func refreshAnimeInBackground(mapping entities.Mapping, sources []enums.Upstream) {
+	key := fmt.Sprintf("refresh:%d", mapping.MAL)
+	flightGroup.DoChan(key, func() (interface{}, error) {
+		refreshCtx, done, ok := background.start(context.Background())
+		if !ok {
+			return nil, ErrShuttingDown
+		}
+		defer done()
+		anime, err := refreshAnime(ratelimit.WithPriority(refreshCtx, ratelimit.Background), &mapping, sources)
+		if err != nil {
+			logger.Warnf("AnimeService", "Background refresh failed (MAL ID: %d): %v", mapping.MAL, err)
+		}
+		return anime, err
+	})
 }
 
-func ForceRefreshAnime(mapping *entities.Mapping) (*entities.Anime, error) {
+// partialRetryDue reports whether a partial anime may retry the upstreams it
+// is missing: its backoff has passed and at least one of them is back. A
+// retry that fails again doubles the backoff, so an outage does not turn
+// every read into a fetch.
+func partialRetryDue(malID int, missing []enums.Upstream) bool {
+	partialRetries.mu.Lock()
+	retry, ok := partialRetries.byMALID[malID]
+	partialRetries.mu.Unlock()
+	if ok && time.Now().Before(retry.next) {
+		return false
+	}
+
+	return slices.ContainsFunc(missing, upstream.Available)
+}
+
+// notePartialFetch backs off the next retry of an anime that is still missing
+// upstreams and forgets one that is complete.
+func notePartialFetch(malID int, missing []enums.Upstream) {
+	partialRetries.mu.Lock()
+	defer partialRetries.mu.Unlock()
+
+	if len(missing) == 0 {
+		delete(partialRetries.byMALID, malID)
+		return
+	}
+
+	retry := partialRetries.byMALID[malID]
+	delay := partialRetryBackoff << min(retry.failures, 10)
+	retry.failures++
+	retry.next = time.Now().Add(min(delay, partialRetryMaxBackoff))
+	partialRetries.byMALID[malID] = retry
+}
+
+// ForceRefreshAnime fetches the anime again from every upstream. The calls
+// revalidate the response cache, so that a refresh sees new airing data
+// rather than what was cached before it.
+func ForceRefreshAnime(ctx context.Context, mapping *entities.Mapping) (*entities.Anime, error) {
+	return refreshAnime(ctx, mapping, nil)
+}
+
+func refreshAnime(ctx context.Context, mapping *entities.Mapping, sources []enums.Upstream) (*entities.Anime, error) {
 	if mapping == nil {
 		logger.Errorf("AnimeService", "Mapping is nil")
 		return nil, fmt.Errorf("mapping is nil")
 	}
+	ctx = upstream.WithRevalidate(ctx)
 
 	logger.Infof("AnimeService", "Force refreshing anime data for MAL ID: %d", mapping.MAL)
 
 	existingAnime, err := repositories.GetAnime(enums.MAL, mapping.MAL)
 	if err == nil {
-		return fetchAnime(mapping, &existingAnime)
+		return fetchAnime(ctx, mapping, &existingAnime, sources)
 	}
-	return fetchAnime(mapping, nil)
+	return fetchAnime(ctx, mapping, nil, nil)
 }
 
-func fetchAnime(mapping *entities.Mapping, existing *entities.Anime) (*entities.Anime, error) {
+// fetchAnime builds the anime from every upstream at once. The core details
+// come from Jikan or the MAL page, or from AniList when neither answered, so
+// the fetch only fails when all three are down and nothing is cached yet. Any
+// other failure leaves a partial anime that lists the upstreams it is
+// missing, keeping whatever an earlier fetch stored for them. Sources limits
+// the fetch to those upstreams, to retry the ones a partial anime is missing;
+// nil fetches from all of them.
+func fetchAnime(ctx context.Context, mapping *entities.Mapping, existing *entities.Anime, sources []enums.Upstream) (*entities.Anime, error) {
 	malID := mapping.MAL
+	wants := func(source enums.Upstream) bool {
+		return sources == nil || slices.Contains(sources, source)
+	}
 
 	var anime *entities.Anime
 	if existing != nil {
@@ -91,242 +285,567 @@ func fetchAnime(mapping *entities.Mapping, existing *entities.Anime) (*entities.
 	var jikanAnime *types.JikanAnimeResponse
 	var jikanEpisodes *types.JikanAnimeEpisodeResponse
 	var jikanCharacters *types.JikanAnimeCharacterResponse
+	var jikanStaff *types.JikanAnimeStaffResponse
 	var anilistData *types.AnilistAnimeResponse
 	var malSyncData *types.MalsyncAnimeResponse
+	var malAnime *mal.Anime
+	var jikanAnimeErr, jikanEpisodesErr, jikanCharactersErr, jikanStaffErr, anilistErr, malSyncErr, malErr error
 
-	fetchGroup, _ := errgroup.WithContext(context.Background())
+	var fetchGroup errgroup.Group
 
-	fetchGroup.Go(func() error {
-		var err error
-		jikanAnime, err = jikan.GetAnimeByMALID(malID)
-		if err != nil {
-			return fmt.Errorf("failed to fetch anime from Jikan: %w", err)
-		}
-		return nil
-	})
+	if wants(enums.UpstreamJikan) {
+		fetchGroup.Go(func() error {
+			jikanAnime, jikanAnimeErr = jikan.GetAnimeByMALID(ctx, malID)
+			return nil
+		})
 
-	fetchGroup.Go(func() error {
-		var err error
-		jikanEpisodes, err = jikan.GetAnimeEpisodesByMALID(malID)
-		if err != nil {
-			return fmt.Errorf("failed to fetch episodes from Jikan: %w", err)
-		}
-		return nil
-	})
+		fetchGroup.Go(func() error {
+			jikanEpisodes, jikanEpisodesErr = jikan.GetAnimeEpisodesByMALID(ctx, malID)
+			return nil
+		})
 
-	fetchGroup.Go(func() error {
-		var err error
-		jikanCharacters, err = jikan.GetAnimeCharactersByMALID(malID)
-		if err != nil {
-			logger.Warnf("AnimeService", "Failed to fetch characters from Jikan: %v", err)
-		}
-		return nil
-	})
+		fetchGroup.Go(func() error {
+			jikanCharacters, jikanCharactersErr = jikan.GetAnimeCharactersByMALID(ctx, malID)
+			return nil
+		})
 
-	if mapping.Anilist > 0 {
 		fetchGroup.Go(func() error {
-			var err error
-			anilistData, err = anilist.GetAnimeByAnilistID(mapping.Anilist)
-			if err != nil {
-				logger.Warnf("AnimeService", "Failed to fetch Anilist data: %v", err)
-			}
+			jikanStaff, jikanStaffErr = jikan.GetAnimeStaffByMALID(ctx, malID)
 			return nil
 		})
 	}
 
-	fetchGroup.Go(func() error {
-		var err error
-		malSyncData, err = malsync.GetAnimeByMALID(malID)
-		if err != nil {
-			logger.Warnf("AnimeService", "Failed to fetch MALsync data: %v", err)
-		}
-		return nil
-	})
+	if mapping.Anilist > 0 && wants(enums.UpstreamAnilist) {
+		fetchGroup.Go(func() error {
+			anilistData, anilistErr = anilist.GetAnimeByAnilistID(ctx, mapping.Anilist)
+			return nil
+		})
+	}
+
+	if wants(enums.UpstreamMALsync) {
+		fetchGroup.Go(func() error {
+			malSyncData, malSyncErr = malsync.GetAnimeByMALID(ctx, malID)
+			return nil
+		})
+	}
+
+	if wants(enums.UpstreamMAL) {
+		fetchGroup.Go(func() error {
+			malAnime, malErr = mal.GetAnimeDetailsByMALID(ctx, malID)
+			return nil
+		})
+	}
+
+	fetchGroup.Wait()
 
-	if err := fetchGroup.Wait(); err != nil {
-		logger.Errorf("AnimeService", "Failed to fetch anime data: %v", err)
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 
-	anime.Episodes = nil
-	anime.Characters = nil
-	anime.Genres = nil
-	anime.Themes = nil
-	anime.Demographics = nil
-	anime.Producers = nil
-	anime.Studios = nil
-	anime.Licensors = nil
-	anime.Schedule = nil
+	if jikanAnime == nil && malAnime == nil && anilistData == nil && existing == nil {
+		logger.Errorf("AnimeService", "Failed to fetch anime details (MAL ID: %d): Jikan: %v, MAL: %v, Anilist: %v", malID, jikanAnimeErr, malErr, anilistErr)
+		return nil, fmt.Errorf("failed to fetch anime details from Jikan, MAL or Anilist: %w", jikanAnimeErr)
+	}
+
+	// Upstreams left out of a retry keep what the last fetch stored.
+	var missing []enums.Upstream
+	markMissing := func(source enums.Upstream, what string, err error) {
+		if !wants(source) {
+			return
+		}
+		logger.Warnf("AnimeService", "Failed to fetch %s (MAL ID: %d): %v", what, malID, err)
+		if !slices.Contains(missing, source) {
+			missing = append(missing, source)
+		}
+	}
+
+	if jikanAnime != nil {
+		anime.Genres = nil
+		anime.Themes = nil
+		anime.Demographics = nil
+		anime.Producers = nil
+		anime.Studios = nil
+		anime.Licensors = nil
+	} else if wants(enums.UpstreamJikan) {
+		markMissing(enums.UpstreamJikan, "anime from Jikan", jikanAnimeErr)
+		if malAnime != nil {
+			applyMALDetails(anime, malAnime)
+		} else if anilistData != nil && existing == nil {
+			applyAnilistDetails(anime, anilistData)
+		}
+	}
+
+	if jikanEpisodes != nil {
+		anime.Episodes = nil
+	} else {
+		markMissing(enums.UpstreamJikan, "episodes from Jikan", jikanEpisodesErr)
+	}
 
-	applyJikanData(anime, jikanAnime, jikanEpisodes, jikanCharacters)
+	if jikanCharacters != nil {
+		anime.Characters = nil
+	} else {
+		markMissing(enums.UpstreamJikan, "characters from Jikan", jikanCharactersErr)
+	}
 
-	if anilistData != nil {
+	// The staff list is optional, so failing to fetch it leaves the stored one
+	// in place without marking the anime partial.
+	if jikanStaff != nil {
+		anime.Staff = nil
+	} else if jikanStaffErr != nil {
+		logger.Warnf("AnimeService", "Failed to fetch staff from Jikan (MAL ID: %d): %v", malID, jikanStaffErr)
+	}
+
+	applyJikanData(anime, jikanAnime, jikanEpisodes, jikanCharacters, jikanStaff)
+
+	if anilistErr != nil {
+		markMissing(enums.UpstreamAnilist, "Anilist data", anilistErr)
+	} else if wants(enums.UpstreamAnilist) {
+		anime.Schedule = nil
 		applyAnilistData(anime, anilistData)
 	}
 
-	if malSyncData != nil {
+	if malSyncErr != nil {
+		markMissing(enums.UpstreamMALsync, "MALsync data", malSyncErr)
+	} else if wants(enums.UpstreamMALsync) {
 		applyMALsyncData(anime, malSyncData)
 	}
 
+	if malErr != nil {
+		markMissing(enums.UpstreamMAL, "MAL page data", malErr)
+	} else if wants(enums.UpstreamMAL) {
+		applyMALData(anime, malAnime)
+	}
+
 	animeType := string(mapping.Type)
 	if (animeType == "MOVIE" || animeType == "Movie") && mapping.TMDB > 0 {
-		logger.Infof("AnimeService", "Enriching movie episode from TMDB")
-		if err := tmdb.EnrichEpisodeFromMovie(anime); err != nil {
-			logger.Warnf("AnimeService", "Failed to enrich movie from TMDB: %v", err)
+		if wants(enums.UpstreamTMDB) {
+			logger.Infof("AnimeService", "Enriching movie episode from TMDB")
+			if err := tmdb.EnrichEpisodeFromMovie(ctx, anime); tmdbFailed(err) {
+				markMissing(enums.UpstreamTMDB, "movie from TMDB", err)
+			}
 		}
 	} else {
-		if mapping.TVDB > 0 {
+		enriched := false
+		var tvdbErr error
+		if mapping.TVDB > 0 && wants(enums.UpstreamTVDB) {
 			logger.Infof("AnimeService", "Enriching episodes from TVDB")
-			tvdbEpisodes, err := tvdb.GetSeriesEpisodes(mapping.TVDB)
+			tvdbEpisodes, err := tvdb.GetSeriesEpisodes(ctx, mapping.TVDB)
+			tvdbErr = err
 			if err == nil && len(tvdbEpisodes) > 0 {
 				tvdb.EnrichEpisodesFromTVDB(anime, tvdbEpisodes)
 				logger.Successf("AnimeService", "Successfully enriched %d episodes from TVDB", len(tvdbEpisodes))
+				enriched = true
 			} else {
-				logger.Warnf("AnimeService", "Failed to fetch TVDB episodes: %v, falling back to TMDB", err)
-				applyTMDBData(anime)
+				logger.Infof("AnimeService", "No TVDB episodes, falling back to TMDB")
 			}
-		} else {
-			applyTMDBData(anime)
+		}
+		if !enriched && wants(enums.UpstreamTMDB) {
+			err := applyTMDBData(ctx, anime)
+			if tmdbFailed(err) {
+				markMissing(enums.UpstreamTMDB, "TMDB episodes", err)
+			} else if err == nil && anime.Mapping != nil && anime.Mapping.TMDB > 0 {
+				enriched = true
+			}
+		}
+		// TMDB standing in for TVDB makes a TVDB outage harmless.
+		if !enriched && tvdbFailed(tvdbErr) {
+			markMissing(enums.UpstreamTVDB, "TVDB episodes", tvdbErr)
 		}
 	}
 
 	epSkipMap := make(map[string][]entities.EpisodeSkipTime)
-	if mapping.Anilist > 0 {
+	if mapping.Anilist > 0 && wants(enums.UpstreamAniskip) {
 		logger.Infof("AnimeService", "Enriching episodes with Aniskip data")
+		episodeNumbers := make([]int, len(anime.Episodes))
+		for i, episode := range anime.Episodes {
+			episodeNumbers[i] = episode.EpisodeNumber
+		}
+		skipData, err := aniskip.GetSkipTimesForEpisodes(ctx, malID, episodeNumbers)
+		if err != nil {
+			markMissing(enums.UpstreamAniskip, "Aniskip data", err)
+		}
 		for i := range anime.Episodes {
 			episode := &anime.Episodes[i]
-			skipData, err := aniskip.GetSkipTimesForEpisode(malID, episode.EpisodeNumber)
-			if err != nil {
-				continue
-			}
-			skipTimes := applyAniskipData(episode, skipData)
+			skipTimes := applyAniskipData(episode, skipData[episode.EpisodeNumber])
 			if len(skipTimes) > 0 {
 				epSkipMap[episode.EpisodeID] = skipTimes
 			}
 		}
 	}
 
-	applyStreamingData(anime)
+	if wants(enums.UpstreamStreaming) {
+		if err := applyStreamingData(ctx, anime); err != nil {
+			markMissing(enums.UpstreamStreaming, "streaming data", err)
+		}
+	}
 
 	if mapping.TVDB > 0 || mapping.TMDB > 0 {
 		logger.Infof("AnimeService", "Fetching related anime seasons")
-		applySeasonData(anime, mapping)
+		applySeasonData(ctx, anime, mapping)
+	}
+
+	// A cancelled fetch leaves the entity half enriched, so keep the old row.
+	if err := ctx.Err(); err != nil {
+		logger.Warnf("AnimeService", "Fetch cancelled before saving (MAL ID: %d): %v", malID, err)
+		return nil, err
+	}
+
+	// A partial anime is not enriched, so the next sync refetches it.
+	anime.MissingSources = missing
+	if len(missing) > 0 {
+		anime.EnrichedAt = nil
 	}
 
 	if err := saveAnime(anime, epSkipMap); err != nil {
 		logger.Errorf("AnimeService", "Failed to save anime to database: %v", err)
 		return nil, fmt.Errorf("failed to save anime to database: %w", err)
 	}
+	notePartialFetch(malID, missing)
+
+	if len(missing) > 0 {
+		logger.Warnf("AnimeService", "Saved partial anime (MAL ID: %d), missing %v", malID, missing)
+		return anime, nil
+	}
 
 	logger.Successf("AnimeService", "Successfully fetched and saved anime (MAL ID: %d)", malID)
 	return anime, nil
 }
 
-func applyTMDBData(anime *entities.Anime) {
+func applyTMDBData(ctx context.Context, anime *entities.Anime) error {
 	if anime.Mapping != nil && anime.Mapping.TMDB > 0 {
 		logger.Infof("AnimeService", "Enriching episodes from TMDB")
-		if err := tmdb.AttachEpisodeDescriptions(anime); err != nil {
-			logger.Warnf("AnimeService", "Failed to enrich episodes from TMDB: %v", err)
-		} else {
-			logger.Successf("AnimeService", "Successfully enriched episodes from TMDB")
+		if err := tmdb.AttachEpisodeDescriptions(ctx, anime); err != nil {
+			return err
 		}
+		logger.Successf("AnimeService", "Successfully enriched episodes from TMDB")
 	}
+	return nil
 }
 
-func applyJikanData(anime *entities.Anime, jikanAnime *types.JikanAnimeResponse, jikanEpisodes *types.JikanAnimeEpisodeResponse, jikanCharacters *types.JikanAnimeCharacterResponse) {
-	anime.Synopsis = jikanAnime.Data.Synopsis
-	anime.Type = jikanAnime.Data.Type
-	anime.Source = jikanAnime.Data.Source
-	anime.Airing = jikanAnime.Data.Airing
-	anime.Status = jikanAnime.Data.Status
-	anime.Duration = jikanAnime.Data.Duration
-	anime.Season = jikanAnime.Data.Season
-	anime.Year = jikanAnime.Data.Year
-	anime.Rating = jikanAnime.Data.Rating
-	anime.Background = jikanAnime.Data.Background
+// tmdbFailed tells a TMDB outage apart from TMDB not being set up or not
+// knowing the anime, neither of which a later fetch would change.
+func tmdbFailed(err error) bool {
+	return err != nil && !errors.Is(err, tmdb.ErrNotConfigured) && !errors.Is(err, tmdb.ErrNoMatch)
+}
+
+// tvdbFailed tells a TVDB outage apart from TVDB not being set up or not
+// knowing the series, neither of which a later fetch would change.
+func tvdbFailed(err error) bool {
+	return err != nil && !errors.Is(err, tvdb.ErrNotConfigured) && !errors.Is(err, tvdb.ErrNotFound)
+}
+
+// applyJikanData copies whichever of the four Jikan responses were fetched.
+func applyJikanData(anime *entities.Anime, jikanAnime *types.JikanAnimeResponse, jikanEpisodes *types.JikanAnimeEpisodeResponse, jikanCharacters *types.JikanAnimeCharacterResponse, jikanStaff *types.JikanAnimeStaffResponse) {
+	if jikanAnime != nil {
+		applyJikanDetails(anime, &jikanAnime.Data)
+	}
+
+	if jikanEpisodes != nil {
+		anime.AiredEpisodes = len(jikanEpisodes.Data)
+
+		for _, jikanEpisode := range jikanEpisodes.Data {
+			episode := entities.Episode{
+				EpisodeNumber: jikanEpisode.MALID,
+				URL:           jikanEpisode.URL,
+				Aired:         jikanEpisode.Aired,
+				Score:         jikanEpisode.Score,
+				Filler:        jikanEpisode.Filler,
+				Recap:         jikanEpisode.Recap,
+				ForumURL:      jikanEpisode.ForumURL,
+				Title: entities.EpisodeTitle{
+					English:  jikanEpisode.Title,
+					Japanese: jikanEpisode.TitleJapanese,
+					Romaji:   jikanEpisode.TitleRomaji,
+				},
+			}
+
+			titleForID := jikanEpisode.Title
+			if titleForID == "" {
+				titleForID = jikanEpisode.TitleRomaji
+			}
+			episode.EpisodeID = generateEpisodeID(anime.MALID, jikanEpisode.MALID, titleForID)
+
+			anime.Episodes = append(anime.Episodes, episode)
+		}
+	}
+
+	if jikanCharacters != nil {
+		for _, jikanCharacter := range jikanCharacters.Data {
+			character := entities.Character{
+				MALID:    jikanCharacter.Character.MALID,
+				Name:     jikanCharacter.Character.Name,
+				URL:      jikanCharacter.Character.URL,
+				ImageURL: jikanCharacter.Character.Images.JPG.ImageURL,
+				Role:     jikanCharacter.Role,
+			}
+
+			for _, voiceActor := range jikanCharacter.VoiceActors {
+				character.VoiceActors = append(character.VoiceActors, entities.CharacterVoiceActor{
+					Language: voiceActor.Language,
+					Person: &entities.Person{
+						Image: voiceActor.Person.Images.JPG.ImageURL,
+					},
+				})
+			}
+
+			anime.Characters = append(anime.Characters, character)
+		}
+	}
+
+	if jikanStaff != nil {
+		for _, member := range jikanStaff.Data {
+			anime.Staff = append(anime.Staff, entities.AnimeStaff{
+				Positions: member.Positions,
+				Person: &entities.Person{
+					MALID: member.Person.MALID,
+					URL:   member.Person.URL,
+					Image: member.Person.Images.JPG.ImageURL,
+					Name:  member.Person.Name,
+				},
+			})
+		}
+	}
+}
+
+// applyMALDetails fills the core details from the MAL page when Jikan could
+// not be reached. The page has no genre or producer names, so those are left
+// as they were.
+func applyMALDetails(anime *entities.Anime, malAnime *mal.Anime) {
+	anime.Synopsis = malAnime.Synopsis
+	anime.Type = string(malAnime.Type)
+	anime.Source = string(malAnime.Source)
+	anime.Airing = malAnime.Airing
+	anime.Status = string(malAnime.Status)
+	anime.Duration = malAnime.Duration
+	anime.Season = strings.ToLower(string(malAnime.Premiered.Season))
+	anime.Year = malAnime.Premiered.Year
+	anime.Rating = string(malAnime.Rating)
+	anime.Background = malAnime.Background
+	anime.TotalEpisodes = malAnime.EpisodeCount
 
 	anime.Title = entities.AnimeTitle{
-		Romaji:   jikanAnime.Data.Title,
-		English:  jikanAnime.Data.TitleEnglish,
-		Japanese: jikanAnime.Data.TitleJapanese,
-		Synonyms: jikanAnime.Data.TitleSynonyms,
+		Romaji:   malAnime.Title.Romaji,
+		English:  malAnime.Title.English,
+		Japanese: malAnime.Title.Japanese,
+		Synonyms: malAnime.Title.Synonyms,
 	}
 
 	anime.Scores = entities.AnimeScores{
-		Score:      jikanAnime.Data.Score,
-		ScoredBy:   jikanAnime.Data.ScoredBy,
-		Rank:       jikanAnime.Data.Rank,
-		Popularity: jikanAnime.Data.Popularity,
-		Members:    jikanAnime.Data.Members,
-		Favorites:  jikanAnime.Data.Favorites,
+		Score:      malAnime.Statistics.Score,
+		ScoredBy:   malAnime.Statistics.ScoredBy,
+		Rank:       malAnime.Statistics.Rank,
+		Popularity: malAnime.Statistics.Popularity,
+		Members:    malAnime.Statistics.Members,
+		Favorites:  malAnime.Statistics.Favorites,
+	}
+
+	anime.Images = entities.AnimeImages{
+		Small:    malAnime.Image.Small,
+		Large:    malAnime.Image.Large,
+		Original: malAnime.Image.Original,
+	}
+
+	anime.Aired = entities.AnimeAired{
+		String: malAnime.Aired.String,
+		From:   malAiredDate(malAnime.Aired.From),
+		To:     malAiredDate(malAnime.Aired.To),
+	}
+
+	anime.Broadcast = entities.AnimeBroadcast{
+		Day:      malAnime.Broadcast.Day,
+		Time:     malAnime.Broadcast.Time,
+		Timezone: malAnime.Broadcast.Timezone,
+		String:   malAnime.Broadcast.String,
+	}
+
+	anime.Trailer = entities.AnimeTrailer{
+		YoutubeID: malAnime.Trailer.YoutubeID,
+		URL:       malAnime.Trailer.URL,
+		EmbedURL:  malAnime.Trailer.EmbedURL,
+	}
+}
+
+// htmlTagPattern matches the tags AniList puts in descriptions.
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// anilistFormats maps AniList formats to the types MAL uses.
+var anilistFormats = map[string]mal.Type{
+	"TV":       mal.TypeTV,
+	"TV_SHORT": mal.TypeTV,
+	"MOVIE":    mal.TypeMovie,
+	"SPECIAL":  mal.TypeSpecial,
+	"OVA":      mal.TypeOVA,
+	"ONA":      mal.TypeONA,
+	"MUSIC":    mal.TypeMusic,
+}
+
+// anilistStatuses maps AniList statuses to the ones MAL uses.
+var anilistStatuses = map[string]mal.Status{
+	"RELEASING":        mal.StatusAiring,
+	"FINISHED":         mal.StatusFinished,
+	"NOT_YET_RELEASED": mal.StatusNotYetAired,
+}
+
+// applyAnilistDetails fills the core details from AniList for a new anime
+// when neither Jikan nor the MAL page could be reached. AniList has no MAL
+// scores, genres or producers, so those wait for the retry.
+func applyAnilistDetails(anime *entities.Anime, anilistData *types.AnilistAnimeResponse) {
+	media := anilistData.Data.Media
+	if media.ID == 0 {
+		return
+	}
+
+	anime.Synopsis = html.UnescapeString(htmlTagPattern.ReplaceAllString(media.Description, ""))
+	anime.Type = string(anilistFormats[media.Format])
+	anime.Status = string(anilistStatuses[media.Status])
+	anime.Airing = media.Status == "RELEASING"
+	anime.Season = strings.ToLower(media.Season)
+	anime.Year = media.SeasonYear
+	anime.TotalEpisodes = media.Episodes
+	if media.Duration > 0 {
+		anime.Duration = fmt.Sprintf("%d min per ep", media.Duration)
+	}
+
+	anime.Title = entities.AnimeTitle{
+		Romaji:   media.Title.Romaji,
+		English:  media.Title.English,
+		Japanese: media.Title.Native,
+		Synonyms: media.Synonyms,
 	}
 
 	anime.Images = entities.AnimeImages{
-		Small:    jikanAnime.Data.Images.JPG.SmallImageURL,
-		Large:    jikanAnime.Data.Images.JPG.LargeImageURL,
-		Original: jikanAnime.Data.Images.JPG.ImageURL,
+		Small:    media.CoverImage.Medium,
+		Large:    media.CoverImage.Large,
+		Original: media.CoverImage.ExtraLarge,
 	}
 
 	anime.Aired = entities.AnimeAired{
-		String: jikanAnime.Data.Aired.String,
+		From: anilistDate(media.StartDate),
+		To:   anilistDate(media.EndDate),
+	}
+}
+
+func anilistDate(date types.AnilistDate) *time.Time {
+	if date.Year == 0 {
+		return nil
 	}
-	if jikanAnime.Data.Aired.From != "" {
-		if parsedTime, err := time.Parse(time.RFC3339, jikanAnime.Data.Aired.From); err == nil {
+
+	month, day := max(date.Month, 1), max(date.Day, 1)
+	parsed := time.Date(date.Year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	return &parsed
+}
+
+func malAiredDate(date mal.AiredDate) *time.Time {
+	if date.Year == 0 {
+		return nil
+	}
+
+	month, day := max(date.Month, 1), max(date.Day, 1)
+	parsed := time.Date(date.Year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	return &parsed
+}
+
+// applyJikanDetails copies the fields of a Jikan anime object that do not
+// depend on the separate episodes and characters endpoints.
+func applyJikanDetails(anime *entities.Anime, data *types.JikanSingleAnime) {
+	anime.Synopsis = data.Synopsis
+	anime.Type = data.Type
+	anime.Source = data.Source
+	anime.Airing = data.Airing
+	anime.Status = data.Status
+	anime.Duration = data.Duration
+	anime.Season = data.Season
+	anime.Year = data.Year
+	anime.Rating = data.Rating
+	anime.Background = data.Background
+	anime.TotalEpisodes = data.Episodes
+
+	anime.Title = entities.AnimeTitle{
+		Romaji:   data.Title,
+		English:  data.TitleEnglish,
+		Japanese: data.TitleJapanese,
+		Synonyms: data.TitleSynonyms,
+	}
+
+	anime.Scores = entities.AnimeScores{
+		Score:      data.Score,
+		ScoredBy:   data.ScoredBy,
+		Rank:       data.Rank,
+		Popularity: data.Popularity,
+		Members:    data.Members,
+		Favorites:  data.Favorites,
+	}
+
+	anime.Images = entities.AnimeImages{
+		Small:    data.Images.JPG.SmallImageURL,
+		Large:    data.Images.JPG.LargeImageURL,
+		Original: data.Images.JPG.ImageURL,
+	}
+
+	anime.Aired = entities.AnimeAired{
+		String: data.Aired.String,
+	}
+	if data.Aired.From != "" {
+		if parsedTime, err := time.Parse(time.RFC3339, data.Aired.From); err == nil {
 			anime.Aired.From = &parsedTime
 		}
 	}
-	if jikanAnime.Data.Aired.To != "" {
-		if parsedTime, err := time.Parse(time.RFC3339, jikanAnime.Data.Aired.To); err == nil {
+	if data.Aired.To != "" {
+		if parsedTime, err := time.Parse(time.RFC3339, data.Aired.To); err == nil {
 			anime.Aired.To = &parsedTime
 		}
 	}
 
 	anime.Broadcast = entities.AnimeBroadcast{
-		Day:      jikanAnime.Data.Broadcast.Day,
-		Time:     jikanAnime.Data.Broadcast.Time,
-		Timezone: jikanAnime.Data.Broadcast.Timezone,
-		String:   jikanAnime.Data.Broadcast.String,
+		Day:      data.Broadcast.Day,
+		Time:     data.Broadcast.Time,
+		Timezone: data.Broadcast.Timezone,
+		String:   data.Broadcast.String,
 	}
 
 	anime.Trailer = entities.AnimeTrailer{
-		YoutubeID: jikanAnime.Data.Trailer.YoutubeID,
-		URL:       jikanAnime.Data.Trailer.URL,
-		EmbedURL:  jikanAnime.Data.Trailer.EmbedURL,
+		YoutubeID: data.Trailer.YoutubeID,
+		URL:       data.Trailer.URL,
+		EmbedURL:  data.Trailer.EmbedURL,
 	}
 
-	for _, genreEntry := range jikanAnime.Data.Genres {
+	for _, genreEntry := range data.Genres {
 		anime.Genres = append(anime.Genres, entities.Genre{
 			GenreID: genreEntry.MALID,
 			Name:    genreEntry.Name,
 			URL:     genreEntry.URL,
+			Type:    enums.GenreTypeGenre,
 		})
 	}
 
-	for _, genreEntry := range jikanAnime.Data.ExplicitGenres {
+	for _, genreEntry := range data.ExplicitGenres {
 		anime.Genres = append(anime.Genres, entities.Genre{
 			GenreID: genreEntry.MALID,
 			Name:    genreEntry.Name,
 			URL:     genreEntry.URL,
+			Type:    enums.GenreTypeExplicitGenre,
 		})
 	}
 
-	for _, themeEntry := range jikanAnime.Data.Themes {
+	for _, themeEntry := range data.Themes {
 		anime.Themes = append(anime.Themes, entities.Genre{
 			GenreID: themeEntry.MALID,
 			Name:    themeEntry.Name,
 			URL:     themeEntry.URL,
+			Type:    enums.GenreTypeTheme,
 		})
 	}
 
-	for _, demographicEntry := range jikanAnime.Data.Demographics {
+	for _, demographicEntry := range data.Demographics {
 		anime.Demographics = append(anime.Demographics, entities.Genre{
 			GenreID: demographicEntry.MALID,
 			Name:    demographicEntry.Name,
 			URL:     demographicEntry.URL,
+			Type:    enums.GenreTypeDemographic,
 		})
 	}
 
-	for _, producerEntry := range jikanAnime.Data.Producers {
+	for _, producerEntry := range data.Producers {
 		producer := entities.Producer{
 			MALID: producerEntry.MALID,
 			URL:   producerEntry.URL,
@@ -337,7 +856,7 @@ func applyJikanData(anime *entities.Anime, jikanAnime *types.JikanAnimeResponse,
 		anime.Producers = append(anime.Producers, producer)
 	}
 
-	for _, studioEntry := range jikanAnime.Data.Studios {
+	for _, studioEntry := range data.Studios {
 		studio := entities.Producer{
 			MALID: studioEntry.MALID,
 			URL:   studioEntry.URL,
@@ -348,7 +867,7 @@ func applyJikanData(anime *entities.Anime, jikanAnime *types.JikanAnimeResponse,
 		anime.Studios = append(anime.Studios, studio)
 	}
 
-	for _, licensorEntry := range jikanAnime.Data.Licensors {
+	for _, licensorEntry := range data.Licensors {
 		licensor := entities.Producer{
 			MALID: licensorEntry.MALID,
 			URL:   licensorEntry.URL,
@@ -358,57 +877,6 @@ func applyJikanData(anime *entities.Anime, jikanAnime *types.JikanAnimeResponse,
 		}
 		anime.Licensors = append(anime.Licensors, licensor)
 	}
-
-	anime.TotalEpisodes = jikanAnime.Data.Episodes
-	anime.AiredEpisodes = len(jikanEpisodes.Data)
-
-	for _, jikanEpisode := range jikanEpisodes.Data {
-		episode := entities.Episode{
-			EpisodeNumber: jikanEpisode.MALID,
-			URL:           jikanEpisode.URL,
-			Aired:         jikanEpisode.Aired,
-			Score:         jikanEpisode.Score,
-			Filler:        jikanEpisode.Filler,
-			Recap:         jikanEpisode.Recap,
-			ForumURL:      jikanEpisode.ForumURL,
-			Title: entities.EpisodeTitle{
-				English:  jikanEpisode.Title,
-				Japanese: jikanEpisode.TitleJapanese,
-				Romaji:   jikanEpisode.TitleRomaji,
-			},
-		}
-
-		titleForID := jikanEpisode.Title
-		if titleForID == "" {
-			titleForID = jikanEpisode.TitleRomaji
-		}
-		episode.EpisodeID = generateEpisodeID(anime.MALID, jikanEpisode.MALID, titleForID)
-
-		anime.Episodes = append(anime.Episodes, episode)
-	}
-
-	if jikanCharacters != nil {
-		for _, jikanCharacter := range jikanCharacters.Data {
-			character := entities.Character{
-				MALID:    jikanCharacter.Character.MALID,
-				Name:     jikanCharacter.Character.Name,
-				URL:      jikanCharacter.Character.URL,
-				ImageURL: jikanCharacter.Character.Images.JPG.ImageURL,
-				Role:     jikanCharacter.Role,
-			}
-
-			for _, voiceActor := range jikanCharacter.VoiceActors {
-				character.VoiceActors = append(character.VoiceActors, entities.CharacterVoiceActor{
-					Language: voiceActor.Language,
-					Person: &entities.Person{
-						Image: voiceActor.Person.Images.JPG.ImageURL,
-					},
-				})
-			}
-
-			anime.Characters = append(anime.Characters, character)
-		}
-	}
 }
 
 func applyAnilistData(anime *entities.Anime, anilistData *types.AnilistAnimeResponse) {
@@ -454,6 +922,90 @@ func applyMALsyncData(anime *entities.Anime, malSyncData *types.MalsyncAnimeResp
 	}
 }
 
+func applyMALData(anime *entities.Anime, malAnime *mal.Anime) {
+	anime.ThemeSongs = nil
+	anime.Videos = nil
+	anime.Links = nil
+	anime.Relations = nil
+
+	themeSongGroups := []struct {
+		songType enums.ThemeSongType
+		songs    []mal.ThemeSong
+	}{
+		{enums.Opening, malAnime.Openings},
+		{enums.Ending, malAnime.Endings},
+	}
+
+	for _, group := range themeSongGroups {
+		for i, song := range group.songs {
+			themeSong := entities.AnimeThemeSong{
+				Type:          group.songType,
+				Position:      i + 1,
+				Title:         song.Title.Romaji,
+				TitleJapanese: song.Title.Japanese,
+				Artist:        song.Artist,
+				EpisodeStart:  song.Episodes.Start,
+				EpisodeEnd:    song.Episodes.End,
+			}
+			for _, link := range song.Links {
+				themeSong.Links = append(themeSong.Links, entities.ThemeSongLink{Name: link.Name, URL: link.URL})
+			}
+			anime.ThemeSongs = append(anime.ThemeSongs, themeSong)
+		}
+	}
+
+	for _, video := range malAnime.Videos {
+		anime.Videos = append(anime.Videos, entities.AnimeVideo{
+			Type:  enums.PromotionalVideo,
+			Title: video.Title.Romaji,
+			URL:   video.URL,
+			Thumbnail: entities.AnimeImages{
+				Small:    video.Thumbnail.Small,
+				Large:    video.Thumbnail.Large,
+				Original: video.Thumbnail.Original,
+			},
+		})
+	}
+
+	for _, video := range malAnime.MusicVideos {
+		anime.Videos = append(anime.Videos, entities.AnimeVideo{
+			Type:   enums.MusicVideo,
+			Title:  video.Title.Romaji,
+			Artist: video.Artist,
+			URL:    video.URL,
+			Thumbnail: entities.AnimeImages{
+				Small:    video.Thumbnail.Small,
+				Large:    video.Thumbnail.Large,
+				Original: video.Thumbnail.Original,
+			},
+		})
+	}
+
+	for _, link := range malAnime.External {
+		anime.Links = append(anime.Links, entities.AnimeLink{Type: enums.ExternalLink, Name: link.Name, URL: link.URL})
+	}
+
+	for _, link := range malAnime.Streaming {
+		anime.Links = append(anime.Links, entities.AnimeLink{Type: enums.StreamingLink, Name: link.Name, URL: link.URL})
+	}
+
+	for _, related := range malAnime.Relations {
+		anime.Relations = append(anime.Relations, entities.AnimeRelation{
+			Relation: malRelationTypes[related.Relation],
+			Media:    enums.RelatedMediaType(related.Kind),
+			MALID:    related.MALID,
+			Title:    related.Title,
+			Format:   related.Format,
+			URL:      related.URL,
+			Image: entities.AnimeImages{
+				Small:    related.Image.Small,
+				Large:    related.Image.Large,
+				Original: related.Image.Original,
+			},
+		})
+	}
+}
+
 func extractLogosFromMALSync(malSyncData *types.MalsyncAnimeResponse) entities.AnimeLogos {
 	if malSyncData == nil {
 		return entities.AnimeLogos{}
@@ -535,26 +1087,31 @@ func applyAniskipData(episode *entities.Episode, skipData []types.AniskipResult)
 	return skipTimes
 }
 
-func applyStreamingData(anime *entities.Anime) {
+// applyStreamingData returns an error only when the streaming upstream failed.
+// An anime it has no match for is not an error.
+func applyStreamingData(ctx context.Context, anime *entities.Anime) error {
 	searchTitle := anime.Title.Romaji
 	if searchTitle == "" {
 		searchTitle = anime.Title.English
 	}
 	if searchTitle == "" {
-		return
+		return nil
 	}
 
-	subCount, dubCount, err := streaming.GetStreamingCounts(searchTitle)
+	subCount, dubCount, err := streaming.GetStreamingCounts(ctx, searchTitle)
 	if err != nil && anime.Title.English != "" && anime.Title.English != searchTitle {
-		subCount, dubCount, err = streaming.GetStreamingCounts(anime.Title.English)
+		subCount, dubCount, err = streaming.GetStreamingCounts(ctx, anime.Title.English)
 		if err == nil {
 			searchTitle = anime.Title.English
 		}
 	}
 
+	if errors.Is(err, streaming.ErrNoMatch) {
+		logger.Infof("AnimeService", "No streaming match for: %s", searchTitle)
+		return nil
+	}
 	if err != nil {
-		logger.Warnf("AnimeService", "Failed to fetch streaming counts: %v", err)
-		return
+		return err
 	}
 
 	anime.SubbedCount = subCount
@@ -565,7 +1122,10 @@ func applyStreamingData(anime *entities.Anime) {
 		for i, episode := range anime.Episodes {
 			episodeNumbers[i] = episode.EpisodeNumber
 		}
-		sourcesMap, err := streaming.FetchAllEpisodeSources(searchTitle, episodeNumbers)
+		sourcesMap, err := streaming.FetchAllEpisodeSources(ctx, searchTitle, episodeNumbers)
+		if err != nil && !errors.Is(err, streaming.ErrNoMatch) {
+			return err
+		}
 		if err == nil {
 			for i := range anime.Episodes {
 				episode := &anime.Episodes[i]
@@ -586,6 +1146,8 @@ func applyStreamingData(anime *entities.Anime) {
 			}
 		}
 	}
+
+	return nil
 }
 
 func generateEpisodeID(malID int, episodeNumber int, title string) string {
@@ -594,7 +1156,7 @@ func generateEpisodeID(malID int, episodeNumber int, title string) string {
 	return fmt.Sprintf("%x", hash)
 }
 
-func applySeasonData(anime *entities.Anime, mapping *entities.Mapping) {
+func applySeasonData(ctx context.Context, anime *entities.Anime, mapping *entities.Mapping) {
 	var relatedMappings []entities.Mapping
 	malIDSet := make(map[int]bool)
 
@@ -640,7 +1202,7 @@ func applySeasonData(anime *entities.Anime, mapping *entities.Mapping) {
 	}}
 
 	for _, relatedMapping := range relatedMappings {
-		seasonAnime, err := jikan.GetAnimeByMALID(relatedMapping.MAL)
+		seasonAnime, err := jikan.GetAnimeByMALID(ctx, relatedMapping.MAL)
 		if err != nil {
 			logger.Warnf("AnimeService", "Failed to fetch season data for MAL ID %d: %v", relatedMapping.MAL, err)
 			continue
@@ -760,6 +1322,14 @@ func saveAnime(anime *entities.Anime, skipTimeMap map[string][]entities.EpisodeS
 		return fmt.Errorf("failed to save anime: %w", err)
 	}
 
+	if err := repositories.ReplaceAnimeMedia(anime.ID, anime.ThemeSongs, anime.Videos, anime.Links); err != nil {
+		logger.Warnf("AnimeService", "Failed to save theme songs, videos and links: %v", err)
+	}
+
+	if err := repositories.ReplaceAnimeRelations(anime.ID, anime.Relations); err != nil {
+		logger.Warnf("AnimeService", "Failed to save relations: %v", err)
+	}
+
 	if len(anime.Episodes) > 0 {
 		if err := repositories.SaveAnimeEpisodes(anime.ID, anime.Episodes); err != nil {
 			logger.Warnf("AnimeService", "Failed to save episodes: %v", err)
@@ -780,6 +1350,12 @@ func saveAnime(anime *entities.Anime, skipTimeMap map[string][]entities.EpisodeS
 		}
 	}
 
+	if len(anime.Staff) > 0 {
+		if err := repositories.ReplaceAnimeStaff(anime); err != nil {
+			logger.Warnf("AnimeService", "Failed to save staff: %v", err)
+		}
+	}
+
 	for episodeID, skipTimes := range skipTimeMap {
 		if err := repositories.SaveEpisodeSkipTimes(episodeID, skipTimes); err != nil {
 			logger.Warnf("AnimeService", "Failed to save skip times for episode %s: %v", episodeID, err)
@@ -787,6 +1363,9 @@ func saveAnime(anime *entities.Anime, skipTimeMap map[string][]entities.EpisodeS
 	}
 
 	logger.Successf("AnimeService", "Saved anime with %d episodes, %d characters, %d skip time entries", len(anime.Episodes), len(anime.Characters), len(skipTimeMap))
+	if len(anime.MissingSources) > 0 {
+		return nil
+	}
 	if err := repositories.SetAnimeEnriched(anime.MALID); err != nil {
 		logger.Warnf("AnimeService", "Failed to stamp enriched_at for anime %d: %v", anime.MALID, err)
 	}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrShuttingDown is returned for fetches asked for after Shutdown.
+var ErrShuttingDown = errors.New("anime service is shutting down")
+
+// background tracks the fetches that outlive the request that started them,
+// so that Shutdown can cancel them and wait for them to finish writing.
+var background = func() *backgroundWork {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &backgroundWork{ctx: ctx, cancel: cancel}
+}()
+
+type backgroundWork struct {
+	mu      sync.Mutex
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	stopped bool
+}
+
+// start reserves a slot for a background fetch and returns a context carrying
+// the values of ctx that is cancelled on shutdown rather than with ctx. It
+// reports false once Shutdown has been called. The returned func must be
+// called when the fetch is done.
+func (b *backgroundWork) start(ctx context.Context) (context.Context, func(), bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.stopped {
+		return nil, nil, false
+	}
+	b.wg.Add(1)
+
+	detached, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(b.ctx, cancel)
+	return detached, func() {
+		stop()
+		cancel()
+		b.wg.Done()
+	}, true
+}
+
+// Shutdown cancels the fetches running in the background and waits for them
+// to return. It gives up when ctx expires.
+func Shutdown(ctx context.Context) error {
+	background.mu.Lock()
+	background.stopped = true
+	background.cancel()
+	background.mu.Unlock()
+
+	stopped := make(chan struct{})
+	go func() {
+		background.wg.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"context"
+	"metachan/entities"
+	"metachan/enums"
+	"metachan/repositories"
+	"metachan/types"
+	"metachan/utils/franchise"
+	"metachan/utils/logger"
+	"strconv"
+)
+
+// maxFranchiseEntries bounds the walk for the longest running franchises.
+const maxFranchiseEntries = 300
+
+// franchiseRelations are the MAL relations followed when walking a
+// franchise. Character and Other are left out since MAL uses them for
+// crossovers and tie-ins with otherwise unrelated shows.
+var franchiseRelations = map[enums.AnimeRelationType]bool{
+	enums.Sequel:             true,
+	enums.Prequel:            true,
+	enums.AlternativeSetting: true,
+	enums.AlternativeVersion: true,
+	enums.SideStory:          true,
+	enums.ParentStory:        true,
+	enums.Summary:            true,
+	enums.FullStory:          true,
+	enums.SpinOff:            true,
+}
+
+// GetFranchise walks the relations stored for an anime and suggests the
+// orders to watch the franchise in. The anime is loaded first, so that its
+// own relations are there even if it was never requested before.
+func GetFranchise(ctx context.Context, mapping *entities.Mapping) (*types.Franchise, error) {
+	if _, _, err := GetAnime(ctx, mapping); err != nil {
+		return nil, err
+	}
+
+	malIDs, edges, err := walkFranchise(mapping.MAL)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := franchiseEntries(malIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	franchise.SortByRelease(entries)
+	release := make([]int, len(entries))
+	for i, entry := range entries {
+		release[i] = entry.MALID
+	}
+	chronological := franchise.ChronologicalOrder(release, edges)
+
+	logger.Debugf("Franchise", "Franchise of MAL ID %d has %d entries and %d edges", mapping.MAL, len(entries), len(edges))
+
+	return &types.Franchise{
+		MALID:   mapping.MAL,
+		Entries: entries,
+		Edges:   edges,
+		WatchOrders: types.WatchOrders{
+			Release:       release,
+			Chronological: chronological,
+			MainStory:     franchise.MainStoryOrder(mapping.MAL, chronological, entries, edges),
+		},
+	}, nil
+}
+
+// walkFranchise collects the anime reachable from root one layer at a time,
+// along MAL relations in either direction and shared TVDB and TMDB series.
+func walkFranchise(root int) ([]int, []types.FranchiseEdge, error) {
+	malIDs := []int{root}
+	seen := map[int]bool{root: true}
+	edges := []types.FranchiseEdge{}
+	seenEdges := make(map[types.FranchiseEdge]bool)
+
+	var next []int
+	addEdge := func(edge types.FranchiseEdge) {
+		for _, malID := range []int{edge.From, edge.To} {
+			if !seen[malID] && len(malIDs) < maxFranchiseEntries {
+				seen[malID] = true
+				malIDs = append(malIDs, malID)
+				next = append(next, malID)
+			}
+		}
+		if seen[edge.From] && seen[edge.To] && !seenEdges[edge] {
+			seenEdges[edge] = true
+			edges = append(edges, edge)
+		}
+	}
+
+	frontier := []int{root}
+	for len(frontier) > 0 {
+		next = nil
+
+		relationEdges, err := repositories.GetRelationEdges(frontier)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, edge := range relationEdges {
+			if franchiseRelations[edge.Relation] {
+				addEdge(edge)
+			}
+		}
+
+		ids := make([]string, len(frontier))
+		for i, malID := range frontier {
+			ids[i] = strconv.Itoa(malID)
+		}
+		mappings, err := repositories.ResolveMappings(enums.MAL, ids)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, id := range ids {
+			for _, mapping := range mappings[id] {
+				addSeriesEdges(mapping, addEdge)
+			}
+		}
+
+		frontier = next
+	}
+
+	return malIDs, edges, nil
+}
+
+// addSeriesEdges links an anime to the others filed under its TVDB or TMDB
+// series. These links have no direction, so the lower MAL ID always comes
+// first.
+func addSeriesEdges(mapping entities.Mapping, addEdge func(types.FranchiseEdge)) {
+	link := func(other int, via enums.MappingType) {
+		addEdge(types.FranchiseEdge{From: min(mapping.MAL, other), To: max(mapping.MAL, other), Via: via})
+	}
+
+	if mapping.TVDB > 0 {
+		if related, err := repositories.GetRelatedAnimeByTVDB(mapping.TVDB, mapping.MAL); err == nil {
+			for _, relatedMapping := range related {
+				link(relatedMapping.MAL, enums.TVDB)
+			}
+		}
+	}
+
+	if mapping.TMDB > 0 {
+		if related, err := repositories.GetRelatedAnimeByTMDB(mapping.TMDB, mapping.MAL); err == nil {
+			for _, relatedMapping := range related {
+				link(relatedMapping.MAL, enums.TMDB)
+			}
+		}
+	}
+}
+
+// franchiseEntries describes each anime from the database, or from a
+// relation pointing at it when it has not been synced.
+func franchiseEntries(malIDs []int) ([]types.FranchiseEntry, error) {
+	anime, err := repositories.GetAnimeByMALIDs(malIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	byMALID := make(map[int]types.FranchiseEntry, len(malIDs))
+	var unsynced []int
+	for _, series := range anime {
+		byMALID[series.MALID] = types.FranchiseEntry{
+			MALID:        series.MALID,
+			Title:        series.Title.Romaji,
+			TitleEnglish: series.Title.English,
+			Type:         series.Type,
+			Status:       series.Status,
+			Episodes:     series.TotalEpisodes,
+			Season:       series.Season,
+			Year:         series.Year,
+			AiredFrom:    series.Aired.From,
+			ImageURL:     series.Images.Original,
+			Synced:       true,
+		}
+	}
+	for _, malID := range malIDs {
+		if _, ok := byMALID[malID]; !ok {
+			unsynced = append(unsynced, malID)
+		}
+	}
+
+	relations, err := repositories.GetRelationsTo(unsynced)
+	if err != nil {
+		return nil, err
+	}
+	for _, relation := range relations {
+		if _, ok := byMALID[relation.MALID]; ok {
+			continue
+		}
+		byMALID[relation.MALID] = types.FranchiseEntry{
+			MALID:    relation.MALID,
+			Title:    relation.Title,
+			Type:     relation.Format,
+			ImageURL: relation.Image.Original,
+		}
+	}
+
+	entries := make([]types.FranchiseEntry, len(malIDs))
+	for i, malID := range malIDs {
+		entry, ok := byMALID[malID]
+		if !ok {
+			entry = types.FranchiseEntry{MALID: malID}
+		}
+		entries[i] = entry
+	}
+	return entries, nil
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"metachan/entities"
+	"metachan/enums"
+	"metachan/repositories"
+	"metachan/types"
+	"metachan/utils/api/jikan"
+	"metachan/utils/logger"
+)
+
+const jikanMaxPageLimit = 25
+
+// GetAnimeByGenre lists anime from the local join tables, falling back to a
+// Jikan search while none of the genre's anime have been synced yet.
+func GetAnimeByGenre(ctx context.Context, genreID int, sort enums.AnimeSort, page, limit int) (*types.PaginatedResponse[entities.Anime], error) {
+	anime, total, err := repositories.GetAnimeByGenre(genreID, sort, page, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if total > 0 {
+		return &types.PaginatedResponse[entities.Anime]{
+			Pagination: types.NewPagination(page, limit, total),
+			Data:       anime,
+		}, nil
+	}
+
+	logger.Infof("GenreService", "No synced anime for genre %d, falling back to Jikan", genreID)
+
+	orderBy, direction := jikanOrder(sort)
+	limit = min(limit, jikanMaxPageLimit)
+
+	response, err := jikan.GetAnimeByGenre(ctx, genreID, page, limit, orderBy, direction)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.PaginatedResponse[entities.Anime]{
+		Pagination: types.NewPagination(page, limit, int64(response.Pagination.Items.Total)),
+		Data:       animeFromJikan(response.Data),
+	}, nil
+}
+
+func jikanOrder(sort enums.AnimeSort) (string, string) {
+	switch sort {
+	case enums.SortByPopularity:
+		return "popularity", "asc"
+	case enums.SortByYear:
+		return "start_date", "desc"
+	default:
+		return "score", "desc"
+	}
+}
+
+// animeFromJikan builds unsaved anime entities from Jikan list results. They
+// carry no mapping or episodes and are only meant for listings.
+func animeFromJikan(data []types.JikanSingleAnime) []entities.Anime {
+	anime := make([]entities.Anime, 0, len(data))
+	for i := range data {
+		entry := entities.Anime{MALID: data[i].MALID}
+		applyJikanDetails(&entry, &data[i])
+		anime = append(anime, entry)
+	}
+	return anime
+}
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"fmt"
+	"metachan/entities"
+	"metachan/repositories"
+	"metachan/types"
+	"metachan/utils/ical"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultBroadcastTimezone = "Asia/Tokyo"
+	defaultEpisodeDuration   = 24 * time.Minute
+)
+
+var (
+	weekdays = []time.Weekday{
+		time.Monday, time.Tuesday, time.Wednesday, time.Thursday,
+		time.Friday, time.Saturday, time.Sunday,
+	}
+	durationHoursPattern   = regexp.MustCompile(`(\d+)\s*hr`)
+	durationMinutesPattern = regexp.MustCompile(`(\d+)\s*min`)
+)
+
+// GetWeeklySchedule groups everything airing in the next seven days by the
+// weekday it airs on in the given location. Airing anime without an AniList
+// schedule are placed using their MAL broadcast slot and marked as estimated.
+func GetWeeklySchedule(location *time.Location) (*types.WeeklySchedule, error) {
+	now := time.Now()
+
+	entries, err := getScheduleEntries(now, now.Add(7*24*time.Hour), location)
+	if err != nil {
+		return nil, err
+	}
+
+	scheduled := make(map[int]bool, len(entries))
+	for _, entry := range entries {
+		scheduled[entry.MALID] = true
+	}
+
+	airing, err := repositories.GetAiringAnime()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range airing {
+		if scheduled[airing[i].MALID] {
+			continue
+		}
+		airsAt, ok := nextBroadcast(airing[i].Broadcast, now)
+		if !ok {
+			continue
+		}
+		entry := newScheduleEntry(&airing[i], 0, airsAt, location)
+		entry.Estimated = true
+		entries = append(entries, entry)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].AiringAt < entries[j].AiringAt
+	})
+
+	byDay := make(map[time.Weekday][]types.ScheduleEntry, len(weekdays))
+	for _, entry := range entries {
+		day := time.Unix(entry.AiringAt, 0).In(location).Weekday()
+		byDay[day] = append(byDay[day], entry)
+	}
+
+	schedule := &types.WeeklySchedule{
+		Timezone: location.String(),
+		Days:     make([]types.ScheduleDay, 0, len(weekdays)),
+	}
+	for _, day := range weekdays {
+		anime := byDay[day]
+		if anime == nil {
+			anime = []types.ScheduleEntry{}
+		}
+		schedule.Days = append(schedule.Days, types.ScheduleDay{
+			Day:   strings.ToLower(day.String()),
+			Anime: anime,
+		})
+	}
+
+	return schedule, nil
+}
+
+func GetUpcomingSchedule(hours int, location *time.Location) (*types.UpcomingSchedule, error) {
+	now := time.Now()
+
+	entries, err := getScheduleEntries(now, now.Add(time.Duration(hours)*time.Hour), location)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.UpcomingSchedule{
+		Timezone: location.String(),
+		Hours:    hours,
+		Episodes: entries,
+	}, nil
+}
+
+// BuildScheduleCalendar turns the airing schedules of the given anime into an
+// iCalendar feed with one event per episode.
+func BuildScheduleCalendar(name string, anime []entities.Anime) ical.Calendar {
+	calendar := ical.Calendar{Name: name}
+
+	for i := range anime {
+		series := &anime[i]
+		title := series.Title.English
+		if title == "" {
+			title = series.Title.Romaji
+		}
+		duration := parseEpisodeDuration(series.Duration)
+
+		schedule := series.Schedule
+		if len(schedule) == 0 && series.NextAiringAt > 0 {
+			schedule = []entities.EpisodeSchedule{{AiringAt: series.NextAiringAt, Episode: series.NextAiringEpisode}}
+		}
+
+		seen := make(map[int]bool, len(schedule))
+		for _, entry := range schedule {
+			if entry.AiringAt == 0 || seen[entry.Episode] {
+				continue
+			}
+			seen[entry.Episode] = true
+
+			calendar.Events = append(calendar.Events, ical.Event{
+				UID:         fmt.Sprintf("anime-%d-episode-%d@metachan", series.MALID, entry.Episode),
+				Summary:     fmt.Sprintf("%s - Episode %d", title, entry.Episode),
+				Description: series.Broadcast.String,
+				URL:         fmt.Sprintf("https://myanimelist.net/anime/%d", series.MALID),
+				Start:       time.Unix(int64(entry.AiringAt), 0),
+				Duration:    duration,
+			})
+		}
+	}
+
+	sort.SliceStable(calendar.Events, func(i, j int) bool {
+		return calendar.Events[i].Start.Before(calendar.Events[j].Start)
+	})
+
+	return calendar
+}
+
+func getScheduleEntries(from, to time.Time, location *time.Location) ([]types.ScheduleEntry, error) {
+	schedule, err := repositories.GetScheduleBetween(from.Unix(), to.Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	type episodeKey struct {
+		animeID uint
+		episode int
+	}
+	seen := make(map[episodeKey]bool, len(schedule))
+	animeIDs := make([]uint, 0, len(schedule))
+	unique := make([]entities.EpisodeSchedule, 0, len(schedule))
+	for _, entry := range schedule {
+		key := episodeKey{entry.AnimeID, entry.Episode}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, entry)
+		animeIDs = append(animeIDs, entry.AnimeID)
+	}
+
+	anime, err := repositories.GetAnimeByIDs(animeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	animeByID := make(map[uint]*entities.Anime, len(anime))
+	for i := range anime {
+		animeByID[anime[i].ID] = &anime[i]
+	}
+
+	entries := make([]types.ScheduleEntry, 0, len(unique))
+	for _, entry := range unique {
+		series, exists := animeByID[entry.AnimeID]
+		if !exists {
+			continue
+		}
+		entries = append(entries, newScheduleEntry(series, entry.Episode, time.Unix(int64(entry.AiringAt), 0), location))
+	}
+
+	return entries, nil
+}
+
+func newScheduleEntry(anime *entities.Anime, episode int, airsAt time.Time, location *time.Location) types.ScheduleEntry {
+	return types.ScheduleEntry{
+		MALID:    anime.MALID,
+		Titles:   anime.Title,
+		Images:   anime.Images,
+		Episode:  episode,
+		AiringAt: airsAt.Unix(),
+		AirsAt:   airsAt.In(location).Format(time.RFC3339),
+	}
+}
+
+// nextBroadcast finds the next occurrence of a MAL broadcast slot such as
+// "Saturdays at 23:00 (JST)" after now.
+func nextBroadcast(broadcast entities.AnimeBroadcast, now time.Time) (time.Time, bool) {
+	day, ok := parseWeekday(broadcast.Day)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	clock, err := time.Parse("15:04", broadcast.Time)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	timezone := broadcast.Timezone
+	if timezone == "" {
+		timezone = defaultBroadcastTimezone
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	local := now.In(location)
+	next := time.Date(local.Year(), local.Month(), local.Day(), clock.Hour(), clock.Minute(), 0, 0, location)
+	next = next.AddDate(0, 0, (int(day)-int(local.Weekday())+7)%7)
+	if next.Before(now) {
+		next = next.AddDate(0, 0, 7)
+	}
+
+	return next, true
+}
+
+func parseWeekday(day string) (time.Weekday, bool) {
+	day = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(day)), "s")
+	for _, weekday := range weekdays {
+		if strings.ToLower(weekday.String()) == day {
+			return weekday, true
+		}
+	}
+	return 0, false
+}
+
+// parseEpisodeDuration reads MAL durations such as "24 min per ep" or
+// "1 hr 55 min".
+func parseEpisodeDuration(duration string) time.Duration {
+	var total time.Duration
+
+	if matches := durationHoursPattern.FindStringSubmatch(duration); len(matches) > 1 {
+		hours, _ := strconv.Atoi(matches[1])
+		total += time.Duration(hours) * time.Hour
+	}
+	if matches := durationMinutesPattern.FindStringSubmatch(duration); len(matches) > 1 {
+		minutes, _ := strconv.Atoi(matches[1])
+		total += time.Duration(minutes) * time.Minute
+	}
+
+	if total == 0 {
+		return defaultEpisodeDuration
+	}
+	return total
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"metachan/entities"
+	"metachan/enums"
+	"metachan/repositories"
+	"metachan/types"
+	"metachan/utils/api/jikan"
+	"metachan/utils/logger"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	seasonFillTTL      = 6 * time.Hour
+	maxSeasonFillPages = 20
+	// maxSeasonFills bounds the seasons kept filled at once. A fill can hold
+	// hundreds of anime, and old seasons are rarely viewed twice in a row.
+	maxSeasonFills = 16
+)
+
+type seasonFill struct {
+	anime     []entities.Anime
+	fetchedAt time.Time
+}
+
+var (
+	seasonFillCache = make(map[string]seasonFill)
+	seasonFillMutex sync.Mutex
+	seasonOrder     = []enums.AnimeSeason{enums.Winter, enums.Spring, enums.Summer, enums.Fall}
+)
+
+// CurrentSeason returns the anime season that contains t.
+func CurrentSeason(t time.Time) (int, enums.AnimeSeason) {
+	return t.Year(), seasonOrder[(int(t.Month())-1)/3]
+}
+
+// NextSeason returns the season that follows the given one.
+func NextSeason(year int, season enums.AnimeSeason) (int, enums.AnimeSeason) {
+	for i, s := range seasonOrder {
+		if s == season {
+			if i == len(seasonOrder)-1 {
+				return year + 1, seasonOrder[0]
+			}
+			return year, seasonOrder[i+1]
+		}
+	}
+	return year, season
+}
+
+func seasonStart(year int, season enums.AnimeSeason) time.Time {
+	for i, s := range seasonOrder {
+		if s == season {
+			return time.Date(year, time.Month(i*3+1), 1, 0, 0, 0, 0, time.UTC)
+		}
+	}
+	return time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// GetSeasonChart lists a season from the database. If Jikan knows of more
+// premieres than have been synced, the missing ones are filled in from its
+// season listing as unsaved entries.
+func GetSeasonChart(ctx context.Context, year int, season enums.AnimeSeason, options types.SeasonChartOptions) (*types.SeasonChart, error) {
+	start := seasonStart(year, season)
+
+	anime, err := repositories.GetSeasonAnime(year, season, start, options.IncludeContinuing)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[int]bool, len(anime))
+	premieres := 0
+	for i := range anime {
+		known[anime[i].MALID] = true
+		if strings.EqualFold(anime[i].Season, string(season)) && anime[i].Year == year {
+			premieres++
+		}
+	}
+
+	for _, entry := range getSeasonFill(ctx, year, season, premieres) {
+		if !known[entry.MALID] {
+			known[entry.MALID] = true
+			anime = append(anime, entry)
+		}
+	}
+
+	if options.Type != "" {
+		filtered := anime[:0]
+		for _, entry := range anime {
+			if strings.EqualFold(entry.Type, options.Type) {
+				filtered = append(filtered, entry)
+			}
+		}
+		anime = filtered
+	}
+
+	sortSeasonChart(anime, options.Sort)
+
+	total := len(anime)
+	from := min((options.Page-1)*options.Limit, total)
+	to := min(from+options.Limit, total)
+
+	return &types.SeasonChart{
+		Year:   year,
+		Season: string(season),
+		PaginatedResponse: types.PaginatedResponse[entities.Anime]{
+			Pagination: types.NewPagination(options.Page, options.Limit, int64(total)),
+			Data:       anime[from:to],
+		},
+	}, nil
+}
+
+func sortSeasonChart(anime []entities.Anime, order enums.AnimeSort) {
+	sort.SliceStable(anime, func(i, j int) bool {
+		a, b := &anime[i], &anime[j]
+		switch order {
+		case enums.SortByScore:
+			if a.Scores.Score != b.Scores.Score {
+				return a.Scores.Score > b.Scores.Score
+			}
+		case enums.SortByStartDate:
+			if a.Aired.From == nil || b.Aired.From == nil {
+				if (a.Aired.From == nil) != (b.Aired.From == nil) {
+					return b.Aired.From == nil
+				}
+			} else if !a.Aired.From.Equal(*b.Aired.From) {
+				return a.Aired.From.Before(*b.Aired.From)
+			}
+		default:
+			if a.Scores.Members != b.Scores.Members {
+				return a.Scores.Members > b.Scores.Members
+			}
+		}
+		return a.MALID < b.MALID
+	})
+}
+
+// getSeasonFill returns Jikan's premieres for a season when the database has
+// fewer than Jikan reports. Results are cached so that a chart page view costs
+// at most one Jikan request once the season is complete locally.
+func getSeasonFill(ctx context.Context, year int, season enums.AnimeSeason, localPremieres int) []entities.Anime {
+	key := fmt.Sprintf("%d-%s", year, season)
+
+	seasonFillMutex.Lock()
+	cached, exists := seasonFillCache[key]
+	seasonFillMutex.Unlock()
+	if exists && time.Since(cached.fetchedAt) < seasonFillTTL {
+		return cached.anime
+	}
+
+	firstPage, err := jikan.GetSeasonAnime(ctx, year, string(season), 1)
+	if err != nil {
+		logger.Warnf("SeasonService", "Failed to fetch %s %d from Jikan: %v", season, year, err)
+		return nil
+	}
+
+	var fill []entities.Anime
+	if localPremieres < firstPage.Pagination.Items.Total {
+		logger.Infof("SeasonService", "Filling %s %d from Jikan (%d local, %d on MAL)", season, year, localPremieres, firstPage.Pagination.Items.Total)
+
+		data := firstPage.Data
+		lastPage := min(firstPage.Pagination.LastVisiblePage, maxSeasonFillPages)
+		for page := 2; page <= lastPage; page++ {
+			response, err := jikan.GetSeasonAnime(ctx, year, string(season), page)
+			if err != nil {
+				logger.Warnf("SeasonService", "Failed to fetch page %d of %s %d from Jikan: %v", page, season, year, err)
+				break
+			}
+			data = append(data, response.Data...)
+		}
+		fill = animeFromJikan(data)
+	}
+
+	// Don't cache a fill cut short by the client going away.
+	if ctx.Err() != nil {
+		return fill
+	}
+
+	storeSeasonFill(key, fill)
+	return fill
+}
+
+// storeSeasonFill caches a fill, dropping expired ones and, past
+// maxSeasonFills, the one fetched longest ago.
+func storeSeasonFill(key string, fill []entities.Anime) {
+	now := time.Now()
+
+	seasonFillMutex.Lock()
+	defer seasonFillMutex.Unlock()
+
+	oldestKey := ""
+	for cachedKey, cached := range seasonFillCache {
+		if now.Sub(cached.fetchedAt) >= seasonFillTTL {
+			delete(seasonFillCache, cachedKey)
+		} else if oldestKey == "" || cached.fetchedAt.Before(seasonFillCache[oldestKey].fetchedAt) {
+			oldestKey = cachedKey
+		}
+	}
+	if _, exists := seasonFillCache[key]; !exists && len(seasonFillCache) >= maxSeasonFills {
+		delete(seasonFillCache, oldestKey)
+	}
+
+	seasonFillCache[key] = seasonFill{anime: fill, fetchedAt: now}
+}
@@ -1,8 +1,16 @@
 package services
 
+import "time"
+
 type seasonInfo struct {
 	malID       int
 	year        int
 	seasonOrder int
 	isCurrent   bool
 }
+
+// partialRetry is when a partial anime may next retry its missing upstreams.
+type partialRetry struct {
+	failures int
+	next     time.Time
+}
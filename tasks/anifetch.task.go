@@ -1,27 +1,37 @@
 package tasks
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"metachan/config"
 	"metachan/entities"
 	"metachan/enums"
 	"metachan/repositories"
 	"metachan/types"
 	"metachan/utils/logger"
 	"metachan/utils/mappers"
+	"metachan/utils/upstream"
 	"net/http"
 )
 
 const (
-	fribbURL  = "https://raw.githubusercontent.com/Fribb/anime-lists/master/anime-list-full.json"
 	batchSize = 1000
 )
 
-func AniFetch() error {
+var mappingsClient = &http.Client{Transport: upstream.Transport(nil)}
+
+func AniFetch(ctx context.Context) error {
 	logger.Infof("AniFetch", "Starting Anime Fetch")
 
-	response, err := http.Get(fribbURL)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, config.Upstream.MappingsURL, nil)
+	if err != nil {
+		logger.Errorf("AniFetch", "Failed to create request: %v", err)
+		return err
+	}
+
+	response, err := mappingsClient.Do(request)
 	if err != nil {
 		logger.Errorf("AniFetch", "Anime Fetch failed: %v", err)
 		return err
@@ -42,15 +52,23 @@ func AniFetch() error {
 	}
 
 	total := len(mappings)
+	tracker := progressFrom(ctx)
+	tracker.SetTotal(total)
 
 	for i := 0; i < total; i += batchSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		end := i + batchSize
 		if end > total {
 			end = total
 		}
 
 		batch := mappings[i:end]
+		tracker.ItemStarted(fmt.Sprintf("mappings %d-%d", i+1, end))
 		processBatch(batch)
+		tracker.Advance(len(batch))
 		logger.Infof("AniFetch", "Processed %d/%d mappings", end, total)
 	}
 
@@ -1,7 +1,7 @@
 package tasks
 
 import (
-	"metachan/entities"
+	"context"
 	"metachan/enums"
 	"metachan/repositories"
 	"metachan/services"
@@ -9,7 +9,7 @@ import (
 	"time"
 )
 
-func AniSync() error {
+func AniSync(ctx context.Context) error {
 	logger.Infof("AniSync", "Starting Anime Sync - Fetching full anime details")
 
 	mappings, err := repositories.GetAllMappings()
@@ -18,107 +18,99 @@ func AniSync() error {
 		return err
 	}
 
+	stubs, err := repositories.GetAllAnimeStubs()
+	if err != nil {
+		logger.Errorf("AniSync", "Failed to fetch anime stubs: %v", err)
+		return err
+	}
+
 	total := len(mappings)
 	logger.Infof("AniSync", "Found %d anime mappings", total)
 
-	itemsToSync := 0
-	for _, mapping := range mappings {
-		if mapping.MAL == 0 {
-			continue
-		}
-		_, err := repositories.GetAnime(enums.MAL, mapping.MAL)
-		if err == nil {
-			continue
-		}
-		itemsToSync++
+	synced := make(map[int]bool, len(stubs))
+	for _, s := range stubs {
+		synced[s.MALID] = true
 	}
 
-	logger.Infof("AniSync", "Found %d anime to sync (%d already synced)", itemsToSync, total-itemsToSync)
-
-	synced := 0
-	skipped := 0
-	startTime := time.Now()
-	processed := 0
-
+	var missing []int
 	for _, mapping := range mappings {
-		if mapping.MAL == 0 {
-			skipped++
-			continue
+		if mapping.MAL != 0 && !synced[mapping.MAL] {
+			missing = append(missing, mapping.MAL)
 		}
+	}
 
-		_, err := repositories.GetAnime(enums.MAL, mapping.MAL)
-		if err == nil {
-			skipped++
-			continue
-		}
-
-		progress, eta := calculateProgress(processed+1, itemsToSync, startTime)
-
-		logger.Infof("AniSync", "[%d/%d] Synchronising MAL ID %d - %.1f%% | ETA: %v", processed+1, itemsToSync, mapping.MAL, progress, eta)
+	logger.Infof("AniSync", "Found %d anime to sync (%d already synced)", len(missing), total-len(missing))
 
-		_, err = services.GetAnime(&mapping)
-		if err != nil {
-			logger.Warnf("AniSync", "Failed to sync anime MAL ID %d: %v", mapping.MAL, err)
-			continue
-		}
+	if err := repositories.EnqueueJobs(enums.JobAnime, missing); err != nil {
+		return err
+	}
 
-		synced++
-		processed++
+	processed, failed, err := drainJobs(ctx, "AniSync", enums.JobAnime, syncAnimeJob)
+	if err != nil {
+		return err
 	}
 
+	logger.Successf("AniSync", "Anime Sync completed. Synced %d anime (%d failed)", processed-failed, failed)
 	return nil
 }
 
 // ResumeAnimeSync is called on startup to resume any interrupted sync and refresh stale entries.
-func ResumeAnimeSync() {
-	go func() {
-		mappings, err := repositories.GetAllMappings()
-		if err != nil {
-			logger.Errorf("AniSync", "Resume: failed to fetch mappings: %v", err)
-			return
-		}
+func ResumeAnimeSync(ctx context.Context) {
+	mappings, err := repositories.GetAllMappings()
+	if err != nil {
+		logger.Errorf("AniSync", "Resume: failed to fetch mappings: %v", err)
+		return
+	}
 
-		stubs, err := repositories.GetAllAnimeStubs()
-		if err != nil {
-			logger.Errorf("AniSync", "Resume: failed to fetch anime stubs: %v", err)
-			return
-		}
+	stubs, err := repositories.GetAllAnimeStubs()
+	if err != nil {
+		logger.Errorf("AniSync", "Resume: failed to fetch anime stubs: %v", err)
+		return
+	}
 
-		sevenDaysAgo := time.Now().Add(-7 * 24 * time.Hour)
-		updatedAt := make(map[int]time.Time, len(stubs))
-		enrichedAt := make(map[int]*time.Time, len(stubs))
-		for _, s := range stubs {
-			updatedAt[s.MALID] = s.UpdatedAt
-			enrichedAt[s.MALID] = s.EnrichedAt
-		}
+	stale := make(map[int]bool, len(stubs))
+	for _, s := range stubs {
+		stale[s.MALID] = s.EnrichedAt == nil || time.Since(s.UpdatedAt) > services.AnimeTTL(s.Airing, s.Status)
+	}
 
-		var toProcess []entities.Mapping
-		for _, m := range mappings {
-			if m.MAL == 0 {
-				continue
-			}
-			t, exists := updatedAt[m.MAL]
-			if !exists || t.Before(sevenDaysAgo) || enrichedAt[m.MAL] == nil {
-				toProcess = append(toProcess, m)
-			}
+	var toProcess []int
+	for _, m := range mappings {
+		if m.MAL == 0 {
+			continue
 		}
-
-		if len(toProcess) == 0 {
-			return
+		if isStale, exists := stale[m.MAL]; !exists || isStale {
+			toProcess = append(toProcess, m.MAL)
 		}
+	}
 
+	if len(toProcess) > 0 {
 		logger.Infof("AniSync", "Resume: %d anime to sync (missing or stale)", len(toProcess))
-		startTime := time.Now()
+	}
 
-		for i, m := range toProcess {
-			progress, eta := calculateProgress(i+1, len(toProcess), startTime)
-			logger.Infof("AniSync", "[Resume %d/%d] MAL ID %d - %.1f%% | ETA: %v", i+1, len(toProcess), m.MAL, progress, eta)
+	if err := repositories.EnqueueJobs(enums.JobAnime, toProcess); err != nil {
+		return
+	}
 
-			if _, err := services.GetAnime(&m); err != nil {
-				logger.Warnf("AniSync", "Resume: failed to sync MAL ID %d: %v", m.MAL, err)
-			}
-		}
+	processed, failed, err := drainJobs(ctx, "AniSync", enums.JobAnime, syncAnimeJob)
+	if err != nil {
+		logger.Infof("AniSync", "Resume: stopped after %d anime: %v", processed, err)
+		return
+	}
+
+	if processed > 0 {
+		logger.Successf("AniSync", "Resume complete: synced %d anime (%d failed)", processed-failed, failed)
+	}
+}
+
+func syncAnimeJob(ctx context.Context, malID int) error {
+	mapping, err := repositories.GetAnimeMapping(enums.MAL, malID)
+	if err != nil {
+		return err
+	}
 
-		logger.Successf("AniSync", "Resume complete: synced %d anime", len(toProcess))
-	}()
+	// A partial anime is saved without failing the job. It stays unenriched,
+	// so the anime service retries the missing sources with backoff and the
+	// next pass picks it up again.
+	_, err = services.ForceRefreshAnime(ctx, &mapping)
+	return err
 }
@@ -1,6 +1,7 @@
 package tasks
 
 import (
+	"context"
 	"fmt"
 	"metachan/config"
 	"metachan/entities"
@@ -27,7 +28,7 @@ type animeUpdateJob struct {
 	reason string
 }
 
-func AnimeUpdate() error {
+func AnimeUpdate(ctx context.Context) error {
 	logger.Infof("AnimeUpdate", "Starting Anime Update Task")
 
 	airingSeries, err := repositories.GetAiringAnime()
@@ -50,6 +51,8 @@ func AnimeUpdate() error {
 		logger.Debugf("AnimeUpdate", "Using reduced concurrency (%d workers) for SQLite database", maxWorkers)
 	}
 
+	tracker := progressFrom(ctx)
+
 	var wg sync.WaitGroup
 
 	for i := 0; i < maxWorkers; i++ {
@@ -60,13 +63,21 @@ func AnimeUpdate() error {
 			logger.Debugf("AnimeUpdate", "Started worker #%d", workerID+1)
 
 			for job := range jobs {
-				updateAnime(job.series, job.reason)
+				if ctx.Err() != nil {
+					continue
+				}
+				tracker.ItemStarted(fmt.Sprintf("MAL ID %d", job.series.MALID))
+				tracker.ItemDone(updateAnime(ctx, job.series, job.reason))
 			}
 		}(i)
 	}
 
 	jobsQueued := 0
 	for _, series := range airingSeries {
+		if ctx.Err() != nil {
+			break
+		}
+
 		needsUpdate := false
 		reason := ""
 
@@ -108,15 +119,20 @@ func AnimeUpdate() error {
 	}
 
 	close(jobs)
+	tracker.SetTotal(jobsQueued)
 
 	wg.Wait()
 
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	logger.Successf("AnimeUpdate", "Anime Update Task Completed - Processed %d anime", jobsQueued)
 
 	return nil
 }
 
-func updateAnime(series entities.Anime, reason string) {
+func updateAnime(ctx context.Context, series entities.Anime, reason string) error {
 	title := series.Title.English
 	if title == "" {
 		title = series.Title.Romaji
@@ -127,13 +143,13 @@ func updateAnime(series entities.Anime, reason string) {
 	mapping, err := repositories.GetAnimeMapping(enums.MAL, series.MALID)
 	if err != nil {
 		logger.Errorf("AnimeUpdate", "Error getting anime mapping for %s (MAL ID: %d): %v", title, series.MALID, err)
-		return
+		return err
 	}
 
-	updatedAnime, err := services.ForceRefreshAnime(&mapping)
+	updatedAnime, err := services.ForceRefreshAnime(ctx, &mapping)
 	if err != nil {
 		logger.Errorf("AnimeUpdate", "Error getting updated anime data for %s (MAL ID: %d): %v", title, series.MALID, err)
-		return
+		return err
 	}
 
 	logger.Successf("AnimeUpdate", "Successfully updated anime: %s (MAL ID: %d)", title, series.MALID)
@@ -145,16 +161,19 @@ func updateAnime(series entities.Anime, reason string) {
 
 		if err := repositories.CreateOrUpdateAnime(updatedAnime); err != nil {
 			logger.Errorf("AnimeUpdate", "Error saving updated anime data for %s (MAL ID: %d): %v", title, series.MALID, err)
-		} else {
-			logger.Infof("AnimeUpdate", "Successfully saved updated data for %s (MAL ID: %d)", title, series.MALID)
+			return err
+		}
 
-			if !updatedAnime.Airing {
-				logger.Infof("AnimeUpdate", "Anime %s (MAL ID: %d) is no longer airing. Status: %s", title, series.MALID, updatedAnime.Status)
-			}
+		logger.Infof("AnimeUpdate", "Successfully saved updated data for %s (MAL ID: %d)", title, series.MALID)
+
+		if !updatedAnime.Airing {
+			logger.Infof("AnimeUpdate", "Anime %s (MAL ID: %d) is no longer airing. Status: %s", title, series.MALID, updatedAnime.Status)
 		}
 	} else {
 		logger.Debugf("AnimeUpdate", "No significant changes detected for %s (MAL ID: %d), skipping database update", title, series.MALID)
 	}
+
+	return nil
 }
 
 func shouldSaveUpdate(oldAnime *entities.Anime, newAnime *entities.Anime) bool {
@@ -1,19 +1,23 @@
 package tasks
 
 import (
+	"context"
 	"fmt"
 	"metachan/entities"
+	"metachan/enums"
 	"metachan/repositories"
 	"metachan/utils/api/jikan"
 	"metachan/utils/logger"
 	"time"
 )
 
-func ResumeCharacterEnrichment() {
-	go CharacterSync()
+func ResumeCharacterEnrichment(ctx context.Context) {
+	if err := CharacterSync(ctx); err != nil {
+		logger.Warnf("CharacterSync", "Resume failed: %v", err)
+	}
 }
 
-func CharacterSync() error {
+func CharacterSync(ctx context.Context) error {
 	stubs, err := repositories.GetAllCharacterStubs()
 	if err != nil {
 		return fmt.Errorf("failed to load character stubs: %w", err)
@@ -21,72 +25,65 @@ func CharacterSync() error {
 
 	sevenDaysAgo := time.Now().Add(-7 * 24 * time.Hour)
 
-	hasWork := false
+	var pending []int
 	for _, s := range stubs {
 		if s.EnrichedAt == nil || !s.EnrichedAt.After(sevenDaysAgo) {
-			hasWork = true
-			break
+			pending = append(pending, s.MALID)
 		}
 	}
-	if !hasWork {
-		return nil
-	}
 
-	total := len(stubs)
-	startTime := time.Now()
-	enriched := 0
+	if err := repositories.EnqueueJobs(enums.JobCharacter, pending); err != nil {
+		return err
+	}
 
-	for i, s := range stubs {
-		if s.EnrichedAt != nil && s.EnrichedAt.After(sevenDaysAgo) {
-			continue
-		}
+	processed, failed, err := drainJobs(ctx, "CharacterSync", enums.JobCharacter, enrichCharacter)
+	if err != nil {
+		return err
+	}
 
-		resp, err := jikan.GetCharacterByMALID(s.MALID)
-		if err != nil {
-			logger.Warnf("CharacterSync", "Failed to fetch character %d: %v", s.MALID, err)
-			continue
-		}
+	if processed > 0 {
+		logger.Successf("CharacterSync", "Background enrichment complete. Enriched %d characters (%d failed)", processed-failed, failed)
+	}
+	return nil
+}
 
-		d := resp.Data
+func enrichCharacter(ctx context.Context, malID int) error {
+	resp, err := jikan.GetCharacterByMALID(ctx, malID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch character: %w", err)
+	}
 
-		var voiceActors []entities.CharacterVoiceActor
-		for _, v := range d.Voices {
-			voiceActors = append(voiceActors, entities.CharacterVoiceActor{
-				Language: v.Language,
-				Person:   &entities.Person{},
-			})
-		}
+	d := resp.Data
 
-		var animeAppearances []entities.CharacterAnimeAppearance
-		for _, a := range d.Anime {
-			animeAppearances = append(animeAppearances, entities.CharacterAnimeAppearance{
-				AnimeMALID: a.Anime.MALID,
-				Title:      a.Anime.Title,
-				URL:        a.Anime.URL,
-				ImageURL:   a.Anime.Images.JPG.ImageURL,
-				Role:       a.Role,
-			})
-		}
+	var voiceActors []entities.CharacterVoiceActor
+	for _, v := range d.Voices {
+		voiceActors = append(voiceActors, entities.CharacterVoiceActor{
+			Language: v.Language,
+			Person:   &entities.Person{},
+		})
+	}
 
-		if err := repositories.UpdateCharacterDetails(
-			d.MALID, d.Name, d.NameKanji, d.URL, d.Images.JPG.ImageURL,
-			d.About, d.Nicknames, d.Favorites, voiceActors, animeAppearances,
-		); err != nil {
-			logger.Warnf("CharacterSync", "Failed to update character %d: %v", s.MALID, err)
-			continue
-		}
+	var animeAppearances []entities.CharacterAnimeAppearance
+	for _, a := range d.Anime {
+		animeAppearances = append(animeAppearances, entities.CharacterAnimeAppearance{
+			AnimeMALID: a.Anime.MALID,
+			Title:      a.Anime.Title,
+			URL:        a.Anime.URL,
+			ImageURL:   a.Anime.Images.JPG.ImageURL,
+			Role:       a.Role,
+		})
+	}
 
-		if err := repositories.SetCharacterEnriched(d.MALID); err != nil {
-			logger.Warnf("CharacterSync", "Failed to stamp enriched_at for character %d: %v", d.MALID, err)
-		}
+	if err := repositories.UpdateCharacterDetails(
+		d.MALID, d.Name, d.NameKanji, d.URL, d.Images.JPG.ImageURL,
+		d.About, d.Nicknames, d.Favorites, voiceActors, animeAppearances,
+	); err != nil {
+		return fmt.Errorf("failed to update character: %w", err)
+	}
 
-		enriched++
-		if (i+1)%50 == 0 || (i+1) == total {
-			progress, eta := calculateProgress(i+1, total, startTime)
-			logger.Infof("CharacterSync", "Enriching: %d/%d (%.1f%%) | ETA: %v", i+1, total, progress, eta)
-		}
+	if err := repositories.SetCharacterEnriched(d.MALID); err != nil {
+		logger.Warnf("CharacterSync", "Failed to stamp enriched_at for character %d: %v", d.MALID, err)
 	}
 
-	logger.Successf("CharacterSync", "Background enrichment complete. Enriched %d characters", enriched)
 	return nil
 }
@@ -1,36 +1,59 @@
 package tasks
 
 import (
+	"context"
 	"metachan/entities"
+	"metachan/enums"
 	"metachan/repositories"
 	"metachan/utils/api/jikan"
 	"metachan/utils/logger"
 )
 
-func GenreSync() error {
+func GenreSync(ctx context.Context) error {
 	logger.Infof("GenreSync", "Starting Genre Sync from MAL")
 
-	genresResponse, err := jikan.GetAnimeGenres()
-	if err != nil {
-		logger.Errorf("GenreSync", "Failed to fetch genres from MAL: %v", err)
-		return err
+	filters := []struct {
+		filter    string
+		genreType enums.GenreType
+	}{
+		{"genres", enums.GenreTypeGenre},
+		{"explicit_genres", enums.GenreTypeExplicitGenre},
+		{"themes", enums.GenreTypeTheme},
+		{"demographics", enums.GenreTypeDemographic},
 	}
 
-	logger.Infof("GenreSync", "Fetched %d genres from MAL", len(genresResponse.Data))
+	tracker := progressFrom(ctx)
+	tracker.SetTotal(len(filters))
 
-	for _, genre := range genresResponse.Data {
-		genreEntity := entities.Genre{
-			GenreID: genre.MALID,
-			Name:    genre.Name,
-			URL:     genre.URL,
-			Count:   genre.Count,
+	synced := 0
+	for _, filter := range filters {
+		tracker.ItemStarted(filter.filter)
+		genresResponse, err := jikan.GetAnimeGenres(ctx, filter.filter)
+		if err != nil {
+			logger.Errorf("GenreSync", "Failed to fetch %s from MAL: %v", filter.filter, err)
+			return err
 		}
 
-		if err := repositories.CreateOrUpdateGenre(&genreEntity); err != nil {
-			logger.Warnf("GenreSync", "Failed to sync genre %s: %v", genre.Name, err)
+		logger.Infof("GenreSync", "Fetched %d %s from MAL", len(genresResponse.Data), filter.filter)
+
+		for _, genre := range genresResponse.Data {
+			genreEntity := entities.Genre{
+				GenreID: genre.MALID,
+				Name:    genre.Name,
+				Type:    filter.genreType,
+				URL:     genre.URL,
+				Count:   genre.Count,
+			}
+
+			if err := repositories.CreateOrUpdateGenre(&genreEntity); err != nil {
+				logger.Warnf("GenreSync", "Failed to sync genre %s: %v", genre.Name, err)
+				continue
+			}
+			synced++
 		}
+		tracker.ItemDone(nil)
 	}
 
-	logger.Successf("GenreSync", "Genre Sync completed successfully. Synced %d genres", len(genresResponse.Data))
+	logger.Successf("GenreSync", "Genre Sync completed successfully. Synced %d genres", synced)
 	return nil
 }
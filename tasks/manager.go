@@ -1,22 +1,44 @@
 package tasks
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math/rand/v2"
 	"metachan/entities"
+	"metachan/enums"
 	"metachan/repositories"
 	"metachan/types"
+	"metachan/utils/cron"
 	"metachan/utils/logger"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+var (
+	ErrTaskNotFound = errors.New("task not found")
+	ErrTaskRunning  = errors.New("task is already running")
+	ErrShuttingDown = errors.New("task manager is shutting down")
+)
+
 type TaskManager struct {
-	Tasks   map[string]types.Task
-	Tickers map[string]*time.Ticker
-	Done    map[string]chan bool
-	Mutex   sync.Mutex
+	Tasks     map[string]types.Task
+	Schedules map[string]*cron.Schedule
+	Done      map[string]chan bool
+	Runs      map[string]string
+	Mutex     sync.Mutex
+
+	// ctx is cancelled by StopAllTasks and is the parent of every run, so
+	// shutting down interrupts tasks in the middle of their work.
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	progress    map[string]*runProgress
+	subscribers map[chan types.TaskProgress]struct{}
 }
 
 func (tm *TaskManager) RegisterTask(task types.Task) error {
@@ -27,58 +49,95 @@ func (tm *TaskManager) RegisterTask(task types.Task) error {
 		return fmt.Errorf("task %s already registered", task.Name)
 	}
 
+	if task.Schedule != "" {
+		schedule, err := cron.Parse(task.Schedule)
+		if err != nil {
+			return fmt.Errorf("task %s has an invalid schedule: %w", task.Name, err)
+		}
+		tm.Schedules[task.Name] = schedule
+	}
+
 	tm.Tasks[task.Name] = task
 	logger.Infof("TaskManager", "Task %s registered", task.Name)
 
 	return nil
 }
 
-func (tm *TaskManager) shouldExecuteTask(taskName string, interval time.Duration) (bool, error) {
-	lastLog, err := repositories.GetLatestTaskLog(taskName)
+// nextRun returns when a task is due after a run that finished at last. A
+// task that has never run is due immediately, and a task with neither a
+// schedule nor an interval only ever runs once.
+func (tm *TaskManager) nextRun(task types.Task, last time.Time) (time.Time, bool) {
+	tm.Mutex.Lock()
+	schedule := tm.Schedules[task.Name]
+	tm.Mutex.Unlock()
+
+	switch {
+	case last.IsZero():
+		return time.Now(), true
+	case schedule != nil:
+		next := schedule.Next(last)
+		return next, !next.IsZero()
+	case task.Interval > 0:
+		return last.Add(task.Interval), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// lastRunTime returns when the task last finished. An interrupted run does not
+// count, so a task cut short by a shutdown is due again on the next start.
+func (tm *TaskManager) lastRunTime(taskName string) (time.Time, error) {
+	lastLog, err := repositories.GetLatestFinishedTaskLog(taskName)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return true, nil
+			return time.Time{}, nil
 		}
-		return false, err
+		return time.Time{}, err
 	}
 
-	elapsed := time.Since(lastLog.ExecutedAt)
-	return elapsed >= interval, nil
+	return lastLog.ExecutedAt, nil
 }
 
-func (tm *TaskManager) logTaskExecution(taskName, status, message string) {
-	logEntry := entities.TaskLog{
-		TaskName:   taskName,
-		Status:     status,
-		Message:    message,
-		ExecutedAt: time.Now(),
-	}
-
-	if err := repositories.CreateTaskLog(&logEntry); err != nil {
-		logger.Warnf("TaskManager", "Failed to log task execution for %s: %v", taskName, err)
-	}
+func (tm *TaskManager) isPaused(taskName string) bool {
+	status, err := repositories.GetTaskStatus(taskName)
+	return err == nil && status.IsPaused
 }
 
 func (tm *TaskManager) StartTask(taskName string) {
+	tm.scheduleTask(taskName)
+}
+
+// scheduleTask starts the schedule of a task and reports whether the task was
+// due, that is whether its first run starts straight away. A paused or unknown
+// task is not scheduled and reported as not due.
+func (tm *TaskManager) scheduleTask(taskName string) bool {
 	tm.Mutex.Lock()
 	task, exists := tm.Tasks[taskName]
 	tm.Mutex.Unlock()
 	if !exists {
 		logger.Warnf("TaskManager", "Task %s not found", taskName)
-		return
+		return false
 	}
 
 	// Stop existing scheduled execution if any
 	tm.StopTask(taskName)
 
-	shouldExec, err := tm.shouldExecuteTask(taskName, task.Interval)
+	if tm.isPaused(taskName) {
+		logger.Infof("TaskManager", "Task %s is paused, not scheduling it", taskName)
+		return false
+	}
+
+	lastRun, err := tm.lastRunTime(taskName)
 	if err != nil {
 		logger.Errorf("TaskManager", "Error checking execution condition for task %s: %v", taskName, err)
-		return
+		return false
 	}
 
-	if !shouldExec {
-		if lastLog, err := repositories.GetLatestTaskLog(taskName); err == nil && lastLog.Status == "success" {
+	next, scheduled := tm.nextRun(task, lastRun)
+	due := scheduled && !next.After(time.Now())
+
+	if !due {
+		if lastLog, err := repositories.GetLatestFinishedTaskLog(taskName); err == nil && lastLog.Status == enums.TaskRunSuccess {
 			repositories.SetTaskStatus(&entities.TaskStatus{
 				TaskName:    taskName,
 				IsCompleted: true,
@@ -92,97 +151,223 @@ func (tm *TaskManager) StartTask(taskName string) {
 	tm.Done[taskName] = doneChan
 	tm.Mutex.Unlock()
 
-	if task.OnResume != nil {
-		go task.OnResume()
+	go tm.runSchedule(taskName, task, lastRun, doneChan)
+
+	switch {
+	case task.Schedule != "":
+		logger.Infof("TaskManager", "Task %s scheduled with cron expression %q", taskName, task.Schedule)
+	default:
+		logger.Infof("TaskManager", "Task %s scheduled with interval %v", taskName, task.Interval)
 	}
 
-	go func() {
-		if shouldExec {
-			if !tm.checkDependencies(task) {
-				logger.Warnf("TaskManager", "Task %s dependencies not met, skipping execution", taskName)
-			} else if err := task.Execute(); err != nil {
-				tm.logTaskExecution(taskName, "error", err.Error())
-				logger.Errorf("TaskManager", "Task %s execution failed: %v", taskName, err)
-			} else {
-				tm.logTaskExecution(taskName, "success", "Task executed successfully")
-				repositories.SetTaskStatus(&entities.TaskStatus{
-					TaskName:    taskName,
-					IsCompleted: true,
-					LastRunAt:   time.Now(),
-				})
-				logger.Successf("TaskManager", "Task %s executed successfully", taskName)
-				tm.triggerDependentTasks(taskName)
-			}
-		} else {
-			var initialDelay time.Duration = task.Interval
+	return due
+}
 
-			if lastLog, err := repositories.GetLatestTaskLog(taskName); err == nil {
-				elapsed := time.Since(lastLog.ExecutedAt)
-				if elapsed < task.Interval {
-					initialDelay = task.Interval - elapsed
-				}
-			}
+// runSchedule waits for each due time in turn and executes the task until
+// doneChan is closed. Jitter is added to every wait so that tasks sharing a
+// schedule do not hit the upstream APIs at the same moment.
+func (tm *TaskManager) runSchedule(taskName string, task types.Task, lastRun time.Time, doneChan chan bool) {
+	for {
+		next, scheduled := tm.nextRun(task, lastRun)
+		if !scheduled {
+			logger.Debugf("TaskManager", "Task %s is manual-only (no schedule or interval)", taskName)
+			return
+		}
 
-			logger.Infof("TaskManager", "Task %s will run in %v", taskName, initialDelay)
-
-			select {
-			case <-time.After(initialDelay):
-				if !tm.checkDependencies(task) {
-					logger.Warnf("TaskManager", "Task %s dependencies not met, skipping execution", taskName)
-				} else if err := task.Execute(); err != nil {
-					tm.logTaskExecution(taskName, "error", err.Error())
-					logger.Errorf("TaskManager", "Task %s execution failed: %v", taskName, err)
-				} else {
-					tm.logTaskExecution(taskName, "success", "Task executed successfully")
-					repositories.SetTaskStatus(&entities.TaskStatus{
-						TaskName:    taskName,
-						IsCompleted: true,
-						LastRunAt:   time.Now(),
-					})
-					logger.Successf("TaskManager", "Task %s executed successfully", taskName)
-					tm.triggerDependentTasks(taskName)
-				}
-			case <-doneChan:
-				return
-			}
+		delay := max(time.Until(next), 0)
+		if task.Jitter > 0 {
+			delay += rand.N(task.Jitter)
+		}
+
+		if delay > 0 {
+			logger.Infof("TaskManager", "Task %s will run in %v", taskName, delay.Round(time.Second))
 		}
 
-		if task.Interval == 0 {
-			logger.Debugf("TaskManager", "Task %s is manual-only (no scheduled interval)", taskName)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-doneChan:
+			timer.Stop()
 			return
 		}
 
-		ticker := time.NewTicker(task.Interval)
-		tm.Mutex.Lock()
-		tm.Tickers[taskName] = ticker
-		tm.Mutex.Unlock()
+		if !tm.checkDependencies(task) {
+			logger.Warnf("TaskManager", "Task %s dependencies not met, skipping execution", taskName)
+		} else if runID, started := tm.startRun(taskName); !started {
+			logger.Infof("TaskManager", "Task %s is already running, skipping scheduled execution", taskName)
+		} else {
+			tm.executeTask(taskName, task, enums.TaskTriggerSchedule, runID)
+		}
 
-		for {
-			select {
-			case <-ticker.C:
-				if !tm.checkDependencies(task) {
-					logger.Warnf("TaskManager", "Task %s dependencies not met, skipping execution", taskName)
-				} else if err := task.Execute(); err != nil {
-					tm.logTaskExecution(taskName, "error", err.Error())
-					logger.Errorf("TaskManager", "Task %s execution failed: %v", taskName, err)
-				} else {
-					tm.logTaskExecution(taskName, "success", "Task executed successfully")
-					repositories.SetTaskStatus(&entities.TaskStatus{
-						TaskName:    taskName,
-						IsCompleted: true,
-						LastRunAt:   time.Now(),
-					})
-					logger.Successf("TaskManager", "Task %s executed successfully", taskName)
-					tm.triggerDependentTasks(taskName)
-				}
-			case <-doneChan:
-				ticker.Stop()
-				return
-			}
+		lastRun = time.Now()
+	}
+}
+
+func (tm *TaskManager) startRun(taskName string) (string, bool) {
+	tm.Mutex.Lock()
+	defer tm.Mutex.Unlock()
+
+	if _, running := tm.Runs[taskName]; running || tm.ctx.Err() != nil {
+		return "", false
+	}
+
+	runID := uuid.NewString()
+	tm.Runs[taskName] = runID
+	tm.wg.Add(1)
+	return runID, true
+}
+
+func (tm *TaskManager) finishRun(taskName string) {
+	tm.Mutex.Lock()
+	defer tm.Mutex.Unlock()
+
+	delete(tm.Runs, taskName)
+	delete(tm.progress, taskName)
+	tm.wg.Done()
+}
+
+// executeTask runs a task that has already been reserved with startRun and
+// records the outcome in its TaskLog. A resume run calls OnResume instead of
+// Execute and, since it is not a full run, neither marks the task completed
+// nor triggers its dependents.
+func (tm *TaskManager) executeTask(taskName string, task types.Task, trigger enums.TaskTrigger, runID string) {
+	execute := task.Execute
+	resuming := trigger == enums.TaskTriggerResume
+	if resuming {
+		execute = func(ctx context.Context) error {
+			task.OnResume(ctx)
+			return nil
 		}
-	}()
+	}
+
+	startedAt := time.Now()
+	logEntry := entities.TaskLog{
+		RunID:      runID,
+		TaskName:   taskName,
+		Trigger:    trigger,
+		Status:     enums.TaskRunRunning,
+		StartedAt:  startedAt,
+		ExecutedAt: startedAt,
+	}
+
+	if err := repositories.CreateTaskLog(&logEntry); err != nil {
+		logger.Warnf("TaskManager", "Failed to log task execution for %s: %v", taskName, err)
+	}
+
+	progress := newRunProgress(tm, &logEntry)
+	tm.Mutex.Lock()
+	tm.progress[taskName] = progress
+	tm.Mutex.Unlock()
 
-	logger.Infof("TaskManager", "Task %s scheduled with interval %v", taskName, task.Interval)
+	err := execute(withProgress(tm.ctx, progress))
+
+	cancelled := tm.ctx.Err() != nil
+	logEntry.ExecutedAt = time.Now()
+	switch {
+	case cancelled:
+		logEntry.Status = enums.TaskRunCancelled
+		logEntry.Message = "Cancelled by shutdown"
+		logger.Warnf("TaskManager", "Task %s cancelled before completion", taskName)
+	case err != nil:
+		logEntry.Status = enums.TaskRunError
+		logEntry.Message = err.Error()
+		logger.Errorf("TaskManager", "Task %s execution failed: %v", taskName, err)
+	case resuming:
+		logEntry.Status = enums.TaskRunSuccess
+		logEntry.Message = "Interrupted work resumed"
+		logger.Successf("TaskManager", "Task %s resumed its interrupted work", taskName)
+	default:
+		logEntry.Status = enums.TaskRunSuccess
+		logEntry.Message = "Task executed successfully"
+		repositories.SetTaskStatus(&entities.TaskStatus{
+			TaskName:    taskName,
+			IsCompleted: true,
+			LastRunAt:   logEntry.ExecutedAt,
+		})
+		logger.Successf("TaskManager", "Task %s executed successfully", taskName)
+	}
+
+	final := progress.finish(logEntry.Status)
+	logEntry.Processed = final.Processed
+	logEntry.Total = final.Total
+	logEntry.Failed = final.Failed
+
+	if updateErr := repositories.UpdateTaskLog(&logEntry); updateErr != nil {
+		logger.Warnf("TaskManager", "Failed to log task execution for %s: %v", taskName, updateErr)
+	}
+
+	tm.finishRun(taskName)
+
+	if err == nil && !cancelled && !resuming {
+		tm.triggerDependentTasks(taskName)
+	}
+}
+
+// TriggerNow starts a run straight away and returns its run ID. Manual runs
+// ignore the schedule, the paused flag and dependencies, but never overlap
+// with a run of the same task that is already in progress.
+func (tm *TaskManager) TriggerNow(taskName string) (string, error) {
+	tm.Mutex.Lock()
+	task, exists := tm.Tasks[taskName]
+	tm.Mutex.Unlock()
+	if !exists {
+		return "", ErrTaskNotFound
+	}
+
+	runID, started := tm.startRun(taskName)
+	if !started {
+		if tm.ctx.Err() != nil {
+			return "", ErrShuttingDown
+		}
+		return "", ErrTaskRunning
+	}
+
+	logger.Infof("TaskManager", "Task %s triggered manually (run %s)", taskName, runID)
+	go tm.executeTask(taskName, task, enums.TaskTriggerManual, runID)
+
+	return runID, nil
+}
+
+// Pause stops scheduling a task until Resume is called. The paused flag is
+// stored with the task status so it survives restarts. A run already in
+// progress is left to finish.
+func (tm *TaskManager) Pause(taskName string) error {
+	tm.Mutex.Lock()
+	_, exists := tm.Tasks[taskName]
+	tm.Mutex.Unlock()
+	if !exists {
+		return ErrTaskNotFound
+	}
+
+	if err := repositories.SetTaskPaused(taskName, true); err != nil {
+		return err
+	}
+
+	tm.StopTask(taskName)
+	logger.Infof("TaskManager", "Task %s paused", taskName)
+
+	return nil
+}
+
+// Resume clears the paused flag and schedules the task again. If it became
+// due while paused it runs immediately. Interrupted work is not resumed, that
+// only happens on startup.
+func (tm *TaskManager) Resume(taskName string) error {
+	tm.Mutex.Lock()
+	_, exists := tm.Tasks[taskName]
+	tm.Mutex.Unlock()
+	if !exists {
+		return ErrTaskNotFound
+	}
+
+	if err := repositories.SetTaskPaused(taskName, false); err != nil {
+		return err
+	}
+
+	logger.Infof("TaskManager", "Task %s resumed", taskName)
+	tm.StartTask(taskName)
+
+	return nil
 }
 
 func (tm *TaskManager) StopTask(taskName string) {
@@ -192,12 +377,14 @@ func (tm *TaskManager) StopTask(taskName string) {
 	if doneChan, exists := tm.Done[taskName]; exists {
 		close(doneChan)
 		delete(tm.Done, taskName)
-		delete(tm.Tickers, taskName)
 		logger.Infof("TaskManager", "Task %s stopped", taskName)
 	}
 }
 
 func (tm *TaskManager) StartAllTasks() {
+	repositories.CancelInterruptedTaskLogs()
+	repositories.RequeueInterruptedJobs()
+
 	tm.Mutex.Lock()
 	var taskNames []string
 	for name := range tm.Tasks {
@@ -206,23 +393,50 @@ func (tm *TaskManager) StartAllTasks() {
 	tm.Mutex.Unlock()
 
 	for _, taskName := range taskNames {
-		tm.StartTask(taskName)
+		due := tm.scheduleTask(taskName)
+
+		// Work interrupted by the previous shutdown is only resumed on
+		// startup. A task that is due runs its full Execute instead, so
+		// resuming it would only duplicate that run.
+		tm.Mutex.Lock()
+		task := tm.Tasks[taskName]
+		tm.Mutex.Unlock()
+		if task.OnResume != nil && !due && !tm.isPaused(taskName) {
+			if runID, started := tm.startRun(taskName); started {
+				logger.Infof("TaskManager", "Resuming interrupted work of %s (run %s)", taskName, runID)
+				go tm.executeTask(taskName, task, enums.TaskTriggerResume, runID)
+			}
+		}
 	}
 }
 
-func (tm *TaskManager) StopAllTasks() {
+// StopAllTasks stops scheduling, cancels the runs in progress and waits for
+// them to record their outcome. It gives up when ctx expires; runs still going
+// at that point are marked cancelled on the next start.
+func (tm *TaskManager) StopAllTasks(ctx context.Context) error {
 	tm.Mutex.Lock()
-	defer tm.Mutex.Unlock()
-
 	for name, doneChan := range tm.Done {
 		close(doneChan)
 		delete(tm.Done, name)
-		if ticker, exists := tm.Tickers[name]; exists {
-			ticker.Stop()
-			delete(tm.Tickers, name)
-		}
 		logger.Infof("TaskManager", "Task %s stopped", name)
 	}
+	tm.cancel()
+	tm.Mutex.Unlock()
+
+	tm.closeSubscribers()
+
+	stopped := make(chan struct{})
+	go func() {
+		tm.wg.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 func (tm *TaskManager) checkDependencies(task types.Task) bool {
@@ -231,6 +445,16 @@ func (tm *TaskManager) checkDependencies(task types.Task) bool {
 	}
 
 	for _, depName := range task.Dependencies {
+		tm.Mutex.Lock()
+		_, registered := tm.Tasks[depName]
+		tm.Mutex.Unlock()
+
+		// Dependencies disabled through config.Sync never complete, so they
+		// must not block the tasks that depend on them.
+		if !registered {
+			continue
+		}
+
 		taskStatus, err := repositories.GetTaskStatus(depName)
 		if err != nil || !taskStatus.IsCompleted {
 			logger.Debugf("TaskManager", "Dependency %s not completed for task %s", depName, task.Name)
@@ -259,57 +483,87 @@ func (tm *TaskManager) triggerDependentTasks(completedTaskName string) {
 	tm.Mutex.Unlock()
 
 	for _, dependent := range dependentTasks {
-		if !tm.checkDependencies(dependent.taskDefinition) {
+		if tm.isPaused(dependent.taskName) || !tm.checkDependencies(dependent.taskDefinition) {
+			continue
+		}
+
+		runID, started := tm.startRun(dependent.taskName)
+		if !started {
 			continue
 		}
 
 		logger.Infof("TaskManager", "All dependencies met for %s, triggering execution", dependent.taskName)
-		go func(name string, task types.Task) {
-			if err := task.Execute(); err != nil {
-				tm.logTaskExecution(name, "error", err.Error())
-				logger.Errorf("TaskManager", "Task %s execution failed: %v", name, err)
-			} else {
-				tm.logTaskExecution(name, "success", "Task executed successfully")
-				repositories.SetTaskStatus(&entities.TaskStatus{
-					TaskName:    name,
-					IsCompleted: true,
-					LastRunAt:   time.Now(),
-				})
-				logger.Successf("TaskManager", "Task %s executed successfully", name)
-				tm.triggerDependentTasks(name)
-			}
-		}(dependent.taskName, dependent.taskDefinition)
+		go tm.executeTask(dependent.taskName, dependent.taskDefinition, enums.TaskTriggerDependency, runID)
 	}
 }
 
 func (tm *TaskManager) GetTaskStatus(taskName string) *types.TaskStatus {
 	tm.Mutex.Lock()
 	task, registered := tm.Tasks[taskName]
-	_, running := tm.Tickers[taskName]
+	runID, running := tm.Runs[taskName]
+	progress := tm.progress[taskName]
 	tm.Mutex.Unlock()
 
 	var lastRun, nextRun *time.Time
 
-	if logEntry, err := repositories.GetLatestTaskLog(taskName); err == nil {
-		lastRun = &logEntry.ExecutedAt
-		if logEntry.Status == "error" {
-			lastRun = nil
+	if logEntry, err := repositories.GetLatestFinishedTaskLog(taskName); err == nil {
+		if logEntry.Status == enums.TaskRunSuccess {
+			lastRun = &logEntry.ExecutedAt
 		}
 
-		if task.Interval > 0 {
-			next := logEntry.ExecutedAt.Add(task.Interval)
+		if next, scheduled := tm.nextRun(task, logEntry.ExecutedAt); scheduled {
 			nextRun = &next
 		}
 	} else if err != gorm.ErrRecordNotFound {
 		logger.Errorf("TaskManager", "Error fetching task log for %s: %v", taskName, err)
 	}
 
-	return &types.TaskStatus{
+	status := &types.TaskStatus{
 		Registered: registered,
 		Running:    running,
+		Paused:     tm.isPaused(taskName),
+		RunID:      runID,
+		Schedule:   task.Schedule,
 		LastRun:    lastRun,
 		NextRun:    nextRun,
 	}
+	if progress != nil {
+		snapshot := progress.Snapshot()
+		status.Progress = &snapshot
+	}
+
+	return status
+}
+
+// GetTaskRuns lists the recorded runs of a task, newest first. The run in
+// progress carries its live counters rather than the last saved ones.
+func (tm *TaskManager) GetTaskRuns(taskName string, page, limit int) ([]entities.TaskLog, int64, error) {
+	tm.Mutex.Lock()
+	_, exists := tm.Tasks[taskName]
+	progress := tm.progress[taskName]
+	tm.Mutex.Unlock()
+	if !exists {
+		return nil, 0, ErrTaskNotFound
+	}
+
+	runs, total, err := repositories.GetTaskLogs(taskName, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if progress != nil {
+		snapshot := progress.Snapshot()
+		for i := range runs {
+			if runs[i].RunID == snapshot.RunID {
+				runs[i].Processed = snapshot.Processed
+				runs[i].Total = snapshot.Total
+				runs[i].Failed = snapshot.Failed
+				runs[i].CurrentItem = snapshot.CurrentItem
+			}
+		}
+	}
+
+	return runs, total, nil
 }
 
 func (tm *TaskManager) GetAllTaskStatuses() map[string]*types.TaskStatus {
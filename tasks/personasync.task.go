@@ -1,19 +1,23 @@
 package tasks
 
 import (
+	"context"
 	"fmt"
 	"metachan/entities"
+	"metachan/enums"
 	"metachan/repositories"
 	"metachan/utils/api/jikan"
 	"metachan/utils/logger"
 	"time"
 )
 
-func ResumePersonEnrichment() {
-	go PersonSync()
+func ResumePersonEnrichment(ctx context.Context) {
+	if err := PersonSync(ctx); err != nil {
+		logger.Warnf("PersonSync", "Resume failed: %v", err)
+	}
 }
 
-func PersonSync() error {
+func PersonSync(ctx context.Context) error {
 	stubs, err := repositories.GetAllPersonStubs()
 	if err != nil {
 		return fmt.Errorf("failed to load person stubs: %w", err)
@@ -21,114 +25,107 @@ func PersonSync() error {
 
 	sevenDaysAgo := time.Now().Add(-7 * 24 * time.Hour)
 
-	hasWork := false
+	var pending []int
 	for _, s := range stubs {
 		if s.EnrichedAt == nil || !s.EnrichedAt.After(sevenDaysAgo) {
-			hasWork = true
-			break
+			pending = append(pending, s.MALID)
 		}
 	}
-	if !hasWork {
-		return nil
+
+	if err := repositories.EnqueueJobs(enums.JobPerson, pending); err != nil {
+		return err
 	}
 
-	total := len(stubs)
-	startTime := time.Now()
-	enriched := 0
+	processed, failed, err := drainJobs(ctx, "PersonSync", enums.JobPerson, enrichPerson)
+	if err != nil {
+		return err
+	}
 
-	for i, s := range stubs {
-		if s.EnrichedAt != nil && s.EnrichedAt.After(sevenDaysAgo) {
-			continue
-		}
+	if processed > 0 {
+		logger.Successf("PersonSync", "Background enrichment complete. Enriched %d people (%d failed)", processed-failed, failed)
+	}
+	return nil
+}
 
-		resp, err := jikan.GetPersonByMALID(s.MALID)
-		if err != nil {
-			logger.Warnf("PersonSync", "Failed to fetch person %d: %v", s.MALID, err)
-			continue
-		}
+func enrichPerson(ctx context.Context, malID int) error {
+	resp, err := jikan.GetPersonByMALID(ctx, malID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch person: %w", err)
+	}
 
-		d := resp.Data
+	d := resp.Data
 
-		var birthday *time.Time
-		if d.Birthday != nil && *d.Birthday != "" {
-			layouts := []string{
-				time.RFC3339,
-				"2006-01-02T15:04:05-07:00",
-				"2006-01-02",
-			}
-			for _, layout := range layouts {
-				if t, err := time.Parse(layout, *d.Birthday); err == nil {
-					birthday = &t
-					break
-				}
-			}
+	var birthday *time.Time
+	if d.Birthday != nil && *d.Birthday != "" {
+		layouts := []string{
+			time.RFC3339,
+			"2006-01-02T15:04:05-07:00",
+			"2006-01-02",
 		}
-
-		var websiteURL string
-		if d.WebsiteURL != nil {
-			websiteURL = *d.WebsiteURL
+		for _, layout := range layouts {
+			if t, err := time.Parse(layout, *d.Birthday); err == nil {
+				birthday = &t
+				break
+			}
 		}
+	}
 
-		var voiceRoles []entities.PersonVoiceRole
-		for _, v := range d.Voices {
-			voiceRoles = append(voiceRoles, entities.PersonVoiceRole{
-				Role:              v.Role,
-				AnimeMALID:        v.Anime.MALID,
-				AnimeTitle:        v.Anime.Title,
-				AnimeURL:          v.Anime.URL,
-				AnimeImageURL:     v.Anime.Images.JPG.ImageURL,
-				CharacterMALID:    v.Character.MALID,
-				CharacterName:     v.Character.Name,
-				CharacterURL:      v.Character.URL,
-				CharacterImageURL: v.Character.Images.JPG.ImageURL,
-			})
-		}
+	var websiteURL string
+	if d.WebsiteURL != nil {
+		websiteURL = *d.WebsiteURL
+	}
 
-		var animeCredits []entities.PersonAnimeCredit
-		for _, a := range d.Anime {
-			animeCredits = append(animeCredits, entities.PersonAnimeCredit{
-				Position:      a.Position,
-				AnimeMALID:    a.Anime.MALID,
-				AnimeTitle:    a.Anime.Title,
-				AnimeURL:      a.Anime.URL,
-				AnimeImageURL: a.Anime.Images.JPG.ImageURL,
-			})
-		}
+	var voiceRoles []entities.PersonVoiceRole
+	for _, v := range d.Voices {
+		voiceRoles = append(voiceRoles, entities.PersonVoiceRole{
+			Role:              v.Role,
+			AnimeMALID:        v.Anime.MALID,
+			AnimeTitle:        v.Anime.Title,
+			AnimeURL:          v.Anime.URL,
+			AnimeImageURL:     v.Anime.Images.JPG.ImageURL,
+			CharacterMALID:    v.Character.MALID,
+			CharacterName:     v.Character.Name,
+			CharacterURL:      v.Character.URL,
+			CharacterImageURL: v.Character.Images.JPG.ImageURL,
+		})
+	}
 
-		var mangaCredits []entities.PersonMangaCredit
-		for _, m := range d.Manga {
-			mangaCredits = append(mangaCredits, entities.PersonMangaCredit{
-				Position:      m.Position,
-				MangaMALID:    m.Manga.MALID,
-				MangaTitle:    m.Manga.Title,
-				MangaURL:      m.Manga.URL,
-				MangaImageURL: m.Manga.Images.JPG.ImageURL,
-			})
-		}
+	var animeCredits []entities.PersonAnimeCredit
+	for _, a := range d.Anime {
+		animeCredits = append(animeCredits, entities.PersonAnimeCredit{
+			Position:      a.Position,
+			AnimeMALID:    a.Anime.MALID,
+			AnimeTitle:    a.Anime.Title,
+			AnimeURL:      a.Anime.URL,
+			AnimeImageURL: a.Anime.Images.JPG.ImageURL,
+		})
+	}
 
-		if err := repositories.UpdatePersonDetails(
-			d.MALID,
-			d.URL, websiteURL, d.Images.JPG.ImageURL,
-			d.Name, d.GivenName, d.FamilyName,
-			d.AlternateNames, birthday,
-			d.Favorites, d.About,
-			voiceRoles, animeCredits, mangaCredits,
-		); err != nil {
-			logger.Warnf("PersonSync", "Failed to update person %d: %v", s.MALID, err)
-			continue
-		}
+	var mangaCredits []entities.PersonMangaCredit
+	for _, m := range d.Manga {
+		mangaCredits = append(mangaCredits, entities.PersonMangaCredit{
+			Position:      m.Position,
+			MangaMALID:    m.Manga.MALID,
+			MangaTitle:    m.Manga.Title,
+			MangaURL:      m.Manga.URL,
+			MangaImageURL: m.Manga.Images.JPG.ImageURL,
+		})
+	}
 
-		if err := repositories.SetPersonEnriched(d.MALID); err != nil {
-			logger.Warnf("PersonSync", "Failed to stamp enriched_at for person %d: %v", d.MALID, err)
-		}
+	if err := repositories.UpdatePersonDetails(
+		d.MALID,
+		d.URL, websiteURL, d.Images.JPG.ImageURL,
+		d.Name, d.GivenName, d.FamilyName,
+		d.AlternateNames, birthday,
+		d.Favorites, d.About,
+		voiceRoles, animeCredits, mangaCredits,
+	); err != nil {
+		return fmt.Errorf("failed to update person: %w", err)
+	}
 
-		enriched++
-		if (i+1)%50 == 0 || (i+1) == total {
-			progress, eta := calculateProgress(i+1, total, startTime)
-			logger.Infof("PersonSync", "Enriching: %d/%d (%.1f%%) | ETA: %v", i+1, total, progress, eta)
-		}
+	if err := repositories.SetPersonEnriched(d.MALID); err != nil {
+		logger.Warnf("PersonSync", "Failed to stamp enriched_at for person %d: %v", d.MALID, err)
 	}
 
-	logger.Successf("PersonSync", "Background enrichment complete. Enriched %d people", enriched)
 	return nil
 }
@@ -1,7 +1,10 @@
 package tasks
 
 import (
+	"context"
+	"fmt"
 	"metachan/entities"
+	"metachan/enums"
 	"metachan/repositories"
 	"metachan/types"
 	"metachan/utils/api/jikan"
@@ -11,14 +14,16 @@ import (
 
 // ResumeProducerEnrichment is called on startup to resume any background enrichment
 // that was interrupted by a previous shutdown.
-func ResumeProducerEnrichment() {
-	go enrichProducersInBackground()
+func ResumeProducerEnrichment(ctx context.Context) {
+	if err := enrichProducers(ctx); err != nil {
+		logger.Warnf("ProducerSync", "Resume failed: %v", err)
+	}
 }
 
-func ProducerSync() error {
+func ProducerSync(ctx context.Context) error {
 	logger.Infof("ProducerSync", "Starting producer sync (includes studios and licensors)")
 
-	response, err := jikan.GetAnimeProducers()
+	response, err := jikan.GetAnimeProducers(ctx)
 	if err != nil {
 		logger.Errorf("ProducerSync", "Failed to fetch producers: %v", err)
 		return err
@@ -31,11 +36,9 @@ func ProducerSync() error {
 		return err
 	}
 
-	logger.Successf("ProducerSync", "Saved basic data for %d producers, enriching external URLs in background", total)
-
-	go enrichProducersInBackground()
+	logger.Successf("ProducerSync", "Saved basic data for %d producers, enriching external URLs", total)
 
-	return nil
+	return enrichProducers(ctx)
 }
 
 func saveProducerListData(producersData []types.JikanSingleProducer) error {
@@ -145,73 +148,69 @@ func saveProducerListData(producersData []types.JikanSingleProducer) error {
 	return nil
 }
 
-func enrichProducersInBackground() {
+func enrichProducers(ctx context.Context) error {
 	producers, err := repositories.GetAllProducers()
 	if err != nil {
 		logger.Errorf("ProducerSync", "Failed to load producers for enrichment: %v", err)
-		return
+		return err
 	}
 
 	sevenDaysAgo := time.Now().Add(-7 * 24 * time.Hour)
 
-	// Pre-check: skip entire run if nothing qualifies
-	hasWork := false
+	var pending []int
 	for _, p := range producers {
 		if p.EnrichedAt == nil || !p.EnrichedAt.After(sevenDaysAgo) {
-			hasWork = true
-			break
+			pending = append(pending, p.MALID)
 		}
 	}
-	if !hasWork {
-		return
+
+	if err := repositories.EnqueueJobs(enums.JobProducer, pending); err != nil {
+		return err
 	}
 
-	logger.Infof("ProducerSync", "Resuming background enrichment for producers missing external URLs")
+	processed, failed, err := drainJobs(ctx, "ProducerSync", enums.JobProducer, enrichProducer)
+	if err != nil {
+		return err
+	}
 
-	total := len(producers)
-	startTime := time.Now()
-	enriched := 0
+	if processed > 0 {
+		logger.Successf("ProducerSync", "Background enrichment complete. Enriched %d producers with external URLs (%d failed)", processed-failed, failed)
+	}
+	return nil
+}
 
-	for i, p := range producers {
-		if p.EnrichedAt != nil && p.EnrichedAt.After(sevenDaysAgo) {
-			continue
-		}
+func enrichProducer(ctx context.Context, malID int) error {
+	p, err := repositories.GetProducer(malID)
+	if err != nil {
+		return err
+	}
 
-		detail, err := jikan.GetProducerByID(p.MALID)
-		if err != nil {
-			logger.Warnf("ProducerSync", "Failed to fetch details for producer %d: %v", p.MALID, err)
-			continue
-		}
+	detail, err := jikan.GetProducerByID(ctx, malID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch producer details: %w", err)
+	}
 
-		data := detail.Data
-		if err := repositories.UpdateProducerDetails(
-			p.ID, data.URL, data.Established, data.About, data.Favorites, data.Count,
-			data.Images.JPG.ImageURL,
-		); err != nil {
-			logger.Warnf("ProducerSync", "Failed to update details for producer %d: %v", p.MALID, err)
-			continue
-		}
+	data := detail.Data
+	if err := repositories.UpdateProducerDetails(
+		p.ID, data.URL, data.Established, data.About, data.Favorites, data.Count,
+		data.Images.JPG.ImageURL,
+	); err != nil {
+		return fmt.Errorf("failed to update producer details: %w", err)
+	}
 
-		if len(data.External) > 0 {
-			externalURLs := make([]entities.ExternalURL, 0, len(data.External))
-			for _, ext := range data.External {
-				externalURLs = append(externalURLs, entities.ExternalURL{Name: ext.Name, URL: ext.URL})
-			}
-			if err := repositories.ReplaceProducerExternalURLs(&p, externalURLs); err != nil {
-				logger.Warnf("ProducerSync", "Failed to update external URLs for producer %d: %v", p.MALID, err)
-			}
+	if len(data.External) > 0 {
+		externalURLs := make([]entities.ExternalURL, 0, len(data.External))
+		for _, ext := range data.External {
+			externalURLs = append(externalURLs, entities.ExternalURL{Name: ext.Name, URL: ext.URL})
 		}
-
-		if err := repositories.SetProducerEnriched(p.ID); err != nil {
-			logger.Warnf("ProducerSync", "Failed to stamp enriched_at for producer %d: %v", p.MALID, err)
+		if err := repositories.ReplaceProducerExternalURLs(&p, externalURLs); err != nil {
+			logger.Warnf("ProducerSync", "Failed to update external URLs for producer %d: %v", malID, err)
 		}
+	}
 
-		enriched++
-		if (i+1)%10 == 0 || (i+1) == total {
-			progress, eta := calculateProgress(i+1, total, startTime)
-			logger.Infof("ProducerSync", "Enriching: %d/%d (%.1f%%) | ETA: %v", i+1, total, progress, eta)
-		}
+	if err := repositories.SetProducerEnriched(p.ID); err != nil {
+		logger.Warnf("ProducerSync", "Failed to stamp enriched_at for producer %d: %v", malID, err)
 	}
 
-	logger.Successf("ProducerSync", "Background enrichment complete. Enriched %d producers with external URLs", enriched)
+	return nil
 }
@@ -0,0 +1,216 @@
+package tasks
+
+import (
+	"context"
+	"metachan/entities"
+	"metachan/enums"
+	"metachan/repositories"
+	"metachan/types"
+	"sync"
+	"time"
+)
+
+// progressPersistInterval limits how often a run's counters are written to
+// its TaskLog. Subscribers still receive every update.
+const progressPersistInterval = 5 * time.Second
+
+type progressKey struct{}
+
+// runProgress tracks how far a run has got. Tasks look it up with
+// progressFrom and report their items as they go. The reporting methods are
+// safe to call on a nil receiver, which is what tasks get when they run
+// outside the manager.
+type runProgress struct {
+	mu          sync.Mutex
+	manager     *TaskManager
+	logID       uint
+	progress    types.TaskProgress
+	persistedAt time.Time
+}
+
+func newRunProgress(tm *TaskManager, logEntry *entities.TaskLog) *runProgress {
+	return &runProgress{
+		manager: tm,
+		logID:   logEntry.ID,
+		progress: types.TaskProgress{
+			Task:      logEntry.TaskName,
+			RunID:     logEntry.RunID,
+			Status:    enums.TaskRunRunning,
+			StartedAt: logEntry.StartedAt,
+			UpdatedAt: logEntry.StartedAt,
+		},
+		persistedAt: logEntry.StartedAt,
+	}
+}
+
+func withProgress(ctx context.Context, progress *runProgress) context.Context {
+	return context.WithValue(ctx, progressKey{}, progress)
+}
+
+func progressFrom(ctx context.Context) *runProgress {
+	progress, _ := ctx.Value(progressKey{}).(*runProgress)
+	return progress
+}
+
+// SetTotal records how many items the run is going to process.
+func (p *runProgress) SetTotal(total int) {
+	p.update(func(progress *types.TaskProgress) {
+		progress.Total = total
+	})
+}
+
+// ItemStarted records the item the run is currently working on.
+func (p *runProgress) ItemStarted(item string) {
+	p.update(func(progress *types.TaskProgress) {
+		progress.CurrentItem = item
+	})
+}
+
+// ItemDone counts one processed item, and a failure as well when err is set.
+func (p *runProgress) ItemDone(err error) {
+	p.update(func(progress *types.TaskProgress) {
+		progress.Processed++
+		if err != nil {
+			progress.Failed++
+		}
+	})
+}
+
+// Advance counts n processed items at once, for tasks that work in batches.
+func (p *runProgress) Advance(n int) {
+	p.update(func(progress *types.TaskProgress) {
+		progress.Processed += n
+	})
+}
+
+func (p *runProgress) Snapshot() types.TaskProgress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.progress
+}
+
+func (p *runProgress) update(apply func(progress *types.TaskProgress)) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	apply(&p.progress)
+	p.progress.UpdatedAt = time.Now()
+	p.progress.Percent, p.progress.ETASeconds = 0, 0
+	if p.progress.Processed > 0 && p.progress.Total > 0 {
+		percent, eta := calculateProgress(min(p.progress.Processed, p.progress.Total), p.progress.Total, p.progress.StartedAt)
+		p.progress.Percent = percent
+		p.progress.ETASeconds = int64(eta.Seconds())
+	}
+	snapshot := p.progress
+	persist := snapshot.UpdatedAt.Sub(p.persistedAt) >= progressPersistInterval
+	if persist {
+		p.persistedAt = snapshot.UpdatedAt
+	}
+	p.mu.Unlock()
+
+	p.manager.publish(snapshot)
+
+	if persist {
+		p.save(snapshot)
+	}
+}
+
+// finish marks the run as ended and returns the final counters so that
+// executeTask can store them with the run's outcome.
+func (p *runProgress) finish(status enums.TaskRunStatus) types.TaskProgress {
+	p.mu.Lock()
+	p.progress.Status = status
+	p.progress.CurrentItem = ""
+	p.progress.ETASeconds = 0
+	p.progress.UpdatedAt = time.Now()
+	snapshot := p.progress
+	p.mu.Unlock()
+
+	p.manager.publish(snapshot)
+	return snapshot
+}
+
+func (p *runProgress) save(snapshot types.TaskProgress) {
+	if p.logID == 0 {
+		return
+	}
+
+	logEntry := entities.TaskLog{
+		Processed:   snapshot.Processed,
+		Total:       snapshot.Total,
+		Failed:      snapshot.Failed,
+		CurrentItem: snapshot.CurrentItem,
+	}
+	logEntry.ID = p.logID
+	logEntry.RunID = snapshot.RunID
+
+	repositories.UpdateTaskLogProgress(&logEntry)
+}
+
+// Subscribe streams the progress of every run until unsubscribe is called or
+// the manager stops, at which point the channel is closed. Updates are
+// dropped for a subscriber that falls behind rather than stalling the tasks.
+func (tm *TaskManager) Subscribe() (<-chan types.TaskProgress, func()) {
+	updates := make(chan types.TaskProgress, 64)
+
+	tm.Mutex.Lock()
+	if tm.ctx.Err() != nil {
+		close(updates)
+	} else {
+		tm.subscribers[updates] = struct{}{}
+	}
+	tm.Mutex.Unlock()
+
+	unsubscribe := func() {
+		tm.Mutex.Lock()
+		defer tm.Mutex.Unlock()
+
+		if _, exists := tm.subscribers[updates]; exists {
+			delete(tm.subscribers, updates)
+			close(updates)
+		}
+	}
+
+	return updates, unsubscribe
+}
+
+func (tm *TaskManager) publish(progress types.TaskProgress) {
+	tm.Mutex.Lock()
+	defer tm.Mutex.Unlock()
+
+	for updates := range tm.subscribers {
+		select {
+		case updates <- progress:
+		default:
+		}
+	}
+}
+
+func (tm *TaskManager) closeSubscribers() {
+	tm.Mutex.Lock()
+	defer tm.Mutex.Unlock()
+
+	for updates := range tm.subscribers {
+		delete(tm.subscribers, updates)
+		close(updates)
+	}
+}
+
+// RunningProgress returns a snapshot of every run in progress.
+func (tm *TaskManager) RunningProgress() []types.TaskProgress {
+	tm.Mutex.Lock()
+	runs := make([]*runProgress, 0, len(tm.progress))
+	for _, progress := range tm.progress {
+		runs = append(runs, progress)
+	}
+	tm.Mutex.Unlock()
+
+	snapshots := make([]types.TaskProgress, len(runs))
+	for i, progress := range runs {
+		snapshots[i] = progress.Snapshot()
+	}
+	return snapshots
+}
@@ -0,0 +1,119 @@
+package tasks
+
+import (
+	"context"
+	"metachan/entities"
+	"metachan/enums"
+	"metachan/repositories"
+	"metachan/utils/logger"
+	"time"
+)
+
+const (
+	jobMaxAttempts = 5
+	jobRetryDelay  = 30 * time.Second
+	jobMaxDelay    = 6 * time.Hour
+	jobClaimBatch  = 50
+)
+
+// drainJobs runs handle for every due job of one kind until none are left.
+// A failed job is retried with exponential backoff, so one that fails early
+// may come round again before the queue is empty; after jobMaxAttempts it is
+// dead-lettered and left for an operator to look at.
+func drainJobs(ctx context.Context, prefix string, kind enums.JobKind, handle func(ctx context.Context, malID int) error) (int, int, error) {
+	due, err := repositories.CountDueJobs(kind)
+	if err != nil {
+		return 0, 0, err
+	}
+	if due == 0 {
+		return 0, 0, nil
+	}
+
+	logger.Infof(prefix, "Processing %d queued %s jobs", due, kind)
+
+	tracker := progressFrom(ctx)
+	tracker.SetTotal(int(due))
+
+	startTime := time.Now()
+	processed, failed := 0, 0
+	for {
+		jobs, err := repositories.GetDueJobs(kind, jobClaimBatch)
+		if err != nil {
+			return processed, failed, err
+		}
+		if len(jobs) == 0 {
+			return processed, failed, nil
+		}
+
+		for i := range jobs {
+			if err := ctx.Err(); err != nil {
+				return processed, failed, err
+			}
+
+			job := &jobs[i]
+			claimed, err := repositories.ClaimJob(job)
+			if err != nil {
+				return processed, failed, err
+			}
+			if !claimed {
+				continue
+			}
+
+			tracker.ItemStarted(job.IdempotencyKey)
+			jobErr := handle(ctx, job.MALID)
+
+			// Shutting down is not the item's fault, so give the attempt back.
+			if jobErr != nil && ctx.Err() != nil {
+				job.Status = enums.JobPending
+				job.Attempts--
+				repositories.UpdateJob(job)
+				return processed, failed, ctx.Err()
+			}
+
+			finishJob(prefix, job, jobErr)
+			tracker.ItemDone(jobErr)
+
+			processed++
+			if jobErr != nil {
+				failed++
+			}
+			if processed%50 == 0 {
+				progress, eta := calculateProgress(min(processed, int(due)), int(due), startTime)
+				logger.Infof(prefix, "Processed %d/%d %s jobs (%.1f%%, %d failed) | ETA: %v", processed, due, kind, progress, failed, eta)
+			}
+		}
+	}
+}
+
+func finishJob(prefix string, job *entities.SyncJob, jobErr error) {
+	now := time.Now()
+
+	switch {
+	case jobErr == nil:
+		job.Status = enums.JobDone
+		job.LastError = ""
+		job.CompletedAt = &now
+	case job.Attempts >= jobMaxAttempts:
+		job.Status = enums.JobDead
+		job.LastError = jobErr.Error()
+		logger.Warnf(prefix, "Job %s failed %d times, dead-lettering it: %v", job.IdempotencyKey, job.Attempts, jobErr)
+	default:
+		delay := retryDelay(job.Attempts)
+		job.Status = enums.JobPending
+		job.LastError = jobErr.Error()
+		job.NextAttemptAt = now.Add(delay)
+		logger.Warnf(prefix, "Job %s failed (attempt %d/%d), retrying in %v: %v", job.IdempotencyKey, job.Attempts, jobMaxAttempts, delay, jobErr)
+	}
+
+	repositories.UpdateJob(job)
+}
+
+// retryDelay doubles with every attempt: 30s, 1m, 2m, 4m and so on, capped at
+// jobMaxDelay.
+func retryDelay(attempts int) time.Duration {
+	delay := jobRetryDelay
+	for i := 1; i < attempts && delay < jobMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, jobMaxDelay)
+}
@@ -1,8 +1,12 @@
 package tasks
 
 import (
+	"context"
+	"metachan/config"
 	"metachan/types"
+	"metachan/utils/cron"
 	"metachan/utils/logger"
+	"metachan/utils/ratelimit"
 	"sync"
 	"time"
 )
@@ -11,19 +15,110 @@ var GlobalTaskManager *TaskManager
 
 func init() {
 	GlobalTaskManager = &TaskManager{
-		Tasks:   make(map[string]types.Task),
-		Tickers: make(map[string]*time.Ticker),
-		Done:    make(map[string]chan bool),
-		Mutex:   sync.Mutex{},
+		Tasks:     make(map[string]types.Task),
+		Schedules: make(map[string]*cron.Schedule),
+		Done:      make(map[string]chan bool),
+		Runs:      make(map[string]string),
+		Mutex:     sync.Mutex{},
+
+		progress:    make(map[string]*runProgress),
+		subscribers: make(map[chan types.TaskProgress]struct{}),
+	}
+	// Task runs queue behind user requests for the upstream rate limits.
+	GlobalTaskManager.ctx, GlobalTaskManager.cancel = context.WithCancel(ratelimit.WithPriority(context.Background(), ratelimit.Background))
+
+	registrations := []struct {
+		enabled bool
+		task    types.Task
+	}{
+		{
+			enabled: config.Sync.AnimeFetch,
+			task: types.Task{
+				Name:     "AnimeFetch",
+				Schedule: config.Sync.AnimeFetchSchedule,
+				Jitter:   config.Sync.AnimeFetchJitter,
+				Interval: 7 * 24 * time.Hour,
+				Execute:  AniFetch,
+			},
+		},
+		{
+			enabled: config.Sync.GenreSync,
+			task: types.Task{
+				Name:     "GenreSync",
+				Schedule: config.Sync.GenreSyncSchedule,
+				Jitter:   config.Sync.GenreSyncJitter,
+				Interval: 7 * 24 * time.Hour,
+				Execute:  GenreSync,
+			},
+		},
+		{
+			enabled: config.Sync.ProducerSync,
+			task: types.Task{
+				Name:     "ProducerSync",
+				Schedule: config.Sync.ProducerSyncSchedule,
+				Jitter:   config.Sync.ProducerSyncJitter,
+				Interval: 7 * 24 * time.Hour,
+				Execute:  ProducerSync,
+				OnResume: ResumeProducerEnrichment,
+			},
+		},
+		{
+			enabled: config.Sync.AniSync,
+			task: types.Task{
+				Name:         "AniSync",
+				Schedule:     config.Sync.AniSyncSchedule,
+				Jitter:       config.Sync.AniSyncJitter,
+				Interval:     24 * time.Hour,
+				Execute:      AniSync,
+				OnResume:     ResumeAnimeSync,
+				Dependencies: []string{"AnimeFetch", "GenreSync"},
+			},
+		},
+		{
+			enabled: config.Sync.AnimeUpdate,
+			task: types.Task{
+				Name:         "AnimeUpdate",
+				Schedule:     config.Sync.AnimeUpdateSchedule,
+				Jitter:       config.Sync.AnimeUpdateJitter,
+				Interval:     UpdateInterval,
+				Execute:      AnimeUpdate,
+				Dependencies: []string{"AniSync"},
+			},
+		},
+		{
+			enabled: config.Sync.CharacterSync,
+			task: types.Task{
+				Name:         "CharacterSync",
+				Schedule:     config.Sync.CharacterSyncSchedule,
+				Jitter:       config.Sync.CharacterSyncJitter,
+				Interval:     24 * time.Hour,
+				Execute:      CharacterSync,
+				OnResume:     ResumeCharacterEnrichment,
+				Dependencies: []string{"AniSync"},
+			},
+		},
+		{
+			enabled: config.Sync.PersonSync,
+			task: types.Task{
+				Name:         "PersonSync",
+				Schedule:     config.Sync.PersonSyncSchedule,
+				Jitter:       config.Sync.PersonSyncJitter,
+				Interval:     24 * time.Hour,
+				Execute:      PersonSync,
+				OnResume:     ResumePersonEnrichment,
+				Dependencies: []string{"AniSync"},
+			},
+		},
 	}
 
-	err := GlobalTaskManager.RegisterTask(types.Task{
-		Name:     "AnimeFetch",
-		Interval: 7 * 24 * time.Hour,
-		Execute:  AniFetch,
-	})
+	for _, registration := range registrations {
+		if !registration.enabled {
+			logger.Infof("TaskManager", "Task %s disabled by configuration", registration.task.Name)
+			continue
+		}
 
-	if err != nil {
-		logger.Errorf("TaskManager", "Failed to register AnimeFetch task: %v", err)
+		if err := GlobalTaskManager.RegisterTask(registration.task); err != nil {
+			logger.Errorf("TaskManager", "Failed to register %s task: %v", registration.task.Name, err)
+		}
 	}
 }
@@ -0,0 +1,6 @@
+package types
+
+type TaskRunResponse struct {
+	Task  string `json:"task"`
+	RunID string `json:"run_id"`
+}
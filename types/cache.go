@@ -0,0 +1,12 @@
+package types
+
+import (
+	"metachan/enums"
+	"time"
+)
+
+type CacheInfo struct {
+	Status enums.CacheStatus
+	Age    time.Duration
+	TTL    time.Duration
+}
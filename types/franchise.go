@@ -0,0 +1,52 @@
+package types
+
+import (
+	"metachan/enums"
+	"time"
+)
+
+// Franchise is every anime connected to one through MAL relations or a
+// shared TVDB or TMDB entry. Entries are in release order and the watch
+// orders list their MAL IDs.
+type Franchise struct {
+	MALID       int              `json:"mal_id"`
+	Entries     []FranchiseEntry `json:"entries"`
+	Edges       []FranchiseEdge  `json:"edges"`
+	WatchOrders WatchOrders      `json:"watch_orders"`
+}
+
+// FranchiseEntry is one anime of a franchise. Anime that have not been synced
+// yet are known only from a relation and carry no dates.
+type FranchiseEntry struct {
+	MALID        int        `json:"mal_id"`
+	Title        string     `json:"title,omitempty"`
+	TitleEnglish string     `json:"title_english,omitempty"`
+	Type         string     `json:"type,omitempty"`
+	Status       string     `json:"status,omitempty"`
+	Episodes     int        `json:"episodes,omitempty"`
+	Season       string     `json:"season,omitempty"`
+	Year         int        `json:"year,omitempty"`
+	AiredFrom    *time.Time `json:"aired_from,omitempty"`
+	ImageURL     string     `json:"image_url,omitempty"`
+	Synced       bool       `json:"synced"`
+}
+
+// FranchiseEdge connects two entries. Via is mal for a MAL relation, read as
+// "To is the Relation of From", and tvdb or tmdb for anime filed under the
+// same series there.
+type FranchiseEdge struct {
+	From     int                     `json:"from"`
+	To       int                     `json:"to"`
+	Via      enums.MappingType       `json:"via"`
+	Relation enums.AnimeRelationType `json:"relation,omitempty"`
+}
+
+// WatchOrders suggest how to go through a franchise. Release follows the air
+// dates, Chronological puts prequels first and side stories after what they
+// branch off, and MainStory keeps only the sequel chain, without recaps,
+// specials or side stories.
+type WatchOrders struct {
+	Release       []int `json:"release"`
+	Chronological []int `json:"chronological"`
+	MainStory     []int `json:"main_story"`
+}
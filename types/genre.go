@@ -0,0 +1,10 @@
+package types
+
+import "metachan/entities"
+
+type GenreListResponse struct {
+	Genres         []entities.Genre `json:"genres"`
+	ExplicitGenres []entities.Genre `json:"explicit_genres"`
+	Themes         []entities.Genre `json:"themes"`
+	Demographics   []entities.Genre `json:"demographics"`
+}
@@ -1,5 +1,10 @@
 package types
 
+import (
+	"metachan/enums"
+	"time"
+)
+
 type MemoryStats struct {
 	Used  string `json:"used"`
 	Total string `json:"total"`
@@ -13,10 +18,73 @@ type DatabaseStatus struct {
 }
 
 type HealthStatus struct {
-	Status    string                 `json:"status"`
-	Timestamp string                 `json:"timestamp"`
-	Uptime    string                 `json:"uptime"`
-	Memory    MemoryStats            `json:"memory"`
-	Database  DatabaseStatus         `json:"database"`
-	Tasks     map[string]*TaskStatus `json:"tasks"`
+	Status        string                 `json:"status"`
+	Timestamp     string                 `json:"timestamp"`
+	Uptime        string                 `json:"uptime"`
+	Memory        MemoryStats            `json:"memory"`
+	Database      DatabaseStatus         `json:"database"`
+	Tasks         map[string]*TaskStatus `json:"tasks"`
+	Upstreams     []UpstreamStatus       `json:"upstreams"`
+	Limiters      []RateLimiterStats     `json:"limiters"`
+	ResponseCache *ResponseCacheStats    `json:"response_cache,omitempty"`
+	Parsers       []ParseHealth          `json:"parsers"`
+}
+
+// ParseHealth tells how one kind of MAL page has been parsing since startup.
+// A page is flagged when fields that every page carries came back empty, and
+// Drifting is set once half of the recent pages were, which usually means
+// MAL changed its markup.
+type ParseHealth struct {
+	Page          string           `json:"page"`
+	Checked       int64            `json:"checked"`
+	Flagged       int64            `json:"flagged"`
+	Recent        int              `json:"recent"`
+	RecentFlagged int              `json:"recent_flagged"`
+	Drifting      bool             `json:"drifting"`
+	MissingFields map[string]int64 `json:"missing_fields,omitempty"`
+	LastFlagged   *FlaggedPage     `json:"last_flagged,omitempty"`
+}
+
+// FlaggedPage is a page that parsed with expected fields missing.
+type FlaggedPage struct {
+	MALID   int       `json:"mal_id"`
+	Missing []string  `json:"missing"`
+	At      time.Time `json:"at"`
+}
+
+// ResponseCacheStats count how upstream calls were answered since startup.
+// Hits never reached the upstream, Revalidated got a 304 and Misses were
+// downloaded again. Evicted counts the expired entries deleted since startup,
+// and Entries and SizeBytes measure the cache as of its last sweep.
+type ResponseCacheStats struct {
+	Hits        int64 `json:"hits"`
+	Revalidated int64 `json:"revalidated"`
+	Misses      int64 `json:"misses"`
+	Evicted     int64 `json:"evicted"`
+	Entries     int64 `json:"entries"`
+	SizeBytes   int64 `json:"size_bytes"`
+}
+
+// RateLimiterStats are the counters of one upstream limiter since startup.
+// RateFactor is the share of the configured rate currently allowed; it drops
+// below 1 after the upstream returns 429 and recovers on its own.
+type RateLimiterStats struct {
+	Name        string     `json:"name"`
+	Allowed     int64      `json:"allowed"`
+	Delayed     int64      `json:"delayed"`
+	WaitSeconds float64    `json:"wait_seconds"`
+	Throttled   int64      `json:"throttled"`
+	Cancelled   int64      `json:"cancelled"`
+	RateFactor  float64    `json:"rate_factor"`
+	PausedUntil *time.Time `json:"paused_until,omitempty"`
+}
+
+// UpstreamStatus is the circuit breaker state of one upstream. RetryAt is
+// when an open breaker lets a probe request through.
+type UpstreamStatus struct {
+	Name     enums.Upstream     `json:"name"`
+	State    enums.BreakerState `json:"state"`
+	Failures int                `json:"failures"`
+	OpenedAt *time.Time         `json:"opened_at,omitempty"`
+	RetryAt  *time.Time         `json:"retry_at,omitempty"`
 }
@@ -177,6 +177,15 @@ type JikanAnimeCharacterResponse struct {
 	Data []JikanSingleCharacter `json:"data"`
 }
 
+type JikanAnimeStaffMember struct {
+	Person    JikanCharacterPerson `json:"person"`
+	Positions []string             `json:"positions"`
+}
+
+type JikanAnimeStaffResponse struct {
+	Data []JikanAnimeStaffMember `json:"data"`
+}
+
 type JikanCharacterSimpleAnime struct {
 	MALID  int                     `json:"mal_id"`
 	URL    string                  `json:"url"`
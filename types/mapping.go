@@ -1,6 +1,9 @@
 package types
 
-import "metachan/enums"
+import (
+	"metachan/entities"
+	"metachan/enums"
+)
 
 type MappingResponse struct {
 	AniDB          int                    `json:"anidb_id"`
@@ -18,3 +21,8 @@ type MappingResponse struct {
 	TVDB           int                    `json:"thetvdb_id"`
 	Type           enums.MappingAnimeType `json:"type"`
 }
+
+type MappingResolveResponse struct {
+	Provider enums.MappingType             `json:"provider"`
+	Results  map[string][]entities.Mapping `json:"results"`
+}
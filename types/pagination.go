@@ -0,0 +1,29 @@
+package types
+
+type Pagination struct {
+	Page        int   `json:"page"`
+	Limit       int   `json:"limit"`
+	Total       int64 `json:"total"`
+	LastPage    int   `json:"last_page"`
+	HasNextPage bool  `json:"has_next_page"`
+}
+
+type PaginatedResponse[T any] struct {
+	Pagination Pagination `json:"pagination"`
+	Data       []T        `json:"data"`
+}
+
+func NewPagination(page, limit int, total int64) Pagination {
+	lastPage := 0
+	if limit > 0 {
+		lastPage = int((total + int64(limit) - 1) / int64(limit))
+	}
+
+	return Pagination{
+		Page:        page,
+		Limit:       limit,
+		Total:       total,
+		LastPage:    lastPage,
+		HasNextPage: page < lastPage,
+	}
+}
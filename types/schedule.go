@@ -0,0 +1,29 @@
+package types
+
+import "metachan/entities"
+
+type ScheduleEntry struct {
+	MALID     int                  `json:"mal_id"`
+	Titles    entities.AnimeTitle  `json:"titles"`
+	Images    entities.AnimeImages `json:"images"`
+	Episode   int                  `json:"episode,omitempty"`
+	AiringAt  int64                `json:"airing_at"`
+	AirsAt    string               `json:"airs_at"`
+	Estimated bool                 `json:"estimated,omitempty"`
+}
+
+type ScheduleDay struct {
+	Day   string          `json:"day"`
+	Anime []ScheduleEntry `json:"anime"`
+}
+
+type WeeklySchedule struct {
+	Timezone string        `json:"timezone"`
+	Days     []ScheduleDay `json:"days"`
+}
+
+type UpcomingSchedule struct {
+	Timezone string          `json:"timezone"`
+	Hours    int             `json:"hours"`
+	Episodes []ScheduleEntry `json:"episodes"`
+}
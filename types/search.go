@@ -0,0 +1,9 @@
+package types
+
+type AnimeSearchFilters struct {
+	Type   string
+	Status string
+	Season string
+	Genre  string
+	Year   int
+}
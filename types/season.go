@@ -0,0 +1,20 @@
+package types
+
+import (
+	"metachan/entities"
+	"metachan/enums"
+)
+
+type SeasonChartOptions struct {
+	Type              string
+	IncludeContinuing bool
+	Sort              enums.AnimeSort
+	Page              int
+	Limit             int
+}
+
+type SeasonChart struct {
+	Year   int    `json:"year"`
+	Season string `json:"season"`
+	PaginatedResponse[entities.Anime]
+}
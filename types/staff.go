@@ -0,0 +1,10 @@
+package types
+
+import "metachan/entities"
+
+// StaffRole lists the people credited with one position on an anime, in the
+// order of its staff page.
+type StaffRole struct {
+	Role   string            `json:"role"`
+	People []entities.Person `json:"people"`
+}
@@ -1,18 +1,48 @@
 package types
 
-import "time"
+import (
+	"context"
+	"metachan/enums"
+	"time"
+)
 
+// Task is a background job run by the TaskManager. Schedule takes a cron
+// expression and, when set, is used instead of Interval. Jitter adds a random
+// delay of up to that duration before every scheduled run. Execute and
+// OnResume are handed a context that is cancelled when the server shuts down.
 type Task struct {
 	Name         string
 	Interval     time.Duration
-	Execute      func() error
-	OnResume     func()
+	Schedule     string
+	Jitter       time.Duration
+	Execute      func(ctx context.Context) error
+	OnResume     func(ctx context.Context)
 	Dependencies []string
 }
 
 type TaskStatus struct {
 	Registered bool
 	Running    bool
+	Paused     bool
+	RunID      string `json:",omitempty"`
+	Schedule   string `json:",omitempty"`
 	LastRun    *time.Time
 	NextRun    *time.Time
+	Progress   *TaskProgress `json:",omitempty"`
+}
+
+// TaskProgress is a live snapshot of a run. Processed includes the items that
+// failed. Percent and ETASeconds stay zero until the task reports its total.
+type TaskProgress struct {
+	Task        string              `json:"task"`
+	RunID       string              `json:"run_id"`
+	Status      enums.TaskRunStatus `json:"status"`
+	Processed   int                 `json:"processed"`
+	Total       int                 `json:"total"`
+	Failed      int                 `json:"failed"`
+	CurrentItem string              `json:"current_item,omitempty"`
+	Percent     float64             `json:"percent"`
+	ETASeconds  int64               `json:"eta_seconds"`
+	StartedAt   time.Time           `json:"started_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
 }
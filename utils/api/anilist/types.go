@@ -1,12 +0,0 @@
-package anilist
-
-import (
-	"net/http"
-	"time"
-)
-
-type client struct {
-	httpClient *http.Client
-	maxRetries int
-	backoff    time.Duration
-}
@@ -0,0 +1,84 @@
+package aniskip
+
+import (
+	"context"
+	"errors"
+	"metachan/enums"
+	"metachan/utils/upstream"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestGetSkipTimesForEpisodesStopsWhenCircuitOpens(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	original := clientInstance
+	defer func() { clientInstance = original }()
+	clientInstance = upstream.New(upstream.Config{
+		Name:    "AniskipTest",
+		BaseURL: server.URL,
+		Retry:   upstream.RetryPolicy{Attempts: 1},
+		Breaker: upstream.NewBreaker(enums.Upstream("aniskip-test")),
+	})
+
+	episodeNumbers := make([]int, 20)
+	for i := range episodeNumbers {
+		episodeNumbers[i] = i + 1
+	}
+
+	results, err := GetSkipTimesForEpisodes(context.Background(), 1, episodeNumbers)
+	if !errors.Is(err, upstream.ErrCircuitOpen) {
+		t.Fatalf("err = %v, want one wrapping upstream.ErrCircuitOpen", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("got skip times for %d episodes, want none", len(results))
+	}
+	// The breaker opens after five failures; the sixth episode finds it
+	// open and nothing after it is asked for.
+	if got := requests.Load(); got != 5 {
+		t.Errorf("Aniskip got %d requests, want 5", got)
+	}
+}
+
+func TestGetSkipTimesForEpisodesKeepsFetchedEpisodes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/skip-times/1/1":
+			w.Write([]byte(`{"found":true,"results":[{"skipType":"op","interval":{"startTime":0,"endTime":90}}]}`))
+		case "/skip-times/1/2":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	original := clientInstance
+	defer func() { clientInstance = original }()
+	clientInstance = upstream.New(upstream.Config{
+		Name:    "AniskipTest",
+		BaseURL: server.URL,
+		Retry:   upstream.RetryPolicy{Attempts: 1},
+	})
+
+	results, err := GetSkipTimesForEpisodes(context.Background(), 1, []int{1, 2, 3})
+	if err == nil {
+		t.Fatal("err = nil, want the failure of episode 3")
+	}
+	if len(results[1]) != 1 || results[1][0].SkipType != "op" {
+		t.Errorf("results[1] = %+v, want one opening", results[1])
+	}
+	if skipTimes, ok := results[2]; !ok || len(skipTimes) != 0 {
+		t.Errorf("results[2] = %+v, %v, want an empty list", skipTimes, ok)
+	}
+	if _, ok := results[3]; ok {
+		t.Error("results[3] is set for an episode that failed")
+	}
+}
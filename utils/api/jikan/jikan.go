@@ -2,162 +2,53 @@ package jikan
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
 	"fmt"
-	"io"
-	"math"
+	"metachan/config"
+	"metachan/enums"
 	"metachan/types"
 	"metachan/utils/logger"
 	"metachan/utils/ratelimit"
-	"net/http"
-	"strconv"
+	"metachan/utils/upstream"
 	"time"
 )
 
 const (
-	jikanAPIBaseURL = "https://api.jikan.moe/v4"
 	rateLimitPerSec = 3
 	rateLimitPerMin = 60
 	contextTimeout  = 60 * time.Second
 	timeout         = 15 * time.Second
 	maxRetries      = 3
 	backoffDuration = 1 * time.Second
+	cacheTTL        = 24 * time.Hour
 )
 
 var (
-	rateLimiter = ratelimit.NewMultiLimiter(
-		ratelimit.NewRateLimiter(rateLimitPerSec, time.Second),
-		ratelimit.NewRateLimiter(rateLimitPerMin, time.Minute),
-	)
-	clientInstance = &client{
-		httpClient: &http.Client{
-			Timeout: timeout,
-		},
-		maxRetries: maxRetries,
-		backoff:    backoffDuration,
-	}
+	clientInstance = upstream.New(upstream.Config{
+		Name:           "JikanClient",
+		BaseURL:        config.Upstream.JikanURL,
+		Timeout:        contextTimeout,
+		AttemptTimeout: timeout,
+		Limiter: ratelimit.NewLimiter("Jikan",
+			ratelimit.Rule{Limit: rateLimitPerSec, Window: time.Second},
+			ratelimit.Rule{Limit: rateLimitPerMin, Window: time.Minute},
+		),
+		Retry:    upstream.RetryPolicy{Attempts: maxRetries, Backoff: backoffDuration},
+		Breaker:  upstream.NewBreaker(enums.UpstreamJikan),
+		CacheTTL: cacheTTL,
+	})
 )
 
-func StopRateLimiters() {
-	rateLimiter.Stop()
-}
-
-func (c *client) getBackOffDuration(attempt int) time.Duration {
-	return time.Duration(float64(c.backoff) * math.Pow(2, float64(attempt-1)))
-}
-
-func (c *client) getRetryAfterDuration(resp *http.Response) time.Duration {
-	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
-		if seconds, err := strconv.Atoi(retryAfter); err == nil {
-			return time.Duration(seconds) * time.Second
-		}
-	}
-	return c.backoff
-}
-
-func (c *client) handleRetry(retries *int, url string, reason string, retryAfter time.Duration) bool {
-	*retries++
-	if *retries >= c.maxRetries {
-		return false
-	}
-
-	backoffDuration := c.getBackOffDuration(*retries)
-	if retryAfter > backoffDuration {
-		backoffDuration = retryAfter
-	}
-
-	logger.Debugf("JikanClient", "%s for %s (attempt %d/%d)", reason, url, *retries, c.maxRetries)
-	time.Sleep(backoffDuration)
-	return true
-}
-
-func (c *client) makeRequest(ctx context.Context, url string) ([]byte, error) {
-	var response *http.Response
-	var retries int
-
-	for retries < c.maxRetries {
-		rateLimiter.Wait()
-
-		request, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-		if err != nil {
-			logger.Errorf("JikanClient", "Failed to create request: %v", err)
-			return nil, errors.New("failed to create request to Jikan API")
-		}
-
-		response, err = c.httpClient.Do(request)
-		if err != nil {
-			if !c.handleRetry(&retries, url, fmt.Sprintf("Request failed: %v", err), 0) {
-				logger.Errorf("JikanClient", "All retries exhausted for request to %s: %v", url, err)
-				return nil, errors.New("failed to make request to Jikan API after max retries")
-			}
-			continue
-		}
-
-		defer response.Body.Close()
-
-		switch response.StatusCode {
-		case http.StatusTooManyRequests:
-			retryAfter := c.getRetryAfterDuration(response)
-			if !c.handleRetry(&retries, url, "Rate limited", retryAfter) {
-				logger.Errorf("JikanClient", "All retries exhausted for request to %s", url)
-				return nil, errors.New("failed to make request to Jikan API after max retries")
-			}
-		case http.StatusOK:
-			bytes, err := io.ReadAll(response.Body)
-
-			if err != nil {
-				logger.Errorf("JikanClient", "Failed to read response body from %s: %v", url, err)
-				return nil, errors.New("failed to read response from Jikan API")
-			}
-
-			return bytes, nil
-		case http.StatusNotFound:
-			logger.Warnf("JikanClient", "Resource not found: %s", url)
-			return nil, errors.New("resource not found")
-		default:
-			if response.StatusCode >= 400 && response.StatusCode < 500 {
-				logger.Warnf("JikanClient", "Client error %d for %s", response.StatusCode, url)
-				return nil, fmt.Errorf("client error: status %d", response.StatusCode)
-			}
-
-			retries++
-			backoffDuration := c.getBackOffDuration(retries)
-
-			logger.Warnf("JikanClient", "Request to %s returned status %d (attempt %d/%d)", url, response.StatusCode, retries, c.maxRetries)
-
-			time.Sleep(backoffDuration)
-		}
-	}
-
-	logger.Errorf("JikanClient", "All retries exhausted for request to %s", url)
-	return nil, errors.New("failed to make request to Jikan API after max retries")
-}
-
-func GetAnimeByMALID(id int) (*types.JikanAnimeResponse, error) {
-	url := fmt.Sprintf("%s/anime/%d/full", jikanAPIBaseURL, id)
-	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
-
-	defer cancel()
-
-	bytes, err := clientInstance.makeRequest(ctx, url)
-	if err != nil {
-		logger.Errorf("JikanClient", "GetAnimeByMALID failed for ID %d: %v", id, err)
-		return nil, errors.New("failed to fetch anime data from Jikan API")
-	}
-
+func GetAnimeByMALID(ctx context.Context, id int) (*types.JikanAnimeResponse, error) {
 	var response types.JikanAnimeResponse
-	if err := json.Unmarshal(bytes, &response); err != nil {
-		logger.Errorf("JikanClient", "Failed to unmarshal response for ID %d: %v", id, err)
-		return nil, errors.New("failed to parse anime data from Jikan API")
+	if err := clientInstance.GetJSON(ctx, fmt.Sprintf("/anime/%d/full", id), &response); err != nil {
+		logger.Errorf("JikanClient", "GetAnimeByMALID failed for ID %d: %v", id, err)
+		return nil, fmt.Errorf("failed to fetch anime data from Jikan API: %w", err)
 	}
 
 	return &response, nil
 }
 
-func GetAnimeEpisodesByMALID(id int) (*types.JikanAnimeEpisodeResponse, error) {
-	url := fmt.Sprintf("%s/anime/%d/episodes", jikanAPIBaseURL, id)
-
+func GetAnimeEpisodesByMALID(ctx context.Context, id int) (*types.JikanAnimeEpisodeResponse, error) {
 	page := 1
 	hasNextPage := true
 
@@ -167,23 +58,10 @@ func GetAnimeEpisodesByMALID(id int) (*types.JikanAnimeEpisodeResponse, error) {
 	}
 
 	for hasNextPage {
-		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
-		pageURL := fmt.Sprintf("%s?page=%d", url, page)
-
-		bytes, err := clientInstance.makeRequest(ctx, pageURL)
-
-		cancel()
-
-		if err != nil {
-			logger.Errorf("JikanClient", "GetAnimeEpisodesByMALID failed for ID %d on page %d: %v", id, page, err)
-			return nil, errors.New("failed to fetch anime episodes from Jikan API")
-		}
-
 		var pageResponse types.JikanAnimeEpisodeResponse
-
-		if err := json.Unmarshal(bytes, &pageResponse); err != nil {
-			logger.Errorf("JikanClient", "Failed to unmarshal episodes response for ID %d on page %d: %v", id, page, err)
-			return nil, errors.New("failed to parse anime episodes from Jikan API")
+		if err := clientInstance.GetJSON(ctx, fmt.Sprintf("/anime/%d/episodes?page=%d", id, page), &pageResponse); err != nil {
+			logger.Errorf("JikanClient", "GetAnimeEpisodesByMALID failed for ID %d on page %d: %v", id, page, err)
+			return nil, fmt.Errorf("failed to fetch anime episodes from Jikan API: %w", err)
 		}
 
 		if response.Pagination.LastVisiblePage == 0 {
@@ -198,71 +76,67 @@ func GetAnimeEpisodesByMALID(id int) (*types.JikanAnimeEpisodeResponse, error) {
 	return response, nil
 }
 
-func GetAnimeCharactersByMALID(id int) (*types.JikanAnimeCharacterResponse, error) {
-	url := fmt.Sprintf("%s/anime/%d/characters", jikanAPIBaseURL, id)
-	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
-
-	defer cancel()
-
-	bytes, err := clientInstance.makeRequest(ctx, url)
-	if err != nil {
-		logger.Errorf("JikanClient", "GetAnimeCharactersByMALID failed for ID %d: %v", id, err)
-		return nil, errors.New("failed to fetch anime characters from Jikan API")
-	}
-
+func GetAnimeCharactersByMALID(ctx context.Context, id int) (*types.JikanAnimeCharacterResponse, error) {
 	var response types.JikanAnimeCharacterResponse
-	if err := json.Unmarshal(bytes, &response); err != nil {
-		logger.Errorf("JikanClient", "Failed to unmarshal characters response for ID %d: %v", id, err)
-		return nil, errors.New("failed to parse anime characters from Jikan API")
+	if err := clientInstance.GetJSON(ctx, fmt.Sprintf("/anime/%d/characters", id), &response); err != nil {
+		logger.Errorf("JikanClient", "GetAnimeCharactersByMALID failed for ID %d: %v", id, err)
+		return nil, fmt.Errorf("failed to fetch anime characters from Jikan API: %w", err)
 	}
 
 	return &response, nil
 }
 
-func GetAnimeGenres() (*types.JikanGenresResponse, error) {
-	url := fmt.Sprintf("%s/genres/anime", jikanAPIBaseURL)
-	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+func GetAnimeStaffByMALID(ctx context.Context, id int) (*types.JikanAnimeStaffResponse, error) {
+	var response types.JikanAnimeStaffResponse
+	if err := clientInstance.GetJSON(ctx, fmt.Sprintf("/anime/%d/staff", id), &response); err != nil {
+		logger.Errorf("JikanClient", "GetAnimeStaffByMALID failed for ID %d: %v", id, err)
+		return nil, fmt.Errorf("failed to fetch anime staff from Jikan API: %w", err)
+	}
 
-	defer cancel()
+	return &response, nil
+}
 
-	bytes, err := clientInstance.makeRequest(ctx, url)
-	if err != nil {
-		logger.Errorf("JikanClient", "GetAnimeGenres failed: %v", err)
-		return nil, errors.New("failed to fetch anime genres from Jikan API")
+// GetAnimeGenres fetches anime genres from Jikan. The filter narrows the list
+// to one of "genres", "explicit_genres", "themes" or "demographics"; an empty
+// filter returns all of them.
+func GetAnimeGenres(ctx context.Context, filter string) (*types.JikanGenresResponse, error) {
+	path := "/genres/anime"
+	if filter != "" {
+		path = fmt.Sprintf("%s?filter=%s", path, filter)
 	}
 
 	var response types.JikanGenresResponse
-	if err := json.Unmarshal(bytes, &response); err != nil {
-		logger.Errorf("JikanClient", "Failed to unmarshal genres response: %v", err)
-		return nil, errors.New("failed to parse anime genres from Jikan API")
+	if err := clientInstance.GetJSON(ctx, path, &response); err != nil {
+		logger.Errorf("JikanClient", "GetAnimeGenres failed: %v", err)
+		return nil, fmt.Errorf("failed to fetch anime genres from Jikan API: %w", err)
 	}
 
 	return &response, nil
 }
 
-func GetAnimeByGenre(genreID int, page int, limit int) (*types.JikanAnimeSearchResponse, error) {
-	url := fmt.Sprintf("%s/anime?genres=%d&page=%d&limit=%d", jikanAPIBaseURL, genreID, page, limit)
-	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+func GetAnimeByGenre(ctx context.Context, genreID int, page int, limit int, orderBy string, sort string) (*types.JikanAnimeSearchResponse, error) {
+	path := fmt.Sprintf("/anime?genres=%d&page=%d&limit=%d&order_by=%s&sort=%s", genreID, page, limit, orderBy, sort)
 
-	defer cancel()
-
-	bytes, err := clientInstance.makeRequest(ctx, url)
-	if err != nil {
+	var response types.JikanAnimeSearchResponse
+	if err := clientInstance.GetJSON(ctx, path, &response); err != nil {
 		logger.Errorf("JikanClient", "GetAnimeByGenre failed for genre %d: %v", genreID, err)
-		return nil, errors.New("failed to fetch anime by genre from Jikan API")
+		return nil, fmt.Errorf("failed to fetch anime by genre from Jikan API: %w", err)
 	}
 
+	return &response, nil
+}
+
+func GetSeasonAnime(ctx context.Context, year int, season string, page int) (*types.JikanAnimeSearchResponse, error) {
 	var response types.JikanAnimeSearchResponse
-	if err := json.Unmarshal(bytes, &response); err != nil {
-		logger.Errorf("JikanClient", "Failed to unmarshal anime by genre response for genre %d: %v", genreID, err)
-		return nil, errors.New("failed to parse anime by genre from Jikan API")
+	if err := clientInstance.GetJSON(ctx, fmt.Sprintf("/seasons/%d/%s?page=%d", year, season, page), &response); err != nil {
+		logger.Errorf("JikanClient", "GetSeasonAnime failed for %s %d: %v", season, year, err)
+		return nil, fmt.Errorf("failed to fetch season anime from Jikan API: %w", err)
 	}
 
 	return &response, nil
 }
 
-func GetAnimeProducers() (*types.JikanProducersResponse, error) {
-	url := fmt.Sprintf("%s/producers", jikanAPIBaseURL)
+func GetAnimeProducers(ctx context.Context) (*types.JikanProducersResponse, error) {
 	page := 1
 	hasNextPage := true
 
@@ -272,20 +146,10 @@ func GetAnimeProducers() (*types.JikanProducersResponse, error) {
 	}
 
 	for hasNextPage {
-		pageURL := fmt.Sprintf("%s?page=%d", url, page)
-
-		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
-		bytes, err := clientInstance.makeRequest(ctx, pageURL)
-		cancel()
-		if err != nil {
-			logger.Errorf("JikanClient", "GetAnimeProducers failed on page %d: %v", page, err)
-			return nil, errors.New("failed to fetch anime producers from Jikan API")
-		}
-
 		var pageResponse types.JikanProducersResponse
-		if err := json.Unmarshal(bytes, &pageResponse); err != nil {
-			logger.Errorf("JikanClient", "Failed to unmarshal producers response on page %d: %v", page, err)
-			return nil, errors.New("failed to parse anime producers from Jikan API")
+		if err := clientInstance.GetJSON(ctx, fmt.Sprintf("/producers?page=%d", page), &pageResponse); err != nil {
+			logger.Errorf("JikanClient", "GetAnimeProducers failed on page %d: %v", page, err)
+			return nil, fmt.Errorf("failed to fetch anime producers from Jikan API: %w", err)
 		}
 
 		if response.Pagination.LastVisiblePage == 0 {
@@ -302,59 +166,32 @@ func GetAnimeProducers() (*types.JikanProducersResponse, error) {
 	return response, nil
 }
 
-func GetProducerByID(producerID int) (*types.JikanSingleProducerResponse, error) {
-	url := fmt.Sprintf("%s/producers/%d/full", jikanAPIBaseURL, producerID)
-	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
-
-	defer cancel()
-	bytes, err := clientInstance.makeRequest(ctx, url)
-	if err != nil {
+func GetProducerByID(ctx context.Context, producerID int) (*types.JikanSingleProducerResponse, error) {
+	var response types.JikanSingleProducerResponse
+	if err := clientInstance.GetJSON(ctx, fmt.Sprintf("/producers/%d/full", producerID), &response); err != nil {
 		logger.Errorf("JikanClient", "GetProducerByID failed for ID %d: %v", producerID, err)
-		return nil, errors.New("failed to fetch producer data from Jikan API")
+		return nil, fmt.Errorf("failed to fetch producer data from Jikan API: %w", err)
 	}
 
-	var response types.JikanSingleProducerResponse
-	if err := json.Unmarshal(bytes, &response); err != nil {
-		logger.Errorf("JikanClient", "Failed to unmarshal producer response for ID %d: %v", producerID, err)
-		return nil, errors.New("failed to parse producer data from Jikan API")
-	}
 	return &response, nil
 }
 
-func GetCharacterByMALID(id int) (*types.JikanCharacterFullResponse, error) {
-	url := fmt.Sprintf("%s/characters/%d/full", jikanAPIBaseURL, id)
-	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
-	defer cancel()
-
-	bytes, err := clientInstance.makeRequest(ctx, url)
-	if err != nil {
+func GetCharacterByMALID(ctx context.Context, id int) (*types.JikanCharacterFullResponse, error) {
+	var response types.JikanCharacterFullResponse
+	if err := clientInstance.GetJSON(ctx, fmt.Sprintf("/characters/%d/full", id), &response); err != nil {
 		logger.Errorf("JikanClient", "GetCharacterByMALID failed for ID %d: %v", id, err)
-		return nil, errors.New("failed to fetch character data from Jikan API")
+		return nil, fmt.Errorf("failed to fetch character data from Jikan API: %w", err)
 	}
 
-	var response types.JikanCharacterFullResponse
-	if err := json.Unmarshal(bytes, &response); err != nil {
-		logger.Errorf("JikanClient", "Failed to unmarshal character response for ID %d: %v", id, err)
-		return nil, errors.New("failed to parse character data from Jikan API")
-	}
 	return &response, nil
 }
 
-func GetPersonByMALID(id int) (*types.JikanPersonFullResponse, error) {
-	url := fmt.Sprintf("%s/people/%d/full", jikanAPIBaseURL, id)
-	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
-	defer cancel()
-
-	bytes, err := clientInstance.makeRequest(ctx, url)
-	if err != nil {
+func GetPersonByMALID(ctx context.Context, id int) (*types.JikanPersonFullResponse, error) {
+	var response types.JikanPersonFullResponse
+	if err := clientInstance.GetJSON(ctx, fmt.Sprintf("/people/%d/full", id), &response); err != nil {
 		logger.Errorf("JikanClient", "GetPersonByMALID failed for ID %d: %v", id, err)
-		return nil, errors.New("failed to fetch person data from Jikan API")
+		return nil, fmt.Errorf("failed to fetch person data from Jikan API: %w", err)
 	}
 
-	var response types.JikanPersonFullResponse
-	if err := json.Unmarshal(bytes, &response); err != nil {
-		logger.Errorf("JikanClient", "Failed to unmarshal person response for ID %d: %v", id, err)
-		return nil, errors.New("failed to parse person data from Jikan API")
-	}
 	return &response, nil
 }
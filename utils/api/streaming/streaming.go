@@ -1,13 +1,16 @@
 package streaming
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"maps"
+	"metachan/config"
+	"metachan/enums"
 	"metachan/types"
 	"metachan/utils/logger"
 	"metachan/utils/mappers"
+	"metachan/utils/upstream"
 	"net/http"
 	"net/url"
 	"sort"
@@ -17,8 +20,6 @@ import (
 )
 
 const (
-	allanimeBaseURL   = "https://api.allanime.day/api"
-	allanimeDay       = "https://allanime.day"
 	allanimeReferer   = "https://allmanga.to"
 	clockPath         = "/apivtwo/clock"
 	clockJSONPath     = "/apivtwo/clock.json"
@@ -50,17 +51,18 @@ const (
 )
 
 var (
-	clientInstance = &client{
-		httpClient: &http.Client{
-			Timeout: timeout,
-		},
-		headers: http.Header{
-			"User-Agent": {userAgent},
-			"Referer":    {allanimeReferer},
-		},
-		maxRetries: maxRetries,
-		backoff:    backoffDuration,
-	}
+	// ErrNoMatch is returned when the search worked but found nothing.
+	ErrNoMatch = errors.New("no streaming match found")
+
+	clientInstance = upstream.New(upstream.Config{
+		Name:           "Streaming",
+		BaseURL:        config.Upstream.AllAnimeURL,
+		AttemptTimeout: timeout,
+		UserAgent:      userAgent,
+		Header:         http.Header{"Referer": {allanimeReferer}},
+		Retry:          upstream.RetryPolicy{Attempts: maxRetries, Backoff: backoffDuration},
+		Breaker:        upstream.NewBreaker(enums.UpstreamStreaming),
+	})
 )
 
 func calculateSimilarity(query, title string) float64 {
@@ -128,32 +130,35 @@ func decodeURL(encodedString string) string {
 func processProviderURL(urlStr string) string {
 	if strings.HasPrefix(urlStr, "/") {
 		urlStr = strings.Replace(urlStr, clockPath, clockJSONPath, 1)
-		return allanimeDay + urlStr
+		return config.Upstream.AllAnimeSiteURL + urlStr
 	}
 
 	return urlStr
 }
 
-func getClockLink(urlStr string) (string, error) {
-	if strings.HasPrefix(urlStr, "/") {
-		urlStr = allanimeDay + urlStr
-	}
+// runQuery runs a GraphQL query against AllAnime, which takes it as URL
+// parameters rather than a POST body.
+func runQuery(ctx context.Context, graphQL string, variables map[string]any) (map[string]any, error) {
+	params := url.Values{}
+	variablesJSON, _ := json.Marshal(variables)
+	params.Set("variables", string(variablesJSON))
+	params.Set("query", graphQL)
 
-	req, err := http.NewRequest("GET", urlStr, nil)
-	if err != nil {
-		return "", err
+	var data map[string]any
+	if err := clientInstance.GetJSON(ctx, "?"+params.Encode(), &data); err != nil {
+		return nil, err
 	}
 
-	maps.Copy(req.Header, clientInstance.headers)
+	return data, nil
+}
 
-	resp, err := clientInstance.httpClient.Do(req)
-	if err != nil {
-		return "", err
+func getClockLink(ctx context.Context, urlStr string) (string, error) {
+	if strings.HasPrefix(urlStr, "/") {
+		urlStr = config.Upstream.AllAnimeSiteURL + urlStr
 	}
-	defer resp.Body.Close()
 
 	var data map[string]any
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+	if err := clientInstance.GetJSON(ctx, urlStr, &data); err != nil {
 		return "", err
 	}
 
@@ -168,7 +173,7 @@ func getClockLink(urlStr string) (string, error) {
 	return "", errors.New("no valid link found")
 }
 
-func processSourceURL(sourceURL, sourceType string) *types.StreamAnimeStreamingSource {
+func processSourceURL(ctx context.Context, sourceURL, sourceType string) *types.StreamAnimeStreamingSource {
 	var decodedURL string
 	if strings.HasPrefix(sourceURL, urlPrefix) {
 		decodedURL = decodeURL(sourceURL)
@@ -179,7 +184,7 @@ func processSourceURL(sourceURL, sourceType string) *types.StreamAnimeStreamingS
 	processedURL := processProviderURL(decodedURL)
 
 	if strings.Contains(processedURL, clockPath) {
-		if directURL, err := getClockLink(processedURL); err == nil {
+		if directURL, err := getClockLink(ctx, processedURL); err == nil {
 			return &types.StreamAnimeStreamingSource{
 				URL:    directURL,
 				Server: getServerName(sourceType),
@@ -219,7 +224,7 @@ func getServerName(sourceType string) string {
 	}
 }
 
-func SearchAnime(query string) ([]types.StreamSearchResult, error) {
+func SearchAnime(ctx context.Context, query string) ([]types.StreamSearchResult, error) {
 	specialID, hasSpecialMapping := mappers.GetSpecialAnimeID(query)
 
 	searchQuery := `
@@ -256,29 +261,11 @@ func SearchAnime(query string) ([]types.StreamSearchResult, error) {
 		"countryOrigin": countryOrigin,
 	}
 
-	params := url.Values{}
-	variablesJSON, _ := json.Marshal(variables)
-	params.Set("variables", string(variablesJSON))
-	params.Set("query", searchQuery)
-
-	req, err := http.NewRequest("GET", allanimeBaseURL+"?"+params.Encode(), nil)
+	data, err := runQuery(ctx, searchQuery, variables)
 	if err != nil {
 		return nil, err
 	}
 
-	maps.Copy(req.Header, clientInstance.headers)
-
-	resp, err := clientInstance.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	var data map[string]any
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return nil, err
-	}
-
 	shows := data["data"].(map[string]any)["shows"].(map[string]any)["edges"].([]any)
 	results := make([]types.StreamSearchResult, 0, len(shows))
 
@@ -307,7 +294,7 @@ func SearchAnime(query string) ([]types.StreamSearchResult, error) {
 	return results, nil
 }
 
-func GetEpisodesList(showID string, mode string) ([]string, error) {
+func GetEpisodesList(ctx context.Context, showID string, mode string) ([]string, error) {
 	episodesQuery := `
 	query ($showId: String!) {
 		show(
@@ -323,29 +310,11 @@ func GetEpisodesList(showID string, mode string) ([]string, error) {
 		"showId": showID,
 	}
 
-	params := url.Values{}
-	variablesJSON, _ := json.Marshal(variables)
-	params.Set("variables", string(variablesJSON))
-	params.Set("query", episodesQuery)
-
-	req, err := http.NewRequest("GET", allanimeBaseURL+"?"+params.Encode(), nil)
+	data, err := runQuery(ctx, episodesQuery, variables)
 	if err != nil {
 		return nil, err
 	}
 
-	maps.Copy(req.Header, clientInstance.headers)
-
-	resp, err := clientInstance.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	var data map[string]any
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return nil, err
-	}
-
 	showData := data["data"].(map[string]any)["show"].(map[string]any)
 	episodesDetail := showData["availableEpisodesDetail"].(map[string]any)
 	episodesList := episodesDetail[mode].([]any)
@@ -371,7 +340,7 @@ func GetEpisodesList(showID string, mode string) ([]string, error) {
 	return result, nil
 }
 
-func GetEpisodeLinks(showID, episode, mode string) ([]types.StreamAnimeStreamingSource, error) {
+func GetEpisodeLinks(ctx context.Context, showID, episode, mode string) ([]types.StreamAnimeStreamingSource, error) {
 	episodeQuery := `
 	query ($showId: String!, $translationType: VaildTranslationTypeEnumType!, $episodeString: String!) {
 		episode(
@@ -391,28 +360,10 @@ func GetEpisodeLinks(showID, episode, mode string) ([]types.StreamAnimeStreaming
 		"episodeString":   episode,
 	}
 
-	params := url.Values{}
-	variablesJSON, _ := json.Marshal(variables)
-	params.Set("variables", string(variablesJSON))
-	params.Set("query", episodeQuery)
-
-	req, err := http.NewRequest("GET", allanimeBaseURL+"?"+params.Encode(), nil)
-	if err != nil {
-		return nil, err
-	}
-
-	maps.Copy(req.Header, clientInstance.headers)
-
-	resp, err := clientInstance.httpClient.Do(req)
+	data, err := runQuery(ctx, episodeQuery, variables)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	var data map[string]any
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return nil, err
-	}
 
 	episodeData := data["data"].(map[string]any)["episode"].(map[string]any)
 	sourceUrls := episodeData["sourceUrls"].([]any)
@@ -422,7 +373,7 @@ func GetEpisodeLinks(showID, episode, mode string) ([]types.StreamAnimeStreaming
 		sourceMap := source.(map[string]any)
 		if sourceURL, ok := sourceMap["sourceUrl"].(string); ok {
 			sourceName := sourceMap["sourceName"].(string)
-			sourceInfo := processSourceURL(sourceURL, sourceName)
+			sourceInfo := processSourceURL(ctx, sourceURL, sourceName)
 
 			if sourceInfo.Type == sourceTypeDirect {
 				if strings.HasSuffix(sourceInfo.URL, patternM3U8) {
@@ -438,10 +389,10 @@ func GetEpisodeLinks(showID, episode, mode string) ([]types.StreamAnimeStreaming
 	return links, nil
 }
 
-func GetStreamingSources(title string, episodeNumber int) (*types.StreamAnimeStreaming, error) {
+func GetStreamingSources(ctx context.Context, title string, episodeNumber int) (*types.StreamAnimeStreaming, error) {
 	logger.Debugf("Streaming", "Fetching streaming sources for '%s' episode %d", title, episodeNumber)
 
-	searchResults, err := SearchAnime(title)
+	searchResults, err := SearchAnime(ctx, title)
 	if err != nil {
 		logger.Errorf("Streaming", "Failed to search anime '%s': %v", title, err)
 		return nil, errors.New("failed to search for anime")
@@ -449,7 +400,7 @@ func GetStreamingSources(title string, episodeNumber int) (*types.StreamAnimeStr
 
 	if len(searchResults) == 0 {
 		logger.Warnf("Streaming", "No streaming sources found for '%s'", title)
-		return nil, errors.New("no streaming sources found")
+		return nil, ErrNoMatch
 	}
 
 	bestMatch := searchResults[0]
@@ -461,7 +412,7 @@ func GetStreamingSources(title string, episodeNumber int) (*types.StreamAnimeStr
 	}
 
 	if bestMatch.SubEpisodes > 0 {
-		episodes, err := GetEpisodesList(bestMatch.ID, "sub")
+		episodes, err := GetEpisodesList(ctx, bestMatch.ID, "sub")
 		if err == nil && len(episodes) > 0 {
 			episodeStr := fmt.Sprintf("%d", episodeNumber)
 			var closestEpisode string
@@ -474,7 +425,7 @@ func GetStreamingSources(title string, episodeNumber int) (*types.StreamAnimeStr
 			}
 
 			if closestEpisode != "" {
-				subSources, err := GetEpisodeLinks(bestMatch.ID, closestEpisode, "sub")
+				subSources, err := GetEpisodeLinks(ctx, bestMatch.ID, closestEpisode, "sub")
 				if err == nil {
 					streaming.Sub = subSources
 					logger.Debugf("Streaming", "Found %d sub sources for episode %d", len(subSources), episodeNumber)
@@ -486,7 +437,7 @@ func GetStreamingSources(title string, episodeNumber int) (*types.StreamAnimeStr
 	}
 
 	if bestMatch.DubEpisodes > 0 {
-		episodes, err := GetEpisodesList(bestMatch.ID, "dub")
+		episodes, err := GetEpisodesList(ctx, bestMatch.ID, "dub")
 		if err == nil && len(episodes) > 0 {
 			episodeStr := fmt.Sprintf("%d", episodeNumber)
 			var closestEpisode string
@@ -499,7 +450,7 @@ func GetStreamingSources(title string, episodeNumber int) (*types.StreamAnimeStr
 			}
 
 			if closestEpisode != "" {
-				dubSources, err := GetEpisodeLinks(bestMatch.ID, closestEpisode, "dub")
+				dubSources, err := GetEpisodeLinks(ctx, bestMatch.ID, closestEpisode, "dub")
 				if err == nil {
 					streaming.Dub = dubSources
 					logger.Debugf("Streaming", "Found %d dub sources for episode %d", len(dubSources), episodeNumber)
@@ -514,10 +465,13 @@ func GetStreamingSources(title string, episodeNumber int) (*types.StreamAnimeStr
 	return streaming, nil
 }
 
-func FetchAllEpisodeSources(title string, episodeNumbers []int) (map[int]*types.StreamAnimeStreaming, error) {
-	searchResults, err := SearchAnime(title)
-	if err != nil || len(searchResults) == 0 {
-		return nil, errors.New("no streaming sources found")
+func FetchAllEpisodeSources(ctx context.Context, title string, episodeNumbers []int) (map[int]*types.StreamAnimeStreaming, error) {
+	searchResults, err := SearchAnime(ctx, title)
+	if err != nil {
+		return nil, errors.New("failed to search for anime")
+	}
+	if len(searchResults) == 0 {
+		return nil, ErrNoMatch
 	}
 
 	bestMatch := searchResults[0]
@@ -526,14 +480,14 @@ func FetchAllEpisodeSources(title string, episodeNumbers []int) (map[int]*types.
 	dubSet := make(map[string]bool)
 
 	if bestMatch.SubEpisodes > 0 {
-		if eps, err := GetEpisodesList(bestMatch.ID, "sub"); err == nil {
+		if eps, err := GetEpisodesList(ctx, bestMatch.ID, "sub"); err == nil {
 			for _, e := range eps {
 				subSet[e] = true
 			}
 		}
 	}
 	if bestMatch.DubEpisodes > 0 {
-		if eps, err := GetEpisodesList(bestMatch.ID, "dub"); err == nil {
+		if eps, err := GetEpisodesList(ctx, bestMatch.ID, "dub"); err == nil {
 			for _, e := range eps {
 				dubSet[e] = true
 			}
@@ -548,12 +502,12 @@ func FetchAllEpisodeSources(title string, episodeNumbers []int) (map[int]*types.
 			Dub: []types.StreamAnimeStreamingSource{},
 		}
 		if subSet[epStr] {
-			if sources, err := GetEpisodeLinks(bestMatch.ID, epStr, "sub"); err == nil {
+			if sources, err := GetEpisodeLinks(ctx, bestMatch.ID, epStr, "sub"); err == nil {
 				s.Sub = sources
 			}
 		}
 		if dubSet[epStr] {
-			if sources, err := GetEpisodeLinks(bestMatch.ID, epStr, "dub"); err == nil {
+			if sources, err := GetEpisodeLinks(ctx, bestMatch.ID, epStr, "dub"); err == nil {
 				s.Dub = sources
 			}
 		}
@@ -564,8 +518,8 @@ func FetchAllEpisodeSources(title string, episodeNumbers []int) (map[int]*types.
 	return result, nil
 }
 
-func GetStreamingCounts(title string) (int, int, error) {
-	searchResults, err := SearchAnime(title)
+func GetStreamingCounts(ctx context.Context, title string) (int, int, error) {
+	searchResults, err := SearchAnime(ctx, title)
 	if err != nil {
 		logger.Errorf("Streaming", "Failed to search anime '%s': %v", title, err)
 		return 0, 0, errors.New("failed to search for anime")
@@ -573,7 +527,7 @@ func GetStreamingCounts(title string) (int, int, error) {
 
 	if len(searchResults) == 0 {
 		logger.Warnf("Streaming", "No results found for '%s'", title)
-		return 0, 0, errors.New("no results found")
+		return 0, 0, ErrNoMatch
 	}
 
 	bestMatch := searchResults[0]
@@ -1,22 +1,24 @@
 package tmdb
 
 import (
+	"context"
 	"crypto/md5"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
 	"metachan/config"
 	"metachan/entities"
+	"metachan/enums"
 	"metachan/types"
 	"metachan/utils/logger"
+	"metachan/utils/upstream"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 )
 
 const (
-	tmdbAPIBaseURL          = "https://api.themoviedb.org/3"
 	tmdbImageBaseURL        = "https://image.tmdb.org/t/p/"
 	searchTVEndpoint        = "/search/tv"
 	searchMovieEndpoint     = "/search/movie"
@@ -25,12 +27,12 @@ const (
 	movieDetailsEndpoint    = "/movie/%d"
 	timeout                 = 5 * time.Second
 	rateLimitWait           = 5 * time.Second
-	maxRetries              = 10
+	maxRetries              = 5
+	backoffDuration         = 500 * time.Millisecond
 	maxEnrichmentDuration   = 10 * time.Second
 	thumbnailSize           = "w300"
 	backdropSize            = "w780"
 	acceptHeader            = "application/json"
-	connectionResetError    = "connection reset"
 	noDescription           = "No description available"
 	tvAnimation             = "TV Animation"
 	seasonSuffix            = ": Season"
@@ -39,43 +41,42 @@ const (
 	courWord                = "Cour"
 	countryPriorityJP       = "JP"
 	episodeCountFlexibility = 2
+	cacheTTL                = 7 * 24 * time.Hour
 )
 
 var (
-	clientInstance = &client{
-		httpClient: &http.Client{
-			Timeout: timeout,
+	// ErrNotConfigured is returned when no TMDB token has been set.
+	ErrNotConfigured = errors.New("TMDB is not configured")
+	// ErrNoMatch is returned when TMDB answered but nothing matched the anime.
+	ErrNoMatch = errors.New("no matching TMDB entry")
+
+	clientInstance = upstream.New(upstream.Config{
+		Name:           "TMDB",
+		BaseURL:        config.Upstream.TMDBURL,
+		AttemptTimeout: timeout,
+		Header:         http.Header{"Accept": {acceptHeader}},
+		Retry: upstream.RetryPolicy{
+			Attempts:   maxRetries,
+			Backoff:    backoffDuration,
+			RetryAfter: rateLimitWait,
 		},
-	}
+		Breaker:  upstream.NewBreaker(enums.UpstreamTMDB),
+		CacheTTL: cacheTTL,
+	})
 )
 
-func makeRequest(req *http.Request) (*http.Response, error) {
-	var lastErr error
-	var resp *http.Response
-
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		resp, lastErr = clientInstance.httpClient.Do(req)
-
-		if lastErr == nil {
-			if resp.StatusCode == http.StatusTooManyRequests {
-				resp.Body.Close()
-				logger.Warnf("TMDB", "TMDB rate limited (attempt %d/%d): waiting %d seconds", attempt+1, maxRetries, int(rateLimitWait.Seconds()))
-				time.Sleep(rateLimitWait)
-				continue
-			}
-			return resp, nil
-		}
-
-		if strings.Contains(lastErr.Error(), connectionResetError) {
-			logger.Debugf("TMDB", "TMDB connection reset (attempt %d/%d): retrying immediately", attempt+1, maxRetries)
-			continue
-		}
-
-		logger.Debugf("TMDB", "TMDB request error (attempt %d/%d): %v", attempt+1, maxRetries, lastErr)
+// get fetches a TMDB endpoint. The read token is only known once the config
+// has been loaded, so it is added per request.
+func get(ctx context.Context, path string, v any) error {
+	if config.API.TMDBReadToken == "" {
+		logger.Errorf("TMDB", "TMDB is not initialized")
+		return ErrNotConfigured
 	}
 
-	logger.Errorf("TMDB", "Failed after %d retry attempts: %v", maxRetries, lastErr)
-	return nil, errors.New("failed after max retry attempts")
+	return clientInstance.Fetch(ctx, upstream.Request{
+		Path:   path,
+		Header: http.Header{"Authorization": {"Bearer " + config.API.TMDBReadToken}},
+	}, v)
 }
 
 func normalizeTitle(title string) string {
@@ -109,12 +110,7 @@ func normalizeTitle(title string) string {
 	return strings.TrimSpace(normalized)
 }
 
-func searchTVShowsByTitle(title string, alternativeTitle string, isAdult bool, countryPriority string) ([]types.TMDBShowResult, error) {
-	if config.API.TMDBReadToken == "" {
-		logger.Errorf("TMDB", "TMDB is not initialized")
-		return nil, errors.New("TMDB is not initialized")
-	}
-
+func searchTVShowsByTitle(ctx context.Context, title string, alternativeTitle string, isAdult bool, countryPriority string) ([]types.TMDBShowResult, error) {
 	query := normalizeTitle(title)
 	if query == "" && alternativeTitle != "" {
 		query = normalizeTitle(alternativeTitle)
@@ -122,37 +118,13 @@ func searchTVShowsByTitle(title string, alternativeTitle string, isAdult bool, c
 
 	logger.Debugf("TMDB", "Searching TMDB for TV show: %s", query)
 
-	apiURL := tmdbAPIBaseURL + searchTVEndpoint
-	req, err := http.NewRequest("GET", apiURL, nil)
-	if err != nil {
-		logger.Errorf("TMDB", "Failed to create request: %v", err)
-		return nil, errors.New("failed to create request")
-	}
+	path := searchTVEndpoint + "?query=" + url.QueryEscape(query)
 
-	q := req.URL.Query()
-	q.Add("query", query)
-	req.URL.RawQuery = q.Encode()
-
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", config.API.TMDBReadToken))
-	req.Header.Add("Accept", acceptHeader)
-
-	resp, err := makeRequest(req)
-	if err != nil {
+	var searchResponse types.TMDBSearchResponse
+	if err := get(ctx, path, &searchResponse); err != nil {
 		logger.Errorf("TMDB", "Failed to search TV shows: %v", err)
 		return nil, errors.New("failed to search TV shows")
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		logger.Errorf("TMDB", "Failed to search TV shows: %s", resp.Status)
-		return nil, errors.New("failed to search TV shows")
-	}
-
-	var searchResponse types.TMDBSearchResponse
-	if err := json.NewDecoder(resp.Body).Decode(&searchResponse); err != nil {
-		logger.Errorf("TMDB", "Failed to decode response: %v", err)
-		return nil, errors.New("failed to decode response")
-	}
 
 	var filteredResults []types.TMDBShowResult
 	for _, show := range searchResponse.Results {
@@ -193,83 +165,33 @@ func searchTVShowsByTitle(title string, alternativeTitle string, isAdult bool, c
 	return filteredResults, nil
 }
 
-func getTVShowDetails(showID int) (*types.TMDBShowDetails, error) {
-	if config.API.TMDBReadToken == "" {
-		logger.Errorf("TMDB", "TMDB is not initialized")
-		return nil, errors.New("TMDB is not initialized")
-	}
-
-	apiURL := fmt.Sprintf(tmdbAPIBaseURL+tvDetailsEndpoint, showID)
-	req, err := http.NewRequest("GET", apiURL, nil)
-	if err != nil {
-		logger.Errorf("TMDB", "Failed to create request: %v", err)
-		return nil, errors.New("failed to create request")
-	}
-
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", config.API.TMDBReadToken))
-	req.Header.Add("Accept", acceptHeader)
+func getTVShowDetails(ctx context.Context, showID int) (*types.TMDBShowDetails, error) {
+	path := fmt.Sprintf(tvDetailsEndpoint, showID)
 
-	resp, err := makeRequest(req)
-	if err != nil {
+	details := &types.TMDBShowDetails{}
+	if err := get(ctx, path, details); err != nil {
 		logger.Errorf("TMDB", "Failed to get TV show details: %v", err)
 		return nil, errors.New("failed to get TV show details")
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		logger.Errorf("TMDB", "Failed to get TV show details: %s", resp.Status)
-		return nil, errors.New("failed to get TV show details")
-	}
-
-	details := &types.TMDBShowDetails{}
-	if err := json.NewDecoder(resp.Body).Decode(details); err != nil {
-		logger.Errorf("TMDB", "Failed to decode response: %v", err)
-		return nil, errors.New("failed to decode response")
-	}
 
 	return details, nil
 }
 
-func getSeasonDetails(showID, seasonNumber int) (*types.TMDBSeasonDetails, error) {
-	if config.API.TMDBReadToken == "" {
-		logger.Errorf("TMDB", "TMDB is not initialized")
-		return nil, errors.New("TMDB is not initialized")
-	}
-
-	apiURL := fmt.Sprintf(tmdbAPIBaseURL+seasonDetailsEndpoint, showID, seasonNumber)
-	req, err := http.NewRequest("GET", apiURL, nil)
-	if err != nil {
-		logger.Errorf("TMDB", "Failed to create request: %v", err)
-		return nil, errors.New("failed to create request")
-	}
-
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", config.API.TMDBReadToken))
-	req.Header.Add("Accept", acceptHeader)
+func getSeasonDetails(ctx context.Context, showID, seasonNumber int) (*types.TMDBSeasonDetails, error) {
+	path := fmt.Sprintf(seasonDetailsEndpoint, showID, seasonNumber)
 
-	resp, err := makeRequest(req)
-	if err != nil {
+	details := &types.TMDBSeasonDetails{}
+	if err := get(ctx, path, details); err != nil {
 		logger.Errorf("TMDB", "Failed to get season details: %v", err)
 		return nil, errors.New("failed to get season details")
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		logger.Errorf("TMDB", "Failed to get season details: %s", resp.Status)
-		return nil, errors.New("failed to get season details")
-	}
-
-	details := &types.TMDBSeasonDetails{}
-	if err := json.NewDecoder(resp.Body).Decode(details); err != nil {
-		logger.Errorf("TMDB", "Failed to decode response: %v", err)
-		return nil, errors.New("failed to decode response")
-	}
 
 	return details, nil
 }
 
-func findBestSeason(shows []types.TMDBShowResult, title string, episodeCount int, airDate string) (int, int, error) {
+func findBestSeason(ctx context.Context, shows []types.TMDBShowResult, title string, episodeCount int, airDate string) (int, int, error) {
 	for _, show := range shows {
-		showDetails, err := getTVShowDetails(show.ID)
+		showDetails, err := getTVShowDetails(ctx, show.ID)
 		if err != nil {
 			logger.Warnf("TMDB", "Failed to get details for show %d: %v", show.ID, err)
 			continue
@@ -299,13 +221,13 @@ func findBestSeason(shows []types.TMDBShowResult, title string, episodeCount int
 	}
 
 	logger.Warnf("TMDB", "Could not find matching season for: %s", title)
-	return 0, 0, errors.New("could not find matching season")
+	return 0, 0, ErrNoMatch
 }
 
-func AttachEpisodeDescriptions(anime *entities.Anime) error {
+func AttachEpisodeDescriptions(ctx context.Context, anime *entities.Anime) error {
 	if config.API.TMDBReadToken == "" {
 		logger.Warnf("TMDB", "TMDB is not configured, skipping episode description enrichment")
-		return errors.New("TMDB is not configured")
+		return ErrNotConfigured
 	}
 
 	if anime == nil || len(anime.Episodes) == 0 {
@@ -342,7 +264,7 @@ func AttachEpisodeDescriptions(anime *entities.Anime) error {
 			return errors.New("TMDB enrichment timed out")
 		}
 
-		showDetails, err := getTVShowDetails(showID)
+		showDetails, err := getTVShowDetails(ctx, showID)
 		if err != nil {
 			logger.Warnf("TMDB", "Failed to get TMDB show details for ID %d: %v", tmdbID, err)
 			return errors.New("failed to get TMDB show details")
@@ -383,7 +305,7 @@ func AttachEpisodeDescriptions(anime *entities.Anime) error {
 			return errors.New("TMDB enrichment timed out")
 		}
 
-		shows, err := searchTVShowsByTitle(title, alternativeTitle, false, countryPriorityJP)
+		shows, err := searchTVShowsByTitle(ctx, title, alternativeTitle, false, countryPriorityJP)
 		if err != nil {
 			logger.Warnf("TMDB", "Failed to search TV shows: %v", err)
 			return errors.New("failed to search TMDB shows")
@@ -391,7 +313,7 @@ func AttachEpisodeDescriptions(anime *entities.Anime) error {
 
 		if len(shows) == 0 {
 			logger.Warnf("TMDB", "No TV shows found for: %s", title)
-			return errors.New("no TMDB shows found")
+			return ErrNoMatch
 		}
 
 		airDate := ""
@@ -404,10 +326,10 @@ func AttachEpisodeDescriptions(anime *entities.Anime) error {
 			return errors.New("TMDB enrichment timed out")
 		}
 
-		showID, seasonNumber, err = findBestSeason(shows, title, len(episodes), airDate)
+		showID, seasonNumber, err = findBestSeason(ctx, shows, title, len(episodes), airDate)
 		if err != nil {
 			logger.Warnf("TMDB", "Failed to find best season: %v", err)
-			return errors.New("failed to find best season")
+			return fmt.Errorf("failed to find best season: %w", err)
 		}
 	}
 
@@ -416,7 +338,7 @@ func AttachEpisodeDescriptions(anime *entities.Anime) error {
 		return errors.New("TMDB enrichment timed out")
 	}
 
-	seasonDetails, err := getSeasonDetails(showID, seasonNumber)
+	seasonDetails, err := getSeasonDetails(ctx, showID, seasonNumber)
 	if err != nil {
 		logger.Warnf("TMDB", "Failed to get season details: %v", err)
 		return errors.New("failed to get season details")
@@ -465,12 +387,7 @@ func AttachEpisodeDescriptions(anime *entities.Anime) error {
 	return nil
 }
 
-func searchMoviesByTitle(title string, alternativeTitle string) ([]types.TMDBMovieResult, error) {
-	if config.API.TMDBReadToken == "" {
-		logger.Errorf("TMDB", "TMDB is not initialized")
-		return nil, errors.New("TMDB is not initialized")
-	}
-
+func searchMoviesByTitle(ctx context.Context, title string, alternativeTitle string) ([]types.TMDBMovieResult, error) {
 	query := normalizeTitle(title)
 	if query == "" && alternativeTitle != "" {
 		query = normalizeTitle(alternativeTitle)
@@ -478,81 +395,32 @@ func searchMoviesByTitle(title string, alternativeTitle string) ([]types.TMDBMov
 
 	logger.Debugf("TMDB", "Searching TMDB for movie: %s", query)
 
-	apiURL := tmdbAPIBaseURL + searchMovieEndpoint
-	req, err := http.NewRequest("GET", apiURL, nil)
-	if err != nil {
-		logger.Errorf("TMDB", "Failed to create request: %v", err)
-		return nil, errors.New("failed to create request")
-	}
-
-	q := req.URL.Query()
-	q.Add("query", query)
-	req.URL.RawQuery = q.Encode()
+	path := searchMovieEndpoint + "?query=" + url.QueryEscape(query)
 
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", config.API.TMDBReadToken))
-	req.Header.Add("Accept", acceptHeader)
-
-	resp, err := makeRequest(req)
-	if err != nil {
+	var searchResp types.TMDBMovieSearchResponse
+	if err := get(ctx, path, &searchResp); err != nil {
 		logger.Errorf("TMDB", "Failed to search movies: %v", err)
 		return nil, errors.New("failed to search movies")
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		logger.Errorf("TMDB", "Search failed with status: %d", resp.StatusCode)
-		return nil, errors.New("search failed")
-	}
-
-	var searchResp types.TMDBMovieSearchResponse
-	if err := json.NewDecoder(resp.Body).Decode(&searchResp); err != nil {
-		logger.Errorf("TMDB", "Failed to decode response: %v", err)
-		return nil, errors.New("failed to decode response")
-	}
 
 	logger.Debugf("TMDB", "Found %d movie results for: %s", len(searchResp.Results), query)
 
 	return searchResp.Results, nil
 }
 
-func getMovieDetails(movieID int) (*types.TMDBMovieDetails, error) {
-	if config.API.TMDBReadToken == "" {
-		logger.Errorf("TMDB", "TMDB is not initialized")
-		return nil, errors.New("TMDB is not initialized")
-	}
-
-	apiURL := fmt.Sprintf(tmdbAPIBaseURL+movieDetailsEndpoint, movieID)
-	req, err := http.NewRequest("GET", apiURL, nil)
-	if err != nil {
-		logger.Errorf("TMDB", "Failed to create request: %v", err)
-		return nil, errors.New("failed to create request")
-	}
-
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", config.API.TMDBReadToken))
-	req.Header.Add("Accept", acceptHeader)
+func getMovieDetails(ctx context.Context, movieID int) (*types.TMDBMovieDetails, error) {
+	path := fmt.Sprintf(movieDetailsEndpoint, movieID)
 
-	resp, err := makeRequest(req)
-	if err != nil {
+	var movieDetails types.TMDBMovieDetails
+	if err := get(ctx, path, &movieDetails); err != nil {
 		logger.Errorf("TMDB", "Failed to fetch movie details: %v", err)
 		return nil, errors.New("failed to fetch movie details")
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		logger.Errorf("TMDB", "Request failed with status: %d", resp.StatusCode)
-		return nil, errors.New("request failed")
-	}
-
-	var movieDetails types.TMDBMovieDetails
-	if err := json.NewDecoder(resp.Body).Decode(&movieDetails); err != nil {
-		logger.Errorf("TMDB", "Failed to decode response: %v", err)
-		return nil, errors.New("failed to decode response")
-	}
 
 	return &movieDetails, nil
 }
 
-func EnrichEpisodeFromMovie(anime *entities.Anime) error {
+func EnrichEpisodeFromMovie(ctx context.Context, anime *entities.Anime) error {
 	if anime == nil || len(anime.Episodes) == 0 {
 		return nil
 	}
@@ -580,17 +448,21 @@ func EnrichEpisodeFromMovie(anime *entities.Anime) error {
 		movieID = tmdbID
 		logger.Debugf("TMDB", "Using provided TMDB movie ID: %d", movieID)
 	} else {
-		movies, err := searchMoviesByTitle(title, alternativeTitle)
-		if err != nil || len(movies) == 0 {
+		movies, err := searchMoviesByTitle(ctx, title, alternativeTitle)
+		if err != nil {
 			logger.Warnf("TMDB", "Failed to find movie on TMDB: %v", err)
-			return errors.New("movie not found on TMDB")
+			return err
+		}
+		if len(movies) == 0 {
+			logger.Warnf("TMDB", "No movies found for: %s", title)
+			return ErrNoMatch
 		}
 
 		movieID = movies[0].ID
 		logger.Debugf("TMDB", "Found TMDB movie ID: %d for title: %s", movieID, title)
 	}
 
-	movieDetails, err := getMovieDetails(movieID)
+	movieDetails, err := getMovieDetails(ctx, movieID)
 	if err != nil {
 		logger.Warnf("TMDB", "Failed to fetch movie details: %v", err)
 		return err
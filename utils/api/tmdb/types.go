@@ -1,9 +0,0 @@
-package tmdb
-
-import (
-	"net/http"
-)
-
-type client struct {
-	httpClient *http.Client
-}
@@ -1,83 +1,78 @@
 package tvdb
 
 import (
-	"bytes"
+	"context"
 	"crypto/md5"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"metachan/config"
 	"metachan/entities"
+	"metachan/enums"
 	"metachan/types"
 	"metachan/utils/logger"
+	"metachan/utils/upstream"
 	"net/http"
 	"time"
 )
 
 const (
-	tvdbAPIBaseURL    = "https://api4.thetvdb.com/v4"
 	tvdbLoginEndpoint = "/login"
 	tvdbImageBaseURL  = "https://artworks.thetvdb.com"
-	timeout           = 10 * time.Second
-	episodesTimeout   = 15 * time.Second
+	timeout           = 15 * time.Second
 	tokenExpiry       = 24 * time.Hour
-	contentType       = "application/json"
 	acceptHeader      = "application/json"
 	noDescription     = "No description available"
 	recapType         = "recap"
+	cacheTTL          = 24 * time.Hour
 )
 
 var (
+	// ErrNotConfigured is returned when no TVDB API key has been set.
+	ErrNotConfigured = errors.New("TVDB is not configured")
+	// ErrNotFound is returned when TVDB has no series under the ID.
+	ErrNotFound = errors.New("no such TVDB series")
+
 	clientInstance = &client{
-		httpClient: &http.Client{
-			Timeout: timeout,
-		},
+		api: upstream.New(upstream.Config{
+			Name:           "TVDB",
+			BaseURL:        config.Upstream.TVDBURL,
+			AttemptTimeout: timeout,
+			Header:         http.Header{"Accept": {acceptHeader}},
+			Breaker:        upstream.NewBreaker(enums.UpstreamTVDB),
+			CacheTTL:       cacheTTL,
+		}),
 	}
 )
 
-func authenticate() (string, error) {
+func authenticate(ctx context.Context) (string, error) {
 	if clientInstance.token != "" && time.Now().Before(clientInstance.tokenExpiry) {
 		return clientInstance.token, nil
 	}
 
 	if config.API.TVDBKey == "" {
 		logger.Errorf("TVDB", "TVDB API key is not set")
-		return "", errors.New("TVDB API key is not set")
+		return "", ErrNotConfigured
 	}
 
 	logger.Debugf("TVDB", "Authenticating with TVDB API")
 
-	authBody := map[string]string{"apikey": config.API.TVDBKey}
-	jsonBody, err := json.Marshal(authBody)
-	if err != nil {
-		logger.Errorf("TVDB", "Failed to marshal auth body: %v", err)
-		return "", errors.New("failed to marshal auth body")
-	}
-
-	req, err := http.NewRequest("POST", tvdbAPIBaseURL+tvdbLoginEndpoint, bytes.NewBuffer(jsonBody))
+	authBody, err := json.Marshal(map[string]string{"apikey": config.API.TVDBKey})
 	if err != nil {
-		logger.Errorf("TVDB", "Failed to create auth request: %v", err)
-		return "", errors.New("failed to create auth request")
-	}
-
-	req.Header.Add("Content-Type", contentType)
-
-	resp, err := clientInstance.httpClient.Do(req)
-	if err != nil {
-		logger.Errorf("TVDB", "Failed to authenticate: %v", err)
-		return "", errors.New("failed to authenticate")
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		logger.Errorf("TVDB", "Authentication failed with status: %d", resp.StatusCode)
-		return "", errors.New("authentication failed")
+		return "", fmt.Errorf("failed to encode login body: %w", err)
 	}
 
+	// The login is never cached, a stored token may already have expired.
 	var authResp types.TVDBAuthResponse
-	if err := json.NewDecoder(resp.Body).Decode(&authResp); err != nil {
-		logger.Errorf("TVDB", "Failed to decode auth response: %v", err)
-		return "", errors.New("failed to decode auth response")
+	if err := clientInstance.api.Fetch(ctx, upstream.Request{
+		Method:  http.MethodPost,
+		Path:    tvdbLoginEndpoint,
+		Header:  http.Header{"Content-Type": {"application/json"}},
+		Body:    authBody,
+		NoCache: true,
+	}, &authResp); err != nil {
+		logger.Errorf("TVDB", "Failed to authenticate: %v", err)
+		return "", fmt.Errorf("failed to authenticate: %w", err)
 	}
 
 	if authResp.Data.Token == "" {
@@ -93,44 +88,25 @@ func authenticate() (string, error) {
 	return clientInstance.token, nil
 }
 
-func GetSeriesEpisodes(tvdbID int) ([]types.TVDBEpisode, error) {
-	token, err := authenticate()
+func GetSeriesEpisodes(ctx context.Context, tvdbID int) ([]types.TVDBEpisode, error) {
+	token, err := authenticate(ctx)
 	if err != nil {
 		logger.Errorf("TVDB", "Failed to authenticate with TVDB for series %d: %v", tvdbID, err)
-		return nil, errors.New("failed to authenticate with TVDB")
+		return nil, fmt.Errorf("failed to authenticate with TVDB: %w", err)
 	}
 
 	logger.Debugf("TVDB", "Fetching episodes for TVDB series %d", tvdbID)
 
-	tempClient := &http.Client{Timeout: episodesTimeout}
-
-	url := fmt.Sprintf("%s/series/%d/episodes/default", tvdbAPIBaseURL, tvdbID)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		logger.Errorf("TVDB", "Failed to create request for series %d: %v", tvdbID, err)
-		return nil, errors.New("failed to create request")
-	}
-
-	req.Header.Add("Authorization", "Bearer "+token)
-	req.Header.Add("Accept", acceptHeader)
-
-	resp, err := tempClient.Do(req)
-	if err != nil {
-		logger.Errorf("TVDB", "Failed to fetch episodes for series %d: %v", tvdbID, err)
-		return nil, errors.New("failed to fetch episodes")
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		logger.Errorf("TVDB", "Failed to fetch episodes with status: %d", resp.StatusCode)
-		return nil, errors.New("failed to fetch episodes")
-	}
-
 	var episodesResp types.TVDBEpisodesResponse
-	if err := json.NewDecoder(resp.Body).Decode(&episodesResp); err != nil {
-		logger.Errorf("TVDB", "Failed to decode episodes response for series %d: %v", tvdbID, err)
-		return nil, errors.New("failed to decode episodes response")
+	if err := clientInstance.api.Fetch(ctx, upstream.Request{
+		Path:   fmt.Sprintf("/series/%d/episodes/default", tvdbID),
+		Header: http.Header{"Authorization": {"Bearer " + token}},
+	}, &episodesResp); err != nil {
+		if errors.Is(err, upstream.ErrNotFound) {
+			return nil, fmt.Errorf("%w: %d", ErrNotFound, tvdbID)
+		}
+		logger.Errorf("TVDB", "Failed to fetch episodes for series %d: %v", tvdbID, err)
+		return nil, fmt.Errorf("failed to fetch episodes: %w", err)
 	}
 
 	logger.Successf("TVDB", "Successfully fetched %d episodes from TVDB for series %d", len(episodesResp.Data.Episodes), tvdbID)
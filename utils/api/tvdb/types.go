@@ -1,12 +1,12 @@
 package tvdb
 
 import (
-	"net/http"
+	"metachan/utils/upstream"
 	"time"
 )
 
 type client struct {
-	httpClient  *http.Client
+	api         *upstream.Client
 	token       string
 	tokenExpiry time.Time
 }
@@ -0,0 +1,95 @@
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// redacted replaces every credential kept in a cassette.
+const redacted = "REDACTED"
+
+// credentialFields are the JSON fields and query parameters that hold keys or
+// the tokens handed out for them, such as the TVDB login and its response.
+var credentialFields = map[string]bool{
+	"apikey":        true,
+	"api_key":       true,
+	"pin":           true,
+	"password":      true,
+	"client_secret": true,
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+}
+
+func isCredential(name string) bool {
+	return credentialFields[strings.ToLower(name)]
+}
+
+// RedactBody replaces the credentials in a JSON body. A body holding none,
+// or one that is not JSON, is returned as is; otherwise it is re-encoded, so
+// that the same body redacts to the same bytes whatever key it was sent with.
+func RedactBody(body []byte) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return body
+	}
+	if !redactValue(value) {
+		return body
+	}
+
+	redactedBody, err := json.Marshal(value)
+	if err != nil {
+		return body
+	}
+	return redactedBody
+}
+
+func redactValue(value any) bool {
+	found := false
+	switch value := value.(type) {
+	case map[string]any:
+		for name, field := range value {
+			if isCredential(name) {
+				value[name] = redacted
+				found = true
+			} else if redactValue(field) {
+				found = true
+			}
+		}
+	case []any:
+		for _, item := range value {
+			if redactValue(item) {
+				found = true
+			}
+		}
+	}
+	return found
+}
+
+// RedactURL replaces the credentials passed as query parameters.
+func RedactURL(target string) string {
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return target
+	}
+
+	query := parsed.Query()
+	found := false
+	for name := range query {
+		if isCredential(name) {
+			query.Set(name, redacted)
+			found = true
+		}
+	}
+	if !found {
+		return target
+	}
+
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
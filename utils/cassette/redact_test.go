@@ -0,0 +1,123 @@
+package cassette
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRedactURL(t *testing.T) {
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{
+			"https://api.themoviedb.org/3/search/tv?api_key=secret&query=naruto",
+			"https://api.themoviedb.org/3/search/tv?api_key=REDACTED&query=naruto",
+		},
+		{
+			"https://example.com/login?ApiKey=secret&Token=abc",
+			"https://example.com/login?ApiKey=REDACTED&Token=REDACTED",
+		},
+		{
+			"https://api.jikan.moe/v4/anime/1/full?page=2",
+			"https://api.jikan.moe/v4/anime/1/full?page=2",
+		},
+	}
+
+	for _, test := range tests {
+		if got := RedactURL(test.target); got != test.want {
+			t.Errorf("RedactURL(%q) = %q, want %q", test.target, got, test.want)
+		}
+	}
+}
+
+func TestRedactBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "top-level key",
+			body: `{"apikey":"secret","pin":"1234"}`,
+			want: `{"apikey":"REDACTED","pin":"REDACTED"}`,
+		},
+		{
+			name: "nested object and array",
+			body: `{"auth":{"client_secret":"s","user":"u"},"sessions":[{"refresh_token":"r","id":7}]}`,
+			want: `{"auth":{"client_secret":"REDACTED","user":"u"},"sessions":[{"id":7,"refresh_token":"REDACTED"}]}`,
+		},
+		{
+			name: "large numbers kept exact",
+			body: `{"password":"p","id":12345678901234567890}`,
+			want: `{"id":12345678901234567890,"password":"REDACTED"}`,
+		},
+		{
+			name: "no credentials left untouched",
+			body: `{ "query": "naruto",  "page": 1 }`,
+			want: `{ "query": "naruto",  "page": 1 }`,
+		},
+		{
+			name: "not JSON left untouched",
+			body: `<html>token</html>`,
+			want: `<html>token</html>`,
+		},
+	}
+
+	for _, test := range tests {
+		if got := string(RedactBody([]byte(test.body))); got != test.want {
+			t.Errorf("%s: RedactBody = %s, want %s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestPathIgnoresCredentials(t *testing.T) {
+	login := func(key string) string {
+		return Path("cassettes", http.MethodPost, "https://api4.thetvdb.com/v4/login", []byte(`{"apikey":"`+key+`"}`))
+	}
+	search := func(key string) string {
+		return Path("cassettes", http.MethodGet, "https://api.themoviedb.org/3/search/tv?query=naruto&api_key="+key, nil)
+	}
+
+	if login("first") != login("second") {
+		t.Error("TVDB login cassettes differ between API keys")
+	}
+	if search("first") != search("second") {
+		t.Error("TMDB search cassettes differ between API keys")
+	}
+	if login("first") == search("first") {
+		t.Error("different requests share a cassette")
+	}
+	if other := Path("cassettes", http.MethodGet, "https://api.themoviedb.org/3/search/tv?query=bleach&api_key=first", nil); other == search("first") {
+		t.Error("requests differing outside their credentials share a cassette")
+	}
+	if !strings.HasPrefix(search("first"), "cassettes/api.themoviedb.org/") {
+		t.Errorf("Path = %s, want it under the upstream host", search("first"))
+	}
+}
+
+func TestNewRedactsTVDBLogin(t *testing.T) {
+	header := http.Header{
+		"Content-Type": {"application/json"},
+		"Set-Cookie":   {"session=abc"},
+	}
+	cassette := New(http.MethodPost, "https://api4.thetvdb.com/v4/login",
+		[]byte(`{"apikey":"secret-key"}`), http.StatusOK, header,
+		[]byte(`{"status":"success","data":{"token":"eyJhbGciOiJIUzI1NiJ9.secret"}}`))
+
+	for _, part := range []string{cassette.Request.URL, cassette.Request.Body, cassette.Response.Body} {
+		if strings.Contains(part, "secret") {
+			t.Errorf("cassette keeps a credential: %s", part)
+		}
+	}
+	if want := `{"data":{"token":"REDACTED"},"status":"success"}`; cassette.Response.Body != want {
+		t.Errorf("response body = %s, want %s", cassette.Response.Body, want)
+	}
+	if cassette.Response.Header.Get("Set-Cookie") != "" {
+		t.Error("cassette keeps Set-Cookie")
+	}
+	if header.Get("Set-Cookie") == "" {
+		t.Error("New removed Set-Cookie from the caller's header")
+	}
+}
@@ -0,0 +1,28 @@
+package cassette
+
+import (
+	"net/http"
+	"time"
+)
+
+// Cassette is one recorded request and the response it got. Bodies are kept
+// as text so that fixtures can be read and edited by hand; a body that is not
+// valid UTF-8 is base64 encoded and marked as such.
+type Cassette struct {
+	RecordedAt time.Time `json:"recorded_at"`
+	Request    Request   `json:"request"`
+	Response   Response  `json:"response"`
+}
+
+type Request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+type Response struct {
+	Status       int         `json:"status"`
+	Header       http.Header `json:"header,omitempty"`
+	Body         string      `json:"body,omitempty"`
+	BodyEncoding string      `json:"body_encoding,omitempty"`
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math/rand"
 	"metachan/utils/browsers"
+	"metachan/utils/upstream"
 	"net"
 	"net/http"
 	"net/http/cookiejar"
@@ -30,7 +31,7 @@ func NewCloudflareClient(timeout time.Duration) *CloudflareClient {
 	return &CloudflareClient{
 		HttpClient: &http.Client{
 			Timeout:   timeout,
-			Transport: transport,
+			Transport: upstream.Transport(transport),
 			Jar:       cookieJar,
 		},
 		BrowserProfile: selectedProfile,
@@ -0,0 +1,211 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	minuteField     = field{min: 0, max: 59}
+	hourField       = field{min: 0, max: 23}
+	dayOfMonthField = field{min: 1, max: 31}
+	monthField      = field{min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// Both 0 and 7 mean Sunday.
+	dayOfWeekField = field{min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+
+	macros = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+// searchLimit bounds Next for expressions such as "0 0 30 2 *" that never match.
+const searchLimit = 5
+
+// everyHour is the hour field of an expression that runs in every hour.
+const everyHour = 1<<24 - 1
+
+// Parse reads a standard five-field expression (minute, hour, day of month,
+// month, day of week) or one of the @daily style macros. Fields accept lists,
+// ranges, steps and three-letter month and weekday names.
+func Parse(expression string) (*Schedule, error) {
+	expression = strings.TrimSpace(expression)
+	if macro, exists := macros[strings.ToLower(expression)]; exists {
+		expression = macro
+	}
+
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields, got %d", expression, len(fields))
+	}
+
+	schedule := &Schedule{
+		dayOfMonthAny: fields[2] == "*" || fields[2] == "?",
+		dayOfWeekAny:  fields[4] == "*" || fields[4] == "?",
+	}
+
+	var err error
+	if schedule.minute, err = parseField(fields[0], minuteField); err != nil {
+		return nil, fmt.Errorf("invalid minute field: %w", err)
+	}
+	if schedule.hour, err = parseField(fields[1], hourField); err != nil {
+		return nil, fmt.Errorf("invalid hour field: %w", err)
+	}
+	if schedule.dayOfMonth, err = parseField(fields[2], dayOfMonthField); err != nil {
+		return nil, fmt.Errorf("invalid day of month field: %w", err)
+	}
+	if schedule.month, err = parseField(fields[3], monthField); err != nil {
+		return nil, fmt.Errorf("invalid month field: %w", err)
+	}
+	if schedule.dayOfWeek, err = parseField(fields[4], dayOfWeekField); err != nil {
+		return nil, fmt.Errorf("invalid day of week field: %w", err)
+	}
+
+	if schedule.dayOfWeek&(1<<7) != 0 {
+		schedule.dayOfWeek = schedule.dayOfWeek&^(1<<7) | 1
+	}
+
+	return schedule, nil
+}
+
+// Next returns the first matching minute strictly after t, in t's location.
+// It returns the zero time if the expression never matches.
+//
+// Around daylight saving time changes Next behaves like classic cron: minutes
+// skipped when the clocks go forward never match, and when an hour repeats as
+// they go back, only expressions that run every hour match it a second time.
+func (s *Schedule) Next(t time.Time) time.Time {
+	location := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(searchLimit, 0, 0)
+
+	for t.Before(limit) {
+		var next time.Time
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			next = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, location)
+		case !s.matchesDay(t):
+			next = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, location)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			next = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, location)
+		case s.minute&(1<<uint(t.Minute())) == 0, s.hour != everyHour && repeated(t):
+			next = t.Add(time.Minute)
+		default:
+			return t
+		}
+
+		// time.Date resolves a wall clock in a repeated hour to one of its two
+		// instants, which may be behind t, so never step backwards.
+		if !next.After(t) {
+			next = t.Add(time.Minute)
+		}
+		t = next
+	}
+
+	return time.Time{}
+}
+
+// repeated reports whether the wall clock of t was already shown once, in
+// the stretch after the clocks go back when daylight saving time ends.
+func repeated(t time.Time) bool {
+	start, _ := t.ZoneBounds()
+	if start.IsZero() {
+		return false
+	}
+
+	_, offset := t.Zone()
+	_, previous := start.Add(-time.Second).Zone()
+	if previous <= offset {
+		return false
+	}
+	return t.Before(start.Add(time.Duration(previous-offset) * time.Second))
+}
+
+func (s *Schedule) matchesDay(t time.Time) bool {
+	dayOfMonth := s.dayOfMonth&(1<<uint(t.Day())) != 0
+	dayOfWeek := s.dayOfWeek&(1<<uint(t.Weekday())) != 0
+
+	if s.dayOfMonthAny || s.dayOfWeekAny {
+		return dayOfMonth && dayOfWeek
+	}
+	return dayOfMonth || dayOfWeek
+}
+
+func parseField(value string, f field) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(value, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			parsed, err := strconv.Atoi(stepPart)
+			if err != nil || parsed < 1 {
+				return 0, fmt.Errorf("invalid step %q", stepPart)
+			}
+			step = parsed
+		}
+
+		var start, end int
+		switch {
+		case rangePart == "*" || rangePart == "?":
+			start, end = f.min, f.max
+		case strings.Contains(rangePart, "-"):
+			low, high, _ := strings.Cut(rangePart, "-")
+			var err error
+			if start, err = f.value(low); err != nil {
+				return 0, err
+			}
+			if end, err = f.value(high); err != nil {
+				return 0, err
+			}
+		default:
+			var err error
+			if start, err = f.value(rangePart); err != nil {
+				return 0, err
+			}
+			end = start
+			if hasStep {
+				end = f.max
+			}
+		}
+
+		if start > end {
+			return 0, fmt.Errorf("range %q is backwards", rangePart)
+		}
+
+		for i := start; i <= end; i += step {
+			bits |= 1 << uint(i)
+		}
+	}
+
+	return bits, nil
+}
+
+func (f field) value(text string) (int, error) {
+	if value, exists := f.names[strings.ToLower(text)]; exists {
+		return value, nil
+	}
+
+	value, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", text)
+	}
+	if value < f.min || value > f.max {
+		return 0, fmt.Errorf("value %d out of range %d-%d", value, f.min, f.max)
+	}
+
+	return value, nil
+}
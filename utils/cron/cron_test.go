@@ -0,0 +1,176 @@
+package cron
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+const layout = "2006-01-02 15:04 MST"
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("failed to load location %s: %v", name, err)
+	}
+	return location
+}
+
+// at parses a time written in layout. The zone abbreviation picks the offset,
+// so either instant of a repeated hour can be named.
+func at(t *testing.T, location *time.Location, value string) time.Time {
+	t.Helper()
+
+	parsed, err := time.ParseInLocation(layout, value, location)
+	if err != nil {
+		t.Fatalf("failed to parse %q: %v", value, err)
+	}
+	return parsed
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, expression := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"30-10 * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"* * * foo *",
+		"@never",
+	} {
+		if _, err := Parse(expression); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", expression)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		from       string
+		want       string
+	}{
+		{"every minute", "* * * * *", "2026-05-10 12:00 UTC", "2026-05-10 12:01 UTC"},
+		{"seconds are dropped", "* * * * *", "2026-05-10 12:00 UTC", "2026-05-10 12:01 UTC"},
+		{"strictly after a match", "30 12 * * *", "2026-05-10 12:30 UTC", "2026-05-11 12:30 UTC"},
+		{"step", "*/15 * * * *", "2026-05-10 12:16 UTC", "2026-05-10 12:30 UTC"},
+		{"step wraps the hour", "*/15 * * * *", "2026-05-10 12:45 UTC", "2026-05-10 13:00 UTC"},
+		{"step from a value", "5/20 * * * *", "2026-05-10 12:26 UTC", "2026-05-10 12:45 UTC"},
+		{"stepped range", "0 8-20/6 * * *", "2026-05-10 15:00 UTC", "2026-05-10 20:00 UTC"},
+		{"list", "0 6,18 * * *", "2026-05-10 07:00 UTC", "2026-05-10 18:00 UTC"},
+		{"weekday range by name", "0 9 * * mon-fri", "2026-05-08 10:00 UTC", "2026-05-11 09:00 UTC"},
+		{"sunday as 7", "0 0 * * 7", "2026-05-10 12:00 UTC", "2026-05-17 00:00 UTC"},
+		{"month by name", "0 0 1 jun *", "2026-05-10 12:00 UTC", "2026-06-01 00:00 UTC"},
+		{"either day field matches", "0 0 13 * fri", "2026-02-10 00:00 UTC", "2026-02-13 00:00 UTC"},
+		{"day of week alone", "0 0 * * fri", "2026-02-10 00:00 UTC", "2026-02-13 00:00 UTC"},
+		{"macro", "@hourly", "2026-05-10 12:59 UTC", "2026-05-10 13:00 UTC"},
+		{"end of month", "0 0 31 * *", "2026-04-01 00:00 UTC", "2026-05-31 00:00 UTC"},
+		{"end of year", "0 0 1 * *", "2026-12-15 00:00 UTC", "2027-01-01 00:00 UTC"},
+		{"leap day", "0 0 29 2 *", "2026-03-01 00:00 UTC", "2028-02-29 00:00 UTC"},
+		{"last minute of the year", "59 23 31 12 *", "2026-12-31 23:58 UTC", "2026-12-31 23:59 UTC"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			schedule, err := Parse(test.expression)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", test.expression, err)
+			}
+
+			from := at(t, time.UTC, test.from)
+			if test.name == "seconds are dropped" {
+				from = from.Add(42*time.Second + 7)
+			}
+
+			got := schedule.Next(from)
+			if want := at(t, time.UTC, test.want); !got.Equal(want) {
+				t.Errorf("Next(%s) = %s, want %s", from.Format(layout), got.Format(layout), want.Format(layout))
+			}
+		})
+	}
+}
+
+func TestNextNeverMatches(t *testing.T) {
+	schedule, err := Parse("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if got := schedule.Next(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)); !got.IsZero() {
+		t.Errorf("Next = %s, want the zero time", got)
+	}
+}
+
+// In New York the clocks go forward from 02:00 EST to 03:00 EDT on 8 March
+// 2026, and back from 02:00 EDT to 01:00 EST on 1 November 2026.
+func TestNextDaylightSaving(t *testing.T) {
+	newYork := mustLocation(t, "America/New_York")
+
+	tests := []struct {
+		name       string
+		expression string
+		from       string
+		want       string
+	}{
+		{"skipped hour never matches", "30 2 * * *", "2026-03-08 00:00 EST", "2026-03-09 02:30 EDT"},
+		{"step across the skipped hour", "*/30 * * * *", "2026-03-08 01:45 EST", "2026-03-08 03:00 EDT"},
+		{"every hour runs in the repeated hour", "0 * * * *", "2026-11-01 01:00 EDT", "2026-11-01 01:00 EST"},
+		{"steps run in the repeated hour", "*/15 * * * *", "2026-11-01 01:50 EDT", "2026-11-01 01:00 EST"},
+		{"strictly after in the repeated hour", "*/15 * * * *", "2026-11-01 01:20 EST", "2026-11-01 01:30 EST"},
+		{"leaving the repeated hour", "*/15 * * * *", "2026-11-01 01:50 EST", "2026-11-01 02:00 EST"},
+		{"fixed hour runs once", "30 1 * * *", "2026-11-01 01:30 EDT", "2026-11-02 01:30 EST"},
+		{"fixed hour from the repeated hour", "45 1 * * *", "2026-11-01 01:50 EST", "2026-11-02 01:45 EST"},
+		{"fixed hour before the change", "30 1 * * *", "2026-10-31 12:00 EDT", "2026-11-01 01:30 EDT"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			schedule, err := Parse(test.expression)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", test.expression, err)
+			}
+
+			from := at(t, newYork, test.from)
+			got := schedule.Next(from)
+			if want := at(t, newYork, test.want); !got.Equal(want) {
+				t.Errorf("Next(%s) = %s, want %s", from.Format(layout), got.Format(layout), want.Format(layout))
+			}
+		})
+	}
+}
+
+// TestNextAlwaysAdvances walks schedules through both changes and expects
+// every run to come strictly after the one before.
+func TestNextAlwaysAdvances(t *testing.T) {
+	newYork := mustLocation(t, "America/New_York")
+
+	for _, expression := range []string{"* * * * *", "*/7 * * * *", "0 * * * *", "30 1 * * *", "15 1-3 * * *"} {
+		schedule, err := Parse(expression)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", expression, err)
+		}
+
+		for _, day := range []string{"2026-03-07 23:00 EST", "2026-10-31 23:00 EDT"} {
+			from := at(t, newYork, day)
+			end := from.Add(6 * time.Hour)
+			for runs := 0; from.Before(end); runs++ {
+				next := schedule.Next(from)
+				if !next.After(from) {
+					t.Fatalf("%q: Next(%s) = %s, not after it", expression, from.Format(layout), next.Format(layout))
+				}
+				if runs > 6*60 {
+					t.Fatalf("%q: more runs than minutes from %s", expression, day)
+				}
+				from = next
+			}
+		}
+	}
+}
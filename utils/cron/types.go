@@ -0,0 +1,22 @@
+package cron
+
+// Schedule is a parsed five-field cron expression. Each field is a bitset of
+// the values it matches.
+type Schedule struct {
+	minute     uint64
+	hour       uint64
+	dayOfMonth uint64
+	month      uint64
+	dayOfWeek  uint64
+
+	// Cron matches a day when either day field matches, unless one of them is
+	// "*", in which case only the other one counts.
+	dayOfMonthAny bool
+	dayOfWeekAny  bool
+}
+
+type field struct {
+	min   int
+	max   int
+	names map[string]int
+}
@@ -9,6 +9,12 @@ import (
 )
 
 func setFieldFromEnv(field reflect.Value, envKey, defaultVal string) {
+	// time.Duration is an int64, so it has to be matched before the kind switch.
+	if field.Type() == reflect.TypeFor[time.Duration]() {
+		setDurationField(field, envKey, defaultVal)
+		return
+	}
+
 	switch field.Kind() {
 	case reflect.String:
 		field.SetString(getEnv(envKey, defaultVal))
@@ -26,8 +32,6 @@ func setFieldFromEnv(field reflect.Value, envKey, defaultVal string) {
 		field.SetFloat(getEnvFloat(envKey, defaultFloat))
 	case reflect.Slice:
 		setSliceField(field, envKey, defaultVal)
-	default:
-		setDurationField(field, envKey, defaultVal)
 	}
 }
 
@@ -66,6 +70,13 @@ func setSliceField(field reflect.Value, envKey, defaultVal string) {
 }
 
 func setFieldDefault(field reflect.Value, defaultVal string) {
+	if field.Type() == reflect.TypeFor[time.Duration]() {
+		if defaultDuration, err := time.ParseDuration(defaultVal); err == nil {
+			field.Set(reflect.ValueOf(defaultDuration))
+		}
+		return
+	}
+
 	switch field.Kind() {
 	case reflect.String:
 		field.SetString(defaultVal)
@@ -97,11 +108,5 @@ func setFieldDefault(field reflect.Value, defaultVal string) {
 			}
 			field.Set(reflect.ValueOf(result))
 		}
-	default:
-		if field.Type() == reflect.TypeFor[time.Duration]() {
-			if defaultDuration, err := time.ParseDuration(defaultVal); err == nil {
-				field.Set(reflect.ValueOf(defaultDuration))
-			}
-		}
 	}
 }
@@ -0,0 +1,219 @@
+// Package franchise orders the anime of a franchise into watch orders from
+// their release dates and the relations between them.
+package franchise
+
+import (
+	"maps"
+	"metachan/enums"
+	"metachan/types"
+	"sort"
+	"strings"
+	"time"
+)
+
+// mainStoryTypes are the anime types that can be part of the main story.
+var mainStoryTypes = map[string]bool{
+	"TV":    true,
+	"Movie": true,
+	"ONA":   true,
+	"OVA":   true,
+}
+
+// seasonStarts is the month each anime season starts in.
+var seasonStarts = map[string]time.Month{
+	"winter": time.January,
+	"spring": time.April,
+	"summer": time.July,
+	"fall":   time.October,
+	"autumn": time.October,
+}
+
+// releaseDate is when an entry first aired, falling back to the start of its
+// season. Entries without either are reported as unknown.
+func releaseDate(entry types.FranchiseEntry) (time.Time, bool) {
+	if entry.AiredFrom != nil {
+		return *entry.AiredFrom, true
+	}
+	if entry.Year > 0 {
+		month := time.January
+		if start, ok := seasonStarts[strings.ToLower(entry.Season)]; ok {
+			month = start
+		}
+		return time.Date(entry.Year, month, 1, 0, 0, 0, 0, time.UTC), true
+	}
+	return time.Time{}, false
+}
+
+// SortByRelease orders entries by air date. Entries without one go last, by
+// MAL ID, which roughly follows when they were added.
+func SortByRelease(entries []types.FranchiseEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		dateI, knownI := releaseDate(entries[i])
+		dateJ, knownJ := releaseDate(entries[j])
+		switch {
+		case knownI && knownJ && !dateI.Equal(dateJ):
+			return dateI.Before(dateJ)
+		case knownI != knownJ:
+			return knownI
+		default:
+			return entries[i].MALID < entries[j].MALID
+		}
+	})
+}
+
+// storyConstraint reports which side of a MAL relation comes first in the
+// story, or false when the relation says nothing about it.
+func storyConstraint(edge types.FranchiseEdge) (int, int, bool) {
+	if edge.Via != enums.MAL {
+		return 0, 0, false
+	}
+
+	switch edge.Relation {
+	case enums.Sequel, enums.SideStory, enums.Summary:
+		return edge.From, edge.To, true
+	case enums.Prequel, enums.ParentStory, enums.FullStory:
+		return edge.To, edge.From, true
+	default:
+		return 0, 0, false
+	}
+}
+
+// ChronologicalOrder sorts the franchise topologically by the story
+// constraints of its relations. Whenever there is a choice, the entry leading
+// to the earliest release goes first, so that a late prequel is placed right
+// before its sequel rather than after everything released in between. MAL
+// relations are not always consistent, and a cycle is broken as soon as it
+// holds up the earliest release by taking the earliest released entry left.
+func ChronologicalOrder(release []int, edges []types.FranchiseEdge) []int {
+	type constraint struct{ first, then int }
+	var constraints []constraint
+	pending := make(map[int]int, len(release))
+	following := make(map[int][]int)
+	for _, edge := range edges {
+		first, then, ok := storyConstraint(edge)
+		if !ok {
+			continue
+		}
+		constraints = append(constraints, constraint{first, then})
+		following[first] = append(following[first], then)
+		pending[then]++
+	}
+
+	rank := make(map[int]int, len(release))
+	for i, malID := range release {
+		rank[malID] = i
+	}
+	leadsTo := maps.Clone(rank)
+	for changed := true; changed; {
+		changed = false
+		for _, c := range constraints {
+			if leadsTo[c.then] < leadsTo[c.first] {
+				leadsTo[c.first] = leadsTo[c.then]
+				changed = true
+			}
+		}
+	}
+
+	order := make([]int, 0, len(release))
+	placed := make(map[int]bool, len(release))
+	for len(order) < len(release) {
+		// next is the free entry leading to the earliest release and lowest
+		// the entry left that does, free or not. Only a cycle can keep
+		// lowest waiting while nothing free leads as early.
+		next, lowest := -1, -1
+		for _, malID := range release {
+			if placed[malID] {
+				continue
+			}
+			if lowest == -1 || leadsTo[malID] < leadsTo[lowest] {
+				lowest = malID
+			}
+			if pending[malID] > 0 {
+				continue
+			}
+			if next == -1 || leadsTo[malID] < leadsTo[next] {
+				next = malID
+			}
+		}
+		if next == -1 || leadsTo[lowest] < leadsTo[next] {
+			next = lowest
+		}
+
+		placed[next] = true
+		order = append(order, next)
+		for _, malID := range following[next] {
+			pending[malID]--
+		}
+	}
+
+	return order
+}
+
+// MainStoryOrder keeps the entries on the sequel chain of root, in
+// chronological order. A root that is a side story or summary is traced
+// back to what it branches off first, and recaps, specials and side stories
+// are dropped.
+func MainStoryOrder(root int, chronological []int, entries []types.FranchiseEntry, edges []types.FranchiseEdge) []int {
+	summaries := make(map[int]bool)
+	branchesOff := make(map[int]int)
+	chain := make(map[int][]int)
+	for _, edge := range edges {
+		switch {
+		case edge.Via != enums.MAL:
+			chain[edge.From] = append(chain[edge.From], edge.To)
+			chain[edge.To] = append(chain[edge.To], edge.From)
+		case edge.Relation == enums.Sequel || edge.Relation == enums.Prequel:
+			chain[edge.From] = append(chain[edge.From], edge.To)
+			chain[edge.To] = append(chain[edge.To], edge.From)
+		case edge.Relation == enums.ParentStory || edge.Relation == enums.FullStory:
+			branchesOff[edge.From] = edge.To
+		case edge.Relation == enums.SideStory || edge.Relation == enums.Summary:
+			branchesOff[edge.To] = edge.From
+		}
+
+		if edge.Via == enums.MAL && edge.Relation == enums.Summary {
+			summaries[edge.To] = true
+		}
+		if edge.Via == enums.MAL && edge.Relation == enums.FullStory {
+			summaries[edge.From] = true
+		}
+	}
+
+	start := root
+	traced := map[int]bool{root: true}
+	for {
+		parent, ok := branchesOff[start]
+		if !ok || traced[parent] {
+			break
+		}
+		traced[parent] = true
+		start = parent
+	}
+
+	onChain := map[int]bool{start: true}
+	queue := []int{start}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, malID := range chain[current] {
+			// Side stories can share a TVDB series with the main one.
+			if _, branch := branchesOff[malID]; !onChain[malID] && !branch {
+				onChain[malID] = true
+				queue = append(queue, malID)
+			}
+		}
+	}
+
+	entryTypes := make(map[int]string, len(entries))
+	for _, entry := range entries {
+		entryTypes[entry.MALID] = entry.Type
+	}
+
+	mainStory := []int{}
+	for _, malID := range chronological {
+		if onChain[malID] && !summaries[malID] && mainStoryTypes[entryTypes[malID]] {
+			mainStory = append(mainStory, malID)
+		}
+	}
+	return mainStory
+}
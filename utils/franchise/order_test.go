@@ -0,0 +1,253 @@
+package franchise
+
+import (
+	"metachan/enums"
+	"metachan/types"
+	"slices"
+	"testing"
+	"time"
+)
+
+func relation(from int, kind enums.AnimeRelationType, to int) types.FranchiseEdge {
+	return types.FranchiseEdge{From: from, To: to, Via: enums.MAL, Relation: kind}
+}
+
+func series(from, to int, via enums.MappingType) types.FranchiseEdge {
+	return types.FranchiseEdge{From: from, To: to, Via: via}
+}
+
+func aired(year int, month time.Month) *time.Time {
+	date := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	return &date
+}
+
+func TestSortByRelease(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []types.FranchiseEntry
+		want    []int
+	}{
+		{
+			name: "prequel released after its sequel",
+			entries: []types.FranchiseEntry{
+				{MALID: 30, AiredFrom: aired(2015, time.April)},
+				{MALID: 10, AiredFrom: aired(2006, time.April)},
+				{MALID: 20, AiredFrom: aired(2009, time.October)},
+			},
+			want: []int{10, 20, 30},
+		},
+		{
+			name: "season used when the air date is missing",
+			entries: []types.FranchiseEntry{
+				{MALID: 1, Year: 2012, Season: "fall"},
+				{MALID: 2, Year: 2012, Season: "Summer"},
+				{MALID: 3, AiredFrom: aired(2012, time.August)},
+			},
+			want: []int{2, 3, 1},
+		},
+		{
+			name: "same date ordered by MAL ID",
+			entries: []types.FranchiseEntry{
+				{MALID: 20, AiredFrom: aired(2008, time.October)},
+				{MALID: 5, Year: 2008, Season: "fall"},
+			},
+			want: []int{5, 20},
+		},
+		{
+			name: "unsynced entries last by MAL ID",
+			entries: []types.FranchiseEntry{
+				{MALID: 3},
+				{MALID: 10, AiredFrom: aired(2010, time.April)},
+				{MALID: 1},
+			},
+			want: []int{10, 1, 3},
+		},
+	}
+
+	for _, test := range tests {
+		SortByRelease(test.entries)
+		got := make([]int, len(test.entries))
+		for i, entry := range test.entries {
+			got[i] = entry.MALID
+		}
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%s: SortByRelease = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestChronologicalOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		release []int
+		edges   []types.FranchiseEdge
+		want    []int
+	}{
+		{
+			name:    "prequel released after its sequel",
+			release: []int{1, 2, 3},
+			edges: []types.FranchiseEdge{
+				relation(1, enums.Sequel, 2),
+				relation(1, enums.Prequel, 3),
+			},
+			want: []int{3, 1, 2},
+		},
+		{
+			// 4 came out between the show and its late prequel 3, but the
+			// prequel belongs right before the show it leads into.
+			name:    "late prequel placed before its sequel",
+			release: []int{1, 4, 2, 3},
+			edges: []types.FranchiseEdge{
+				relation(1, enums.Sequel, 2),
+				relation(2, enums.Prequel, 1),
+				relation(3, enums.Sequel, 2),
+				relation(1, enums.SpinOff, 4),
+			},
+			want: []int{1, 4, 3, 2},
+		},
+		{
+			name:    "MAL relation cycle",
+			release: []int{1, 2, 3, 4},
+			edges: []types.FranchiseEdge{
+				relation(1, enums.Sequel, 2),
+				relation(2, enums.Sequel, 3),
+				relation(3, enums.Sequel, 1),
+			},
+			want: []int{1, 2, 3, 4},
+		},
+		{
+			name:    "summary after what it summarises",
+			release: []int{2, 1, 3},
+			edges: []types.FranchiseEdge{
+				relation(1, enums.Summary, 2),
+				relation(1, enums.Sequel, 3),
+			},
+			want: []int{1, 2, 3},
+		},
+		{
+			name:    "side story after its parent",
+			release: []int{2, 1, 3},
+			edges: []types.FranchiseEdge{
+				relation(2, enums.ParentStory, 1),
+				relation(1, enums.Sequel, 3),
+			},
+			want: []int{1, 2, 3},
+		},
+		{
+			name:    "TVDB-only links keep the release order",
+			release: []int{5, 7, 6},
+			edges: []types.FranchiseEdge{
+				series(5, 7, enums.TVDB),
+				series(5, 6, enums.TVDB),
+				series(6, 7, enums.TMDB),
+			},
+			want: []int{5, 7, 6},
+		},
+	}
+
+	for _, test := range tests {
+		if got := ChronologicalOrder(test.release, test.edges); !slices.Equal(got, test.want) {
+			t.Errorf("%s: ChronologicalOrder = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestMainStoryOrder(t *testing.T) {
+	entries := func(kinds map[int]string) []types.FranchiseEntry {
+		list := make([]types.FranchiseEntry, 0, len(kinds))
+		for malID, kind := range kinds {
+			list = append(list, types.FranchiseEntry{MALID: malID, Type: kind})
+		}
+		return list
+	}
+
+	tests := []struct {
+		name          string
+		root          int
+		chronological []int
+		entries       []types.FranchiseEntry
+		edges         []types.FranchiseEdge
+		want          []int
+	}{
+		{
+			name:          "prequel released after its sequel",
+			root:          1,
+			chronological: []int{3, 1, 2},
+			entries:       entries(map[int]string{1: "TV", 2: "TV", 3: "Movie"}),
+			edges: []types.FranchiseEdge{
+				relation(1, enums.Sequel, 2),
+				relation(1, enums.Prequel, 3),
+			},
+			want: []int{3, 1, 2},
+		},
+		{
+			name:          "MAL relation cycle",
+			root:          2,
+			chronological: []int{1, 2, 3},
+			entries:       entries(map[int]string{1: "TV", 2: "TV", 3: "TV"}),
+			edges: []types.FranchiseEdge{
+				relation(1, enums.Sequel, 2),
+				relation(2, enums.Sequel, 3),
+				relation(3, enums.Sequel, 1),
+			},
+			want: []int{1, 2, 3},
+		},
+		{
+			name:          "parent story cycle",
+			root:          1,
+			chronological: []int{1, 2},
+			entries:       entries(map[int]string{1: "TV", 2: "TV"}),
+			edges: []types.FranchiseEdge{
+				relation(1, enums.ParentStory, 2),
+				relation(2, enums.ParentStory, 1),
+			},
+			want: []int{2},
+		},
+		{
+			name:          "summary and full story dropped",
+			root:          1,
+			chronological: []int{1, 2, 3, 4},
+			entries:       entries(map[int]string{1: "TV", 2: "Movie", 3: "TV", 4: "Movie"}),
+			edges: []types.FranchiseEdge{
+				relation(1, enums.Summary, 2),
+				relation(1, enums.Sequel, 3),
+				relation(3, enums.Sequel, 4),
+				relation(4, enums.FullStory, 3),
+			},
+			want: []int{1, 3},
+		},
+		{
+			name:          "side-story root traced back to the main story",
+			root:          2,
+			chronological: []int{1, 2, 3},
+			entries:       entries(map[int]string{1: "TV", 2: "OVA", 3: "TV"}),
+			edges: []types.FranchiseEdge{
+				relation(2, enums.ParentStory, 1),
+				relation(1, enums.Sequel, 3),
+			},
+			want: []int{1, 3},
+		},
+		{
+			name:          "TVDB-only links",
+			root:          10,
+			chronological: []int{10, 11, 12, 13},
+			entries:       entries(map[int]string{10: "TV", 11: "TV", 12: "Special", 13: "OVA"}),
+			edges: []types.FranchiseEdge{
+				series(10, 11, enums.TVDB),
+				series(10, 12, enums.TVDB),
+				// A side story filed under the same TVDB series is not
+				// part of the main story.
+				series(10, 13, enums.TVDB),
+				relation(10, enums.SideStory, 13),
+			},
+			want: []int{10, 11},
+		},
+	}
+
+	for _, test := range tests {
+		got := MainStoryOrder(test.root, test.chronological, test.entries, test.edges)
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%s: MainStoryOrder = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package ical
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	productID      = "-//metachan//Anime Schedule//EN"
+	dateTimeFormat = "20060102T150405Z"
+	maxLineOctets  = 75
+)
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+)
+
+// String renders the calendar as an RFC 5545 document. Times are written in
+// UTC so that calendar clients convert them to the subscriber's timezone.
+func (c Calendar) String() string {
+	var builder strings.Builder
+	stamp := time.Now().UTC().Format(dateTimeFormat)
+
+	writeLine(&builder, "BEGIN:VCALENDAR")
+	writeLine(&builder, "VERSION:2.0")
+	writeLine(&builder, "PRODID:"+productID)
+	writeLine(&builder, "CALSCALE:GREGORIAN")
+	writeLine(&builder, "METHOD:PUBLISH")
+	if c.Name != "" {
+		writeLine(&builder, "X-WR-CALNAME:"+escapeText(c.Name))
+	}
+
+	for _, event := range c.Events {
+		writeLine(&builder, "BEGIN:VEVENT")
+		writeLine(&builder, "UID:"+event.UID)
+		writeLine(&builder, "DTSTAMP:"+stamp)
+		writeLine(&builder, "DTSTART:"+event.Start.UTC().Format(dateTimeFormat))
+		writeLine(&builder, "DTEND:"+event.Start.Add(event.Duration).UTC().Format(dateTimeFormat))
+		writeLine(&builder, "SUMMARY:"+escapeText(event.Summary))
+		if event.Description != "" {
+			writeLine(&builder, "DESCRIPTION:"+escapeText(event.Description))
+		}
+		if event.URL != "" {
+			writeLine(&builder, "URL:"+event.URL)
+		}
+		writeLine(&builder, "END:VEVENT")
+	}
+
+	writeLine(&builder, "END:VCALENDAR")
+	return builder.String()
+}
+
+func escapeText(text string) string {
+	return textEscaper.Replace(text)
+}
+
+// writeLine folds content lines longer than 75 octets as required by RFC 5545,
+// taking care not to split multi-byte UTF-8 sequences.
+func writeLine(builder *strings.Builder, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		builder.WriteString(line[:cut])
+		builder.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space, which counts towards the limit.
+		limit = maxLineOctets - 1
+	}
+	builder.WriteString(line)
+	builder.WriteString("\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
@@ -0,0 +1,17 @@
+package ical
+
+import "time"
+
+type Calendar struct {
+	Name   string
+	Events []Event
+}
+
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	URL         string
+	Start       time.Time
+	Duration    time.Duration
+}
@@ -1,6 +1,7 @@
 package mal
 
 import (
+	"context"
 	"fmt"
 	"metachan/utils/logger"
 	"regexp"
@@ -23,6 +24,8 @@ var (
 	imageResizePrefixPattern    = regexp.MustCompile(`/r/\d+x\d+`)
 	leadingIndexPattern         = regexp.MustCompile(`^#?\d+:?\s*`)
 	trailingEpisodeInfoPattern  = regexp.MustCompile(`\s*\(eps\s.*$`)
+	relatedEntryPattern         = regexp.MustCompile(`/(anime|manga)/(\d+)`)
+	trailingFormatPattern       = regexp.MustCompile(`\s*\(([^()]+)\)\s*$`)
 )
 
 const airedDateLayout = "Jan 2, 2006"
@@ -468,6 +471,92 @@ func parseMusicVideos(document *goquery.Document) []MusicVideo {
 	return videos
 }
 
+var relationTypes = []RelationType{
+	RelationSequel, RelationPrequel, RelationAlternativeSetting, RelationAlternativeVersion,
+	RelationSideStory, RelationParentStory, RelationSummary, RelationFullStory,
+	RelationSpinOff, RelationAdaptation, RelationCharacter, RelationOther,
+}
+
+// parseRelationType matches a Related Entries label regardless of case,
+// since MAL writes "Side Story" on the tiles and "Side story:" in the table.
+func parseRelationType(label string) RelationType {
+	label = strings.TrimSuffix(strings.TrimSpace(label), ":")
+	for _, relation := range relationTypes {
+		if strings.EqualFold(label, string(relation)) {
+			return relation
+		}
+	}
+	return RelationOther
+}
+
+// splitTrailingFormat cuts the "(Movie)" MAL puts after a related entry.
+func splitTrailingFormat(text string) (string, string) {
+	text = strings.TrimSpace(text)
+	formatMatches := trailingFormatPattern.FindStringSubmatch(text)
+	if len(formatMatches) < 2 {
+		return text, ""
+	}
+	return strings.TrimSpace(text[:len(text)-len(formatMatches[0])]), strings.TrimSpace(formatMatches[1])
+}
+
+func parseRelatedEntry(relation RelationType, linkElement *goquery.Selection, format string) (RelatedEntry, bool) {
+	href, _ := linkElement.Attr("href")
+	entryMatches := relatedEntryPattern.FindStringSubmatch(href)
+	if len(entryMatches) < 3 {
+		return RelatedEntry{}, false
+	}
+	malID, _ := strconv.Atoi(entryMatches[2])
+	return RelatedEntry{
+		Relation: relation,
+		Kind:     entryMatches[1],
+		MALID:    malID,
+		Title:    strings.TrimSpace(linkElement.Text()),
+		Format:   format,
+		URL:      href,
+	}, true
+}
+
+// parseAnimeRelations reads Related Entries, which MAL splits into image
+// tiles for the closest relations and a table for the rest. The table of the
+// older layout is read as well.
+func parseAnimeRelations(document *goquery.Document) []RelatedEntry {
+	var relations []RelatedEntry
+
+	document.Find("div.related-entries div.entry").Each(func(index int, tileElement *goquery.Selection) {
+		relationText, format := splitTrailingFormat(tileElement.Find("div.relation").Text())
+		entry, ok := parseRelatedEntry(parseRelationType(relationText), tileElement.Find("div.title a").First(), format)
+		if !ok {
+			return
+		}
+
+		imageElement := tileElement.Find("div.image img").First()
+		imageURL, exists := imageElement.Attr("data-src")
+		if !exists {
+			imageURL, _ = imageElement.Attr("src")
+		}
+		if imageURL != "" {
+			entry.Image = buildImageFromBaseURL(imageURL)
+		}
+
+		relations = append(relations, entry)
+	})
+
+	document.Find("div.related-entries table.entries-table tr, table.anime_detail_related_anime tr").Each(func(index int, row *goquery.Selection) {
+		relation := parseRelationType(row.Find("td").First().Text())
+		row.Find("td").Last().Find("a").Each(func(linkIndex int, linkElement *goquery.Selection) {
+			var format string
+			if listItem := linkElement.Closest("li"); listItem.Length() > 0 {
+				_, format = splitTrailingFormat(listItem.Text())
+			}
+			if entry, ok := parseRelatedEntry(relation, linkElement, format); ok {
+				relations = append(relations, entry)
+			}
+		})
+	})
+
+	return relations
+}
+
 func parseAnimeDocument(document *goquery.Document, malID int) Anime {
 	pageURL, _ := document.Find(`meta[property="og:url"]`).Attr("content")
 	statusText := extractSidebarValue(document, "Status:")
@@ -496,8 +585,9 @@ func parseAnimeDocument(document *goquery.Document, malID int) Anime {
 		Statistics:   parseAnimeStatistics(document),
 		Trailer:      parseAnimeTrailer(document),
 
-		Openings: parseAnimeThemeSongs(document, "opnening"),
-		Endings:  parseAnimeThemeSongs(document, "ending"),
+		Openings:  parseAnimeThemeSongs(document, "opnening"),
+		Endings:   parseAnimeThemeSongs(document, "ending"),
+		Relations: parseAnimeRelations(document),
 
 		Genres:         extractSidebarMALIDsMultiLabel(document, []string{"Genres:", "Genre:"}, genreIDPattern),
 		ExplicitGenres: extractSidebarMALIDs(document, "Explicit Genres:", genreIDPattern),
@@ -522,9 +612,11 @@ func fixThemeSongEpisodeRanges(themeSongs []ThemeSong, totalEpisodes int) {
 	}
 }
 
-func GetAnimeByMALID(malID int) (*Anime, error) {
+// GetAnimeDetailsByMALID scrapes the anime and video pages only, skipping the
+// episode list which can span dozens of pages for long-running series.
+func GetAnimeDetailsByMALID(ctx context.Context, malID int) (*Anime, error) {
 	animePageURL := fmt.Sprintf("%s/anime/%d", malBaseURL, malID)
-	animeDocument, fetchErr := makeRequest(animePageURL)
+	animeDocument, fetchErr := makeRequest(ctx, animePageURL)
 	if fetchErr != nil {
 		logger.Errorf("MALClient", "Failed to fetch anime page for MAL ID %d: %v", malID, fetchErr)
 		return nil, fmt.Errorf("failed to fetch anime page for MAL ID %d: %w", malID, fetchErr)
@@ -532,6 +624,7 @@ func GetAnimeByMALID(malID int) (*Anime, error) {
 
 	logger.Debugf("MALScraper", "Parsing anime page for MAL ID %d", malID)
 	anime := parseAnimeDocument(animeDocument, malID)
+	recordParse(pageKindAnime, malID, animePageGaps(anime))
 	logger.Debugf("MALScraper", "Parsed anime page: Title=%q, EpisodeCount=%d", anime.Title.Romaji, anime.EpisodeCount)
 
 	fixThemeSongEpisodeRanges(anime.Openings, anime.EpisodeCount)
@@ -539,7 +632,7 @@ func GetAnimeByMALID(malID int) (*Anime, error) {
 
 	logger.Debugf("MALScraper", "Fetching videos page for MAL ID %d", malID)
 	videosPageURL := fmt.Sprintf("%s/anime/%d/_/video", malBaseURL, malID)
-	videosDocument, videosFetchErr := makeRequest(videosPageURL)
+	videosDocument, videosFetchErr := makeRequest(ctx, videosPageURL)
 	if videosFetchErr != nil {
 		logger.Warnf("MALClient", "Failed to fetch videos page for MAL ID %d: %v", malID, videosFetchErr)
 	} else {
@@ -548,8 +641,17 @@ func GetAnimeByMALID(malID int) (*Anime, error) {
 		logger.Debugf("MALScraper", "Parsed videos: %d promotional, %d music", len(anime.Videos), len(anime.MusicVideos))
 	}
 
+	return &anime, nil
+}
+
+func GetAnimeByMALID(ctx context.Context, malID int) (*Anime, error) {
+	anime, err := GetAnimeDetailsByMALID(ctx, malID)
+	if err != nil {
+		return nil, err
+	}
+
 	logger.Debugf("MALScraper", "Fetching episodes for MAL ID %d", malID)
-	episodes, episodesFetchErr := GetAnimeEpisodesByMALID(malID)
+	episodes, episodesFetchErr := GetAnimeEpisodesByMALID(ctx, malID)
 	if episodesFetchErr != nil {
 		logger.Warnf("MALClient", "Failed to fetch episodes for MAL ID %d: %v", malID, episodesFetchErr)
 	} else {
@@ -557,5 +659,5 @@ func GetAnimeByMALID(malID int) (*Anime, error) {
 		logger.Debugf("MALScraper", "Fetched %d episodes for MAL ID %d", len(episodes), malID)
 	}
 
-	return &anime, nil
+	return anime, nil
 }
\ No newline at end of file
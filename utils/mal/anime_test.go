@@ -0,0 +1,30 @@
+package mal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseAnimeDocument(t *testing.T) {
+	anime := parseAnimeDocument(loadDocument(t, "anime.html"), 1)
+	assertGolden(t, "anime", anime)
+
+	if missing := animePageGaps(anime); len(missing) > 0 {
+		t.Errorf("fixture parsed without %v", missing)
+	}
+}
+
+func TestParseThemeSongText(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "theme_songs.txt"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	var themeSongs []ThemeSong
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		themeSongs = append(themeSongs, parseThemeSongText(line))
+	}
+	assertGolden(t, "theme_songs_text", themeSongs)
+}
@@ -1,12 +1,16 @@
 package mal
 
 import (
+	"bytes"
+	"context"
 	"fmt"
-	"math"
+	"metachan/config"
+	"metachan/enums"
 	"metachan/utils/cfbypass"
-	"metachan/utils/logger"
 	"metachan/utils/ratelimit"
+	"metachan/utils/upstream"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
@@ -19,82 +23,56 @@ const (
 	requestJitter   = 250 * time.Millisecond
 	maxRetries      = 3
 	backoffBase     = 2 * time.Second
+	cacheTTL        = 24 * time.Hour
 )
 
 var (
-	rateLimiter      = ratelimit.NewRateLimiter(rateLimitPerSec, time.Second)
 	cloudflareClient = cfbypass.NewCloudflareClient(requestTimeout)
+	clientInstance   = upstream.New(upstream.Config{
+		Name:       "MALClient",
+		BaseURL:    config.Upstream.MALURL,
+		UserAgent:  cloudflareClient.BrowserProfile.UserAgent,
+		Header:     browserHeaders(),
+		Limiter:    ratelimit.NewLimiter("MAL", ratelimit.Rule{Limit: rateLimitPerSec, Window: time.Second}),
+		Jitter:     requestJitter,
+		HTTPClient: cloudflareClient.HttpClient,
+		Retry: upstream.RetryPolicy{
+			Attempts: maxRetries,
+			Backoff:  backoffBase,
+			// Cloudflare answers 403 to a challenge that often passes on retry.
+			RetryStatuses: []int{http.StatusForbidden},
+		},
+		Breaker:  upstream.NewBreaker(enums.UpstreamMAL),
+		CacheTTL: cacheTTL,
+	})
 )
 
-func StopRateLimiters() {
-	rateLimiter.Stop()
-}
-
-func makeRequest(targetURL string) (*goquery.Document, error) {
-	var retries int
-
-	for retries < maxRetries {
-		rateLimiter.Wait()
-		time.Sleep(cfbypass.AddJitter(requestJitter))
-
-		request, err := http.NewRequest("GET", targetURL, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create request for %s: %w", targetURL, err)
-		}
-
-		for headerName, headerValue := range cloudflareClient.BrowserProfile.Headers {
-			if headerName == "Accept-Encoding" {
-				continue
-			}
-			request.Header.Set(headerName, headerValue)
-		}
-		request.Header.Set("User-Agent", cloudflareClient.BrowserProfile.UserAgent)
-
-		response, err := cloudflareClient.HttpClient.Do(request)
-		if err != nil {
-			retries++
-			if retries >= maxRetries {
-				return nil, fmt.Errorf("all retries exhausted for %s: %w", targetURL, err)
-			}
-			logger.Debugf("MALClient", "Request failed for %s (attempt %d/%d)", targetURL, retries, maxRetries)
-			time.Sleep(getBackoffDuration(retries))
+// browserHeaders returns the profile's headers minus Accept-Encoding, which
+// is left to the transport so that it also decompresses the response.
+func browserHeaders() http.Header {
+	header := http.Header{}
+	for headerName, headerValue := range cloudflareClient.BrowserProfile.Headers {
+		if headerName == "Accept-Encoding" {
 			continue
 		}
+		header.Set(headerName, headerValue)
+	}
+	return header
+}
 
-		if response.StatusCode == http.StatusOK {
-			document, parseErr := goquery.NewDocumentFromReader(response.Body)
-			response.Body.Close()
-			if parseErr != nil {
-				return nil, fmt.Errorf("failed to parse HTML from %s: %w", targetURL, parseErr)
-			}
-			return document, nil
-		}
-
-		response.Body.Close()
-
-		if response.StatusCode == http.StatusNotFound {
-			return nil, fmt.Errorf("resource not found: %s", targetURL)
-		}
-
-		if response.StatusCode >= 400 && response.StatusCode < 500 &&
-			response.StatusCode != http.StatusTooManyRequests &&
-			response.StatusCode != http.StatusForbidden {
-			return nil, fmt.Errorf("client error %d for %s", response.StatusCode, targetURL)
-		}
-
-		retries++
-		if retries >= maxRetries {
-			return nil, fmt.Errorf("all retries exhausted for %s (status %d)", targetURL, response.StatusCode)
-		}
-
-		logger.Warnf("MALClient", "Status %d for %s (attempt %d/%d)", response.StatusCode, targetURL, retries, maxRetries)
-		time.Sleep(getBackoffDuration(retries))
+// makeRequest fetches and parses a MAL page. Links scraped from MAL point at
+// malBaseURL, so they are resolved against the configured base URL instead.
+func makeRequest(ctx context.Context, targetURL string) (*goquery.Document, error) {
+	targetURL = clientInstance.URL(strings.TrimPrefix(targetURL, malBaseURL))
+	body, err := clientInstance.Get(ctx, targetURL)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, fmt.Errorf("all retries exhausted for %s", targetURL)
-}
+	document, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML from %s: %w", targetURL, err)
+	}
 
-func getBackoffDuration(attempt int) time.Duration {
-	exponentialDelay := time.Duration(float64(backoffBase) * math.Pow(2, float64(attempt-1)))
-	return cfbypass.AddJitter(exponentialDelay)
+	return document, nil
 }
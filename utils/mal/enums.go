@@ -59,4 +59,21 @@ const (
 	SeasonSpring Season = "Spring"
 	SeasonSummer Season = "Summer"
 	SeasonFall   Season = "Fall"
+)
+
+type RelationType string
+
+const (
+	RelationSequel             RelationType = "Sequel"
+	RelationPrequel            RelationType = "Prequel"
+	RelationAlternativeSetting RelationType = "Alternative Setting"
+	RelationAlternativeVersion RelationType = "Alternative Version"
+	RelationSideStory          RelationType = "Side Story"
+	RelationParentStory        RelationType = "Parent Story"
+	RelationSummary            RelationType = "Summary"
+	RelationFullStory          RelationType = "Full Story"
+	RelationSpinOff            RelationType = "Spin-Off"
+	RelationAdaptation         RelationType = "Adaptation"
+	RelationCharacter          RelationType = "Character"
+	RelationOther              RelationType = "Other"
 )
\ No newline at end of file
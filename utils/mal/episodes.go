@@ -1,6 +1,7 @@
 package mal
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"metachan/utils/logger"
@@ -208,7 +209,7 @@ func extractEpisodeSynopsis(document *goquery.Document) string {
 	return synopsisText
 }
 
-func enrichEpisodesWithDetails(episodes []Episode, malID int) {
+func enrichEpisodesWithDetails(ctx context.Context, episodes []Episode, malID int) {
 	if len(episodes) == 0 {
 		return
 	}
@@ -219,6 +220,10 @@ func enrichEpisodesWithDetails(episodes []Episode, malID int) {
 	thumbnailsExtracted := false
 
 	for episodeIndex := range episodes {
+		if ctx.Err() != nil {
+			return
+		}
+
 		if episodes[episodeIndex].URL == "" {
 			continue
 		}
@@ -226,7 +231,7 @@ func enrichEpisodesWithDetails(episodes []Episode, malID int) {
 		logger.Debugf("MALScraper", "Fetching episode %d/%d detail page for MAL ID %d",
 			episodes[episodeIndex].Number, len(episodes), malID)
 
-		episodeDocument, fetchErr := makeRequest(episodes[episodeIndex].URL)
+		episodeDocument, fetchErr := makeRequest(ctx, episodes[episodeIndex].URL)
 		if fetchErr != nil {
 			logger.Warnf("MALClient", "Failed to fetch episode %d detail page for MAL ID %d: %v",
 				episodes[episodeIndex].Number, malID, fetchErr)
@@ -255,14 +260,14 @@ func enrichEpisodesWithDetails(episodes []Episode, malID int) {
 	}
 }
 
-func GetAnimeEpisodesByMALID(malID int) ([]Episode, error) {
+func GetAnimeEpisodesByMALID(ctx context.Context, malID int) ([]Episode, error) {
 	var allEpisodes []Episode
 	offset := 0
 
 	for {
 		pageURL := fmt.Sprintf("%s/anime/%d/_/episode?offset=%d", malBaseURL, malID, offset)
 		logger.Debugf("MALScraper", "Fetching episode list page at offset %d for MAL ID %d", offset, malID)
-		document, fetchErr := makeRequest(pageURL)
+		document, fetchErr := makeRequest(ctx, pageURL)
 		if fetchErr != nil {
 			if len(allEpisodes) > 0 {
 				logger.Warnf("MALClient", "Failed to fetch episodes page at offset %d for MAL ID %d: %v", offset, malID, fetchErr)
@@ -277,12 +282,17 @@ func GetAnimeEpisodesByMALID(malID int) ([]Episode, error) {
 			break
 		}
 
+		pageEpisodes := make([]Episode, 0, episodeRows.Length())
 		episodeRows.Each(func(index int, row *goquery.Selection) {
-			episode := parseEpisodeRow(row)
+			pageEpisodes = append(pageEpisodes, parseEpisodeRow(row))
+		})
+		recordParse(pageKindEpisodeList, malID, episodeListGaps(pageEpisodes))
+
+		for _, episode := range pageEpisodes {
 			if episode.Number > 0 {
 				allEpisodes = append(allEpisodes, episode)
 			}
-		})
+		}
 
 		nextPageLink := document.Find("a.link-blue-box.next")
 		if nextPageLink.Length() == 0 {
@@ -292,7 +302,7 @@ func GetAnimeEpisodesByMALID(malID int) ([]Episode, error) {
 		offset += 100
 	}
 
-	enrichEpisodesWithDetails(allEpisodes, malID)
+	enrichEpisodesWithDetails(ctx, allEpisodes, malID)
 
 	return allEpisodes, nil
 }
\ No newline at end of file
@@ -0,0 +1,27 @@
+package mal
+
+import (
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestParseEpisodeRow(t *testing.T) {
+	var episodes []Episode
+	loadDocument(t, "episode_list.html").Find("table.episode_list tbody tr").Each(func(index int, row *goquery.Selection) {
+		episodes = append(episodes, parseEpisodeRow(row))
+	})
+	assertGolden(t, "episode_list", episodes)
+
+	if missing := episodeListGaps(episodes); len(missing) > 0 {
+		t.Errorf("fixture parsed without %v", missing)
+	}
+}
+
+func TestExtractEpisodeThumbnailsFromScript(t *testing.T) {
+	document := loadDocument(t, "episode.html")
+	assertGolden(t, "episode", map[string]any{
+		"thumbnails": extractEpisodeThumbnailsFromScript(document),
+		"synopsis":   extractEpisodeSynopsis(document),
+	})
+}
@@ -0,0 +1,72 @@
+package mal
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"metachan/config"
+	"metachan/enums"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// The pages in testdata are trimmed copies of MAL markup. When MAL changes a
+// page, save the new markup over the fixture and run
+//
+//	go test ./utils/mal -update
+//
+// then review the golden diff before committing it.
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestMain replays cassettes, so that no test ever reaches MAL.
+func TestMain(m *testing.M) {
+	config.Upstream.Mode = string(enums.HTTPReplay)
+	os.Exit(m.Run())
+}
+
+func loadDocument(t *testing.T, name string) *goquery.Document {
+	t.Helper()
+
+	file, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to open fixture: %v", err)
+	}
+	defer file.Close()
+
+	document, err := goquery.NewDocumentFromReader(file)
+	if err != nil {
+		t.Fatalf("failed to parse fixture %s: %v", name, err)
+	}
+	return document
+}
+
+// assertGolden compares got, encoded as indented JSON, with
+// testdata/<name>.golden.json.
+func assertGolden(t *testing.T, name string, got any) {
+	t.Helper()
+
+	data, err := json.MarshalIndent(got, "", "  ")
+	if err != nil {
+		t.Fatalf("failed to encode result: %v", err)
+	}
+	data = append(data, '\n')
+
+	path := filepath.Join("testdata", name+".golden.json")
+	if *update {
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file, run with -update to create it: %v", err)
+	}
+	if !bytes.Equal(data, want) {
+		t.Errorf("result differs from %s\n got: %s\nwant: %s", path, data, want)
+	}
+}
@@ -0,0 +1,153 @@
+package mal
+
+import (
+	"maps"
+	"metachan/types"
+	"metachan/utils/logger"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	pageKindAnime       = "anime"
+	pageKindEpisodeList = "episode_list"
+
+	// parseHealthWindow is how many of the latest pages of a kind are looked
+	// at to decide whether parsing is drifting.
+	parseHealthWindow = 20
+	// minDriftSample keeps a couple of odd pages right after startup from
+	// marking a kind as drifting.
+	minDriftSample = 5
+)
+
+var parseHealth = struct {
+	mu    sync.Mutex
+	pages map[string]*pageHealth
+}{pages: make(map[string]*pageHealth)}
+
+// animePageGaps lists the fields every anime page has that came back empty.
+// Synopsis, score and the like are left out since new entries lack them.
+func animePageGaps(anime Anime) []string {
+	var missing []string
+	if anime.URL == "" {
+		missing = append(missing, "url")
+	}
+	if anime.Title.Romaji == "" {
+		missing = append(missing, "title")
+	}
+	if anime.Image.Original == "" {
+		missing = append(missing, "image")
+	}
+	if anime.Type == "" {
+		missing = append(missing, "type")
+	}
+	if anime.Status == "" {
+		missing = append(missing, "status")
+	}
+	if anime.Source == "" {
+		missing = append(missing, "source")
+	}
+	if anime.Statistics.Popularity == 0 {
+		missing = append(missing, "popularity")
+	}
+	if anime.Statistics.Members == 0 {
+		missing = append(missing, "members")
+	}
+	return missing
+}
+
+// episodeListGaps lists the fields that came back empty in any row of an
+// episode list page.
+func episodeListGaps(episodes []Episode) []string {
+	var missing []string
+	add := func(field string) {
+		if !slices.Contains(missing, field) {
+			missing = append(missing, field)
+		}
+	}
+
+	for _, episode := range episodes {
+		if episode.Number == 0 {
+			add("number")
+		}
+		if episode.URL == "" {
+			add("url")
+		}
+		if episode.Title.English == "" {
+			add("title")
+		}
+	}
+	return missing
+}
+
+// recordParse notes how a page of kind parsed and warns when it was missing
+// fields.
+func recordParse(kind string, malID int, missing []string) {
+	parseHealth.mu.Lock()
+	page := parseHealth.pages[kind]
+	if page == nil {
+		page = &pageHealth{missingFields: make(map[string]int64)}
+		parseHealth.pages[kind] = page
+	}
+
+	page.checked++
+	page.recent = append(page.recent, len(missing) > 0)
+	if len(page.recent) > parseHealthWindow {
+		page.recent = page.recent[1:]
+	}
+	if len(missing) > 0 {
+		page.flagged++
+		for _, field := range missing {
+			page.missingFields[field]++
+		}
+		page.lastFlagged = &types.FlaggedPage{MALID: malID, Missing: missing, At: time.Now()}
+	}
+	parseHealth.mu.Unlock()
+
+	if len(missing) > 0 {
+		logger.Warnf("MALScraper", "Parsed %s page for MAL ID %d without %s, the markup may have changed",
+			kind, malID, strings.Join(missing, ", "))
+	}
+}
+
+// ParseHealth returns how each kind of MAL page has been parsing, sorted by
+// kind.
+func ParseHealth() []types.ParseHealth {
+	parseHealth.mu.Lock()
+	defer parseHealth.mu.Unlock()
+
+	report := make([]types.ParseHealth, 0, len(parseHealth.pages))
+	for kind, page := range parseHealth.pages {
+		recentFlagged := 0
+		for _, flagged := range page.recent {
+			if flagged {
+				recentFlagged++
+			}
+		}
+
+		health := types.ParseHealth{
+			Page:          kind,
+			Checked:       page.checked,
+			Flagged:       page.flagged,
+			Recent:        len(page.recent),
+			RecentFlagged: recentFlagged,
+			Drifting:      len(page.recent) >= minDriftSample && recentFlagged*2 >= len(page.recent),
+		}
+		if len(page.missingFields) > 0 {
+			health.MissingFields = maps.Clone(page.missingFields)
+		}
+		if page.lastFlagged != nil {
+			lastFlagged := *page.lastFlagged
+			health.LastFlagged = &lastFlagged
+		}
+		report = append(report, health)
+	}
+
+	sort.Slice(report, func(i, j int) bool {
+		return report[i].Page < report[j].Page
+	})
+	return report
+}
@@ -0,0 +1,61 @@
+package mal
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// TestAnimePageGapsOnDrift renames the sidebar labels the way a MAL redesign
+// would and expects the check to name every field that went missing.
+func TestAnimePageGapsOnDrift(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "anime.html"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	drifted := strings.ReplaceAll(string(data), `class="dark_text"`, `class="sidebar-label"`)
+
+	document, err := goquery.NewDocumentFromReader(strings.NewReader(drifted))
+	if err != nil {
+		t.Fatalf("failed to parse fixture: %v", err)
+	}
+
+	missing := animePageGaps(parseAnimeDocument(document, 1))
+	want := []string{"type", "status", "source", "popularity", "members"}
+	if !slices.Equal(missing, want) {
+		t.Errorf("missing = %v, want %v", missing, want)
+	}
+}
+
+func TestParseHealthDrifting(t *testing.T) {
+	parseHealth.mu.Lock()
+	parseHealth.pages = make(map[string]*pageHealth)
+	parseHealth.mu.Unlock()
+
+	for malID := 1; malID <= minDriftSample-1; malID++ {
+		recordParse(pageKindAnime, malID, []string{"status"})
+	}
+	if report := ParseHealth(); len(report) != 1 || report[0].Drifting {
+		t.Fatalf("drifting before %d pages were seen: %+v", minDriftSample, report)
+	}
+
+	recordParse(pageKindAnime, minDriftSample, nil)
+	report := ParseHealth()[0]
+	if !report.Drifting || report.Checked != minDriftSample || report.Flagged != minDriftSample-1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if report.MissingFields["status"] != minDriftSample-1 || report.LastFlagged.MALID != minDriftSample-1 {
+		t.Errorf("unexpected flagged details: %+v", report)
+	}
+
+	for malID := 0; malID < parseHealthWindow; malID++ {
+		recordParse(pageKindAnime, malID, nil)
+	}
+	if report := ParseHealth()[0]; report.Drifting || report.Recent != parseHealthWindow || report.RecentFlagged != 0 {
+		t.Errorf("still drifting after a window of clean pages: %+v", report)
+	}
+}
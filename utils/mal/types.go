@@ -1,5 +1,7 @@
 package mal
 
+import "metachan/types"
+
 type Image struct {
 	Small    string
 	Medium   string
@@ -100,6 +102,19 @@ type Episode struct {
 	Preview  Preview
 }
 
+// RelatedEntry is one anime or manga listed under Related Entries. Kind is
+// "anime" or "manga" and Format is the type MAL shows next to it, such as
+// Movie or Light Novel.
+type RelatedEntry struct {
+	Relation RelationType
+	Kind     string
+	MALID    int
+	Title    string
+	Format   string
+	URL      string
+	Image    Image
+}
+
 type Anime struct {
 	MALID        int
 	URL          string
@@ -125,6 +140,7 @@ type Anime struct {
 	Videos      []PromotionalVideo
 	MusicVideos []MusicVideo
 	Episodes    []Episode
+	Relations   []RelatedEntry
 
 	Genres         []int
 	ExplicitGenres []int
@@ -136,4 +152,15 @@ type Anime struct {
 
 	External  []ExternalLink
 	Streaming []ExternalLink
+}
+
+// pageHealth counts how often one kind of page parsed with expected fields
+// missing. recent holds whether each of the latest pages was flagged, oldest
+// first.
+type pageHealth struct {
+	checked       int64
+	flagged       int64
+	recent        []bool
+	missingFields map[string]int64
+	lastFlagged   *types.FlaggedPage
 }
\ No newline at end of file
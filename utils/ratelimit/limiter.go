@@ -1,62 +1,196 @@
 package ratelimit
 
 import (
+	"context"
+	"metachan/types"
+	"sort"
+	"sync"
 	"time"
 )
 
-func NewRateLimiter(maxRequests int, window time.Duration) *RateLimiter {
-	interval := window / time.Duration(maxRequests)
-	rl := &RateLimiter{
-		tokens: make(chan struct{}, maxRequests),
-		done:   make(chan struct{}),
-	}
-	rl.tokens <- struct{}{}
-	go func() {
-		ticker := time.NewTicker(interval)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ticker.C:
-				select {
-				case rl.tokens <- struct{}{}:
-				default:
-				}
-			case <-rl.done:
-				return
-			}
+const (
+	// maxSlowdown caps how far repeated 429s can divide a limiter's rate.
+	maxSlowdown = 8
+	// slowdownRecovery is how long a limiter has to go without a 429 before
+	// its rate is doubled again on the way back to the configured one.
+	slowdownRecovery = time.Minute
+)
+
+var registry struct {
+	mu       sync.Mutex
+	limiters []*Limiter
+}
+
+// NewLimiter returns a limiter that satisfies every rule at once. It is
+// listed by AllStats under name.
+func NewLimiter(name string, rules ...Rule) *Limiter {
+	now := time.Now()
+	l := &Limiter{
+		name:       name,
+		buckets:    make([]bucket, 0, len(rules)),
+		refilledAt: now,
+		slowdown:   1,
+	}
+	for _, rule := range rules {
+		burst := rule.Burst
+		if burst <= 0 {
+			burst = rule.Limit
 		}
-	}()
-	return rl
+		l.buckets = append(l.buckets, bucket{
+			rate:     float64(rule.Limit) / rule.Window.Seconds(),
+			capacity: float64(burst),
+			tokens:   float64(burst),
+		})
+	}
+
+	registry.mu.Lock()
+	registry.limiters = append(registry.limiters, l)
+	registry.mu.Unlock()
+
+	return l
 }
 
-func (r *RateLimiter) Wait() {
-	<-r.tokens
+// Wait blocks until a request may be sent or ctx is done. Callers queue by
+// the Priority in ctx, interactive ones first.
+func (l *Limiter) Wait(ctx context.Context) error {
+	startTime := time.Now()
+	if err := l.gate.acquire(ctx); err != nil {
+		l.cancelled.Add(1)
+		return err
+	}
+	defer l.gate.release()
+
+	for {
+		delay := l.reserve(time.Now())
+		if delay <= 0 {
+			break
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			l.cancelled.Add(1)
+			return ctx.Err()
+		}
+	}
+
+	l.allowed.Add(1)
+	if waited := time.Since(startTime); waited > time.Millisecond {
+		l.delayed.Add(1)
+		l.waited.Add(int64(waited))
+	}
+	return nil
 }
 
-func (r *RateLimiter) Stop() {
-	close(r.done)
+// Throttle is called when the upstream answers 429. Nothing is let through
+// for retryAfter, the buckets are emptied so that no burst follows the pause,
+// and the rate is halved until the upstream has been quiet for a while.
+func (l *Limiter) Throttle(retryAfter time.Duration) {
+	now := time.Now()
+
+	l.mu.Lock()
+	l.refill(now)
+	if until := now.Add(retryAfter); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+	for i := range l.buckets {
+		l.buckets[i].tokens = min(l.buckets[i].tokens, 0)
+	}
+	l.slowdown = min(l.slowdown*2, maxSlowdown)
+	l.slowedAt = now
+	l.mu.Unlock()
+
+	l.throttled.Add(1)
 }
 
-func (r *RateLimiter) RemainingRequests() int {
-	return len(r.tokens)
+func (l *Limiter) Stats() types.RateLimiterStats {
+	now := time.Now()
+
+	l.mu.Lock()
+	l.refill(now)
+	stats := types.RateLimiterStats{
+		Name:       l.name,
+		RateFactor: 1 / l.slowdown,
+	}
+	if l.pausedUntil.After(now) {
+		pausedUntil := l.pausedUntil
+		stats.PausedUntil = &pausedUntil
+	}
+	l.mu.Unlock()
+
+	stats.Allowed = l.allowed.Load()
+	stats.Delayed = l.delayed.Load()
+	stats.WaitSeconds = time.Duration(l.waited.Load()).Seconds()
+	stats.Throttled = l.throttled.Load()
+	stats.Cancelled = l.cancelled.Load()
+	return stats
 }
 
-func NewMultiLimiter(limiters ...*RateLimiter) *MultiLimiter {
-	return &MultiLimiter{
-		limiters: limiters,
+// AllStats returns the counters of every limiter, sorted by name.
+func AllStats() []types.RateLimiterStats {
+	registry.mu.Lock()
+	limiters := append([]*Limiter(nil), registry.limiters...)
+	registry.mu.Unlock()
+
+	stats := make([]types.RateLimiterStats, len(limiters))
+	for i, l := range limiters {
+		stats[i] = l.Stats()
 	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Name < stats[j].Name
+	})
+	return stats
 }
 
-func (m *MultiLimiter) Wait() {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	for _, limiter := range m.limiters {
-		limiter.Wait()
+// reserve takes a token from every bucket and returns zero, or returns how
+// long to wait before trying again without taking anything.
+func (l *Limiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill(now)
+	if now.Before(l.pausedUntil) {
+		return l.pausedUntil.Sub(now)
+	}
+
+	var delay time.Duration
+	for _, b := range l.buckets {
+		if b.tokens < 1 {
+			missing := (1 - b.tokens) / (b.rate / l.slowdown)
+			delay = max(delay, time.Duration(missing*float64(time.Second)))
+		}
+	}
+	if delay > 0 {
+		return delay
 	}
+
+	for i := range l.buckets {
+		l.buckets[i].tokens--
+	}
+	return 0
 }
 
-func (m *MultiLimiter) Stop() {
-	for _, limiter := range m.limiters {
-		limiter.Stop()
+func (l *Limiter) refill(now time.Time) {
+	for l.slowdown > 1 && now.Sub(l.slowedAt) >= slowdownRecovery {
+		l.slowdown = max(l.slowdown/2, 1)
+		l.slowedAt = l.slowedAt.Add(slowdownRecovery)
+	}
+
+	// Tokens do not accrue while paused, which keeps the buckets empty until
+	// the Retry-After has passed.
+	from := l.refilledAt
+	if from.Before(l.pausedUntil) {
+		from = l.pausedUntil
+	}
+	if elapsed := now.Sub(from).Seconds(); elapsed > 0 {
+		for i := range l.buckets {
+			b := &l.buckets[i]
+			b.tokens = min(b.tokens+elapsed*b.rate/l.slowdown, b.capacity)
+		}
+	}
+	if now.After(l.refilledAt) {
+		l.refilledAt = now
 	}
 }
@@ -0,0 +1,228 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// near reports whether got is within a millisecond of want, which absorbs
+// float rounding in the bucket arithmetic.
+func near(got, want time.Duration) bool {
+	return got >= want-time.Millisecond && got <= want+time.Millisecond
+}
+
+func TestReserveBurstAndRefill(t *testing.T) {
+	l := NewLimiter("test-rules", Rule{Limit: 3, Window: time.Second}, Rule{Limit: 5, Window: time.Minute})
+	start := l.refilledAt
+
+	for i := 0; i < 3; i++ {
+		if delay := l.reserve(start); delay != 0 {
+			t.Fatalf("request %d delayed by %s within the burst", i+1, delay)
+		}
+	}
+	if delay := l.reserve(start); !near(delay, time.Second/3) {
+		t.Errorf("fourth request delayed by %s, want a third of a second", delay)
+	}
+
+	// A second later the per-second bucket is full again, but the per-minute
+	// one only has two tokens left.
+	later := start.Add(time.Second)
+	for i := 0; i < 2; i++ {
+		if delay := l.reserve(later); delay != 0 {
+			t.Fatalf("request %d delayed by %s with tokens left", i+4, delay)
+		}
+	}
+	if delay := l.reserve(later); !near(delay, 11*time.Second) {
+		t.Errorf("sixth request delayed by %s, want 11s from the per-minute rule", delay)
+	}
+}
+
+func TestReserveBurst(t *testing.T) {
+	l := NewLimiter("test-burst", Rule{Limit: 10, Window: time.Second, Burst: 2})
+	start := l.refilledAt
+
+	for i := 0; i < 2; i++ {
+		if delay := l.reserve(start); delay != 0 {
+			t.Fatalf("request %d delayed by %s within the burst", i+1, delay)
+		}
+	}
+	if delay := l.reserve(start); !near(delay, 100*time.Millisecond) {
+		t.Errorf("request after the burst delayed by %s, want 100ms", delay)
+	}
+
+	// A long quiet spell refills no more than the burst.
+	later := start.Add(time.Hour)
+	for i := 0; i < 2; i++ {
+		if delay := l.reserve(later); delay != 0 {
+			t.Fatalf("request %d delayed by %s after a quiet spell", i+1, delay)
+		}
+	}
+	if delay := l.reserve(later); delay == 0 {
+		t.Error("quiet spell refilled more than the burst")
+	}
+}
+
+func TestThrottlePausesAndEmpties(t *testing.T) {
+	l := NewLimiter("test-throttle", Rule{Limit: 10, Window: time.Second})
+	l.Throttle(2 * time.Second)
+
+	l.mu.Lock()
+	pausedUntil, slowedAt := l.pausedUntil, l.slowedAt
+	l.mu.Unlock()
+
+	if delay := l.reserve(slowedAt); !near(delay, 2*time.Second) {
+		t.Errorf("request during the pause delayed by %s, want the 2s Retry-After", delay)
+	}
+
+	// Nothing accrued during the pause, and the rate is halved to 5 a second.
+	if delay := l.reserve(pausedUntil); !near(delay, 200*time.Millisecond) {
+		t.Errorf("request after the pause delayed by %s, want 200ms at half rate", delay)
+	}
+	if delay := l.reserve(pausedUntil.Add(200 * time.Millisecond)); delay != 0 {
+		t.Errorf("request after one token at half rate delayed by %s", delay)
+	}
+
+	stats := l.Stats()
+	if stats.Throttled != 1 || stats.RateFactor != 0.5 || stats.PausedUntil == nil {
+		t.Errorf("unexpected stats after a throttle: %+v", stats)
+	}
+}
+
+func TestThrottleKeepsLongerPause(t *testing.T) {
+	l := NewLimiter("test-throttle-pause", Rule{Limit: 10, Window: time.Second})
+	l.Throttle(time.Minute)
+	l.Throttle(time.Second)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if remaining := time.Until(l.pausedUntil); remaining < 50*time.Second {
+		t.Errorf("a shorter Retry-After cut the pause to %s", remaining)
+	}
+}
+
+func TestSlowdownRecovery(t *testing.T) {
+	l := NewLimiter("test-recovery", Rule{Limit: 10, Window: time.Second})
+	for i := 0; i < 5; i++ {
+		l.Throttle(0)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.slowdown != maxSlowdown {
+		t.Fatalf("slowdown = %v after repeated throttles, want the %v cap", l.slowdown, float64(maxSlowdown))
+	}
+
+	slowedAt := l.slowedAt
+	for _, step := range []struct {
+		after time.Duration
+		want  float64
+	}{
+		{slowdownRecovery - time.Second, 8},
+		{slowdownRecovery, 4},
+		{2 * slowdownRecovery, 2},
+		{10 * slowdownRecovery, 1},
+	} {
+		l.refill(slowedAt.Add(step.after))
+		if l.slowdown != step.want {
+			t.Errorf("slowdown = %v after %s quiet, want %v", l.slowdown, step.after, step.want)
+		}
+	}
+}
+
+func TestWaitCancelledWhileQueued(t *testing.T) {
+	l := NewLimiter("test-cancel", Rule{Limit: 1, Window: time.Hour})
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+
+	// The next caller holds the gate while it waits an hour for a token.
+	holderCtx, cancelHolder := context.WithCancel(context.Background())
+	holderDone := make(chan error, 1)
+	go func() {
+		holderDone <- l.Wait(holderCtx)
+	}()
+	waitForHolder(t, &l.gate)
+
+	queuedCtx, cancelQueued := context.WithCancel(context.Background())
+	queuedDone := make(chan error, 1)
+	go func() {
+		queuedDone <- l.Wait(queuedCtx)
+	}()
+	waitForWaiters(t, &l.gate, 1)
+
+	cancelQueued()
+	if err := receive(t, queuedDone); !errors.Is(err, context.Canceled) {
+		t.Errorf("queued caller returned %v, want context.Canceled", err)
+	}
+	if waiters := countWaiters(&l.gate); waiters != 0 {
+		t.Errorf("%d waiters left queued after cancelling", waiters)
+	}
+
+	cancelHolder()
+	if err := receive(t, holderDone); !errors.Is(err, context.Canceled) {
+		t.Errorf("waiting caller returned %v, want context.Canceled", err)
+	}
+
+	// Both cancellations let go of the gate.
+	l.gate.mu.Lock()
+	busy := l.gate.busy
+	l.gate.mu.Unlock()
+	if busy {
+		t.Error("gate still busy after every caller left")
+	}
+
+	if stats := l.Stats(); stats.Allowed != 1 || stats.Cancelled != 2 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func waitForHolder(t *testing.T, g *gate) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		g.mu.Lock()
+		busy := g.busy
+		g.mu.Unlock()
+		if busy {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("nobody took the gate")
+}
+
+func waitForWaiters(t *testing.T, g *gate, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if countWaiters(g) == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("%d callers queued, want %d", countWaiters(g), n)
+}
+
+func countWaiters(g *gate) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.waiters)
+}
+
+func receive[T any](t *testing.T, results <-chan T) T {
+	t.Helper()
+
+	select {
+	case result := <-results:
+		return result
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the caller")
+		var zero T
+		return zero
+	}
+}
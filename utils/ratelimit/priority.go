@@ -0,0 +1,108 @@
+package ratelimit
+
+import (
+	"context"
+	"slices"
+)
+
+// WithPriority tags ctx so that limiters serve the call at that priority.
+func WithPriority(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// WithEscalation tags ctx so that limiters serve the call at whatever
+// priority the escalation has reached.
+func WithEscalation(ctx context.Context, escalation *Escalation) context.Context {
+	return context.WithValue(ctx, priorityKey{}, escalation)
+}
+
+func PriorityFrom(ctx context.Context) Priority {
+	switch priority := ctx.Value(priorityKey{}).(type) {
+	case Priority:
+		return priority
+	case *Escalation:
+		return priority.Priority()
+	default:
+		return Interactive
+	}
+}
+
+func NewEscalation(priority Priority) *Escalation {
+	escalation := &Escalation{}
+	escalation.priority.Store(int64(priority))
+	return escalation
+}
+
+func (e *Escalation) Priority() Priority {
+	return Priority(e.priority.Load())
+}
+
+// Raise moves the escalation up to priority. A lower one is ignored.
+func (e *Escalation) Raise(priority Priority) {
+	for {
+		current := e.priority.Load()
+		if int64(priority) >= current || e.priority.CompareAndSwap(current, int64(priority)) {
+			return
+		}
+	}
+}
+
+func (g *gate) acquire(ctx context.Context) error {
+	g.mu.Lock()
+	if !g.busy {
+		g.busy = true
+		g.mu.Unlock()
+		return nil
+	}
+
+	turn := make(chan struct{})
+	g.waiters = append(g.waiters, gateWaiter{
+		turn: turn,
+		priority: func() Priority {
+			return min(max(PriorityFrom(ctx), Interactive), Background)
+		},
+	})
+	g.mu.Unlock()
+
+	select {
+	case <-turn:
+		return nil
+	case <-ctx.Done():
+		g.mu.Lock()
+		index := slices.IndexFunc(g.waiters, func(waiter gateWaiter) bool {
+			return waiter.turn == turn
+		})
+		if index >= 0 {
+			g.waiters = slices.Delete(g.waiters, index, index+1)
+		}
+		g.mu.Unlock()
+
+		// The turn was handed over just as ctx ended, so pass it on.
+		if index < 0 {
+			g.release()
+		}
+		return ctx.Err()
+	}
+}
+
+// release hands the turn to the longest waiting caller of the highest
+// priority, or frees the gate when nobody is waiting.
+func (g *gate) release() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	next := -1
+	var nextPriority Priority
+	for i, waiter := range g.waiters {
+		if priority := waiter.priority(); next == -1 || priority < nextPriority {
+			next, nextPriority = i, priority
+		}
+	}
+	if next == -1 {
+		g.busy = false
+		return
+	}
+
+	close(g.waiters[next].turn)
+	g.waiters = slices.Delete(g.waiters, next, next+1)
+}
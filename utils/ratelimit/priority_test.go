@@ -0,0 +1,104 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPriorityFrom(t *testing.T) {
+	escalation := NewEscalation(Background)
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want Priority
+	}{
+		{"unset", context.Background(), Interactive},
+		{"interactive", WithPriority(context.Background(), Interactive), Interactive},
+		{"background", WithPriority(context.Background(), Background), Background},
+		{"escalation", WithEscalation(context.Background(), escalation), Background},
+	}
+	for _, test := range tests {
+		if got := PriorityFrom(test.ctx); got != test.want {
+			t.Errorf("%s: PriorityFrom = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestEscalationRaise(t *testing.T) {
+	escalation := NewEscalation(Background)
+	ctx := WithEscalation(context.Background(), escalation)
+
+	escalation.Raise(Interactive)
+	if got := PriorityFrom(ctx); got != Interactive {
+		t.Errorf("PriorityFrom = %v after raising, want Interactive", got)
+	}
+
+	escalation.Raise(Background)
+	if got := escalation.Priority(); got != Interactive {
+		t.Errorf("Priority = %v after a lower raise, want Interactive", got)
+	}
+}
+
+// queue starts a caller on g and waits until it is queued behind the ones
+// already waiting. name is sent on order once the caller gets the turn.
+func queue(t *testing.T, g *gate, ctx context.Context, name string, order chan<- string) {
+	t.Helper()
+
+	queued := countWaiters(g)
+	go func() {
+		if err := g.acquire(ctx); err == nil {
+			order <- name
+		}
+	}()
+	waitForWaiters(t, g, queued+1)
+}
+
+func TestGateServesInteractiveFirst(t *testing.T) {
+	var g gate
+	if err := g.acquire(context.Background()); err != nil {
+		t.Fatalf("acquire on a free gate: %v", err)
+	}
+
+	background := WithPriority(context.Background(), Background)
+	order := make(chan string, 3)
+	queue(t, &g, background, "background 1", order)
+	queue(t, &g, background, "background 2", order)
+	queue(t, &g, context.Background(), "interactive", order)
+
+	for _, want := range []string{"interactive", "background 1", "background 2"} {
+		g.release()
+		if got := receive(t, order); got != want {
+			t.Fatalf("turn went to %s, want %s", got, want)
+		}
+	}
+
+	g.release()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.busy {
+		t.Error("gate still busy with nobody waiting")
+	}
+}
+
+func TestGateServesEscalatedWaiter(t *testing.T) {
+	var g gate
+	if err := g.acquire(context.Background()); err != nil {
+		t.Fatalf("acquire on a free gate: %v", err)
+	}
+
+	escalation := NewEscalation(Background)
+	order := make(chan string, 2)
+	queue(t, &g, WithPriority(context.Background(), Background), "background", order)
+	queue(t, &g, WithEscalation(context.Background(), escalation), "escalated", order)
+
+	// An interactive caller joins the shared work after it queued.
+	escalation.Raise(Interactive)
+
+	for _, want := range []string{"escalated", "background"} {
+		g.release()
+		if got := receive(t, order); got != want {
+			t.Fatalf("turn went to %s, want %s", got, want)
+		}
+	}
+}
@@ -2,14 +2,74 @@ package ratelimit
 
 import (
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
-type RateLimiter struct {
-	tokens chan struct{}
-	done   chan struct{}
+// Priority orders callers waiting on the same limiter. The zero value is
+// Interactive, so requests that never set a priority are not starved.
+type Priority int
+
+const (
+	Interactive Priority = iota
+	Background
+)
+
+type priorityKey struct{}
+
+// Escalation is a priority shared by work done for several callers at once.
+// Raise moves it up when a more urgent caller joins, and calls already
+// queued with it move up too.
+type Escalation struct {
+	priority atomic.Int64
+}
+
+// gate lets one caller through at a time, handing the turn to interactive
+// waiters before background ones.
+type gate struct {
+	mu      sync.Mutex
+	busy    bool
+	waiters []gateWaiter
+}
+
+// gateWaiter is a queued caller. Its priority is read when the turn is
+// handed over, so that an escalated caller is served as such.
+type gateWaiter struct {
+	turn     chan struct{}
+	priority func() Priority
+}
+
+// Rule allows Limit requests per Window. Burst is how many of them may be
+// sent back to back after a quiet spell and defaults to Limit.
+type Rule struct {
+	Limit  int
+	Window time.Duration
+	Burst  int
 }
 
-type MultiLimiter struct {
-	mu       sync.Mutex
-	limiters []*RateLimiter
+type bucket struct {
+	rate     float64
+	capacity float64
+	tokens   float64
+}
+
+// Limiter is a token bucket per Rule. A request takes one token from every
+// bucket, so "3 per second and 60 per minute" is enforced by one limiter.
+// Throttle pauses it and lowers its rate when the upstream pushes back.
+type Limiter struct {
+	name string
+	gate gate
+
+	mu          sync.Mutex
+	buckets     []bucket
+	refilledAt  time.Time
+	pausedUntil time.Time
+	slowdown    float64
+	slowedAt    time.Time
+
+	allowed   atomic.Int64
+	delayed   atomic.Int64
+	waited    atomic.Int64
+	throttled atomic.Int64
+	cancelled atomic.Int64
 }
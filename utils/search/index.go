@@ -0,0 +1,242 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	exactTokenScore  = 1.0
+	prefixTokenScore = 0.8
+	typoTokenScore   = 0.6
+	phraseBonus      = 0.5
+	exactTitleBonus  = 1.0
+	minimumScore     = 0.5
+)
+
+func NewIndex() *Index {
+	return &Index{
+		documents: make(map[uint][]string),
+		postings:  make(map[string]map[uint]struct{}),
+	}
+}
+
+// Normalize lowercases text, strips diacritics and collapses punctuation so
+// that "Shingeki no Kyojin: The Final Season" and "shingeki no kyojin the
+// final season" produce the same tokens.
+func Normalize(text string) string {
+	decomposed := norm.NFKD.String(text)
+
+	var builder strings.Builder
+	builder.Grow(len(decomposed))
+	lastWasSpace := true
+	for _, r := range decomposed {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			builder.WriteRune(unicode.ToLower(r))
+			lastWasSpace = false
+		default:
+			if !lastWasSpace {
+				builder.WriteByte(' ')
+				lastWasSpace = true
+			}
+		}
+	}
+
+	return strings.TrimSpace(builder.String())
+}
+
+func Tokenize(text string) []string {
+	return strings.Fields(Normalize(text))
+}
+
+func (idx *Index) Add(id uint, titles ...string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(id)
+
+	normalized := make([]string, 0, len(titles))
+	for _, title := range titles {
+		if value := Normalize(title); value != "" {
+			normalized = append(normalized, value)
+		}
+	}
+	if len(normalized) == 0 {
+		return
+	}
+
+	idx.documents[id] = normalized
+	for _, title := range normalized {
+		for _, token := range strings.Fields(title) {
+			if idx.postings[token] == nil {
+				idx.postings[token] = make(map[uint]struct{})
+			}
+			idx.postings[token][id] = struct{}{}
+		}
+	}
+}
+
+func (idx *Index) remove(id uint) {
+	titles, exists := idx.documents[id]
+	if !exists {
+		return
+	}
+
+	for _, title := range titles {
+		for _, token := range strings.Fields(title) {
+			delete(idx.postings[token], id)
+			if len(idx.postings[token]) == 0 {
+				delete(idx.postings, token)
+			}
+		}
+	}
+	delete(idx.documents, id)
+}
+
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return len(idx.documents)
+}
+
+// Search ranks documents by how well their titles cover the query tokens.
+// Each query token is matched against the vocabulary exactly, as a prefix, or
+// within a small edit distance, so minor typos still find the right title.
+func (idx *Index) Search(query string, limit int) []Hit {
+	phrase := Normalize(query)
+	queryTokens := strings.Fields(phrase)
+	if len(queryTokens) == 0 {
+		return nil
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	scores := make(map[uint]float64)
+	for _, queryToken := range queryTokens {
+		best := make(map[uint]float64)
+		for token, documentIDs := range idx.postings {
+			tokenScore := matchToken(queryToken, token)
+			if tokenScore == 0 {
+				continue
+			}
+			for id := range documentIDs {
+				if tokenScore > best[id] {
+					best[id] = tokenScore
+				}
+			}
+		}
+		for id, tokenScore := range best {
+			scores[id] += tokenScore / float64(len(queryTokens))
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		for _, title := range idx.documents[id] {
+			if title == phrase {
+				score += exactTitleBonus
+				break
+			}
+			if strings.Contains(title, phrase) {
+				score += phraseBonus
+				break
+			}
+		}
+		if score >= minimumScore {
+			hits = append(hits, Hit{ID: id, Score: score})
+		}
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score == hits[j].Score {
+			return hits[i].ID < hits[j].ID
+		}
+		return hits[i].Score > hits[j].Score
+	})
+
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	return hits
+}
+
+func matchToken(queryToken, token string) float64 {
+	if queryToken == token {
+		return exactTokenScore
+	}
+	if strings.HasPrefix(token, queryToken) {
+		return prefixTokenScore
+	}
+
+	allowed := allowedEdits(queryToken)
+	if allowed == 0 {
+		return 0
+	}
+
+	queryLength, tokenLength := len([]rune(queryToken)), len([]rune(token))
+	if tokenLength-queryLength > allowed || queryLength-tokenLength > allowed {
+		return 0
+	}
+
+	if editDistance(queryToken, token, allowed) <= allowed {
+		return typoTokenScore
+	}
+
+	return 0
+}
+
+func allowedEdits(token string) int {
+	switch length := len([]rune(token)); {
+	case length >= 8:
+		return 2
+	case length >= 4:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// editDistance returns the optimal string alignment distance between a and b,
+// which counts a transposition of adjacent characters ("freiren") as a single
+// edit. It gives up early with limit+1 once every cell in a row exceeds limit.
+func editDistance(a, b string, limit int) int {
+	source, target := []rune(a), []rune(b)
+
+	beforePrevious := make([]int, len(target)+1)
+	previous := make([]int, len(target)+1)
+	current := make([]int, len(target)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(source); i++ {
+		current[0] = i
+		rowMinimum := current[0]
+		for j := 1; j <= len(target); j++ {
+			cost := 1
+			if source[i-1] == target[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+			if i > 1 && j > 1 && source[i-1] == target[j-2] && source[i-2] == target[j-1] {
+				current[j] = min(current[j], beforePrevious[j-2]+1)
+			}
+			rowMinimum = min(rowMinimum, current[j])
+		}
+		if rowMinimum > limit {
+			return limit + 1
+		}
+		beforePrevious, previous, current = previous, current, beforePrevious
+	}
+
+	return previous[len(target)]
+}
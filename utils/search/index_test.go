@@ -0,0 +1,126 @@
+package search
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Shingeki no Kyojin: The Final Season", "shingeki no kyojin the final season"},
+		{"  Pokémon -- Mewtwo  ", "pokemon mewtwo"},
+		{"Ｆｕｌｌ Ｍｅｔａｌ", "full metal"},
+		{"!!!", ""},
+	}
+
+	for _, test := range tests {
+		if got := Normalize(test.text); got != test.want {
+			t.Errorf("Normalize(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		limit int
+		want  int
+	}{
+		{"frieren", "frieren", 2, 0},
+		{"frieren", "frieran", 2, 1},
+		{"frieren", "frierens", 2, 1},
+		{"frieren", "freren", 2, 1},
+		{"frieren", "freiren", 2, 1},
+		{"naruto", "nartuo", 2, 1},
+		{"ca", "abc", 3, 3},
+		{"gintama", "gnitmaa", 2, 2},
+		{"bleach", "naruto", 2, 3},
+		{"", "abc", 5, 3},
+		{"ヴァイオレット", "ヴァイオレシト", 1, 1},
+	}
+
+	for _, test := range tests {
+		if got := editDistance(test.a, test.b, test.limit); got != test.want {
+			t.Errorf("editDistance(%q, %q, %d) = %d, want %d", test.a, test.b, test.limit, got, test.want)
+		}
+	}
+}
+
+func TestMatchToken(t *testing.T) {
+	tests := []struct {
+		query, token string
+		want         float64
+	}{
+		{"naruto", "naruto", exactTokenScore},
+		{"nar", "naruto", prefixTokenScore},
+		{"nartuo", "naruto", typoTokenScore},
+		{"narutoo", "naruto", typoTokenScore},
+		// Short tokens must match exactly or as a prefix.
+		{"oen", "one", 0},
+		{"bleach", "naruto", 0},
+	}
+
+	for _, test := range tests {
+		if got := matchToken(test.query, test.token); got != test.want {
+			t.Errorf("matchToken(%q, %q) = %v, want %v", test.query, test.token, got, test.want)
+		}
+	}
+}
+
+func TestSearchRanking(t *testing.T) {
+	idx := NewIndex()
+	idx.Add(1, "Sousou no Frieren", "Frieren: Beyond Journey's End")
+	idx.Add(2, "Frieren")
+	idx.Add(3, "Naruto", "NARUTO")
+	idx.Add(4, "Naruto: Shippuuden")
+	idx.Add(5, "Boruto: Naruto Next Generations")
+
+	tests := []struct {
+		query string
+		want  []uint
+	}{
+		// An exact title beats a title containing the phrase.
+		{"frieren", []uint{2, 1}},
+		{"naruto", []uint{3, 4, 5}},
+		{"naruto shippuuden", []uint{4, 3, 5}},
+		{"freiren", []uint{1, 2}},
+		{"nothing like it", nil},
+	}
+
+	for _, test := range tests {
+		hits := idx.Search(test.query, 0)
+		if len(hits) != len(test.want) {
+			t.Errorf("Search(%q) returned %v, want ids %v", test.query, hits, test.want)
+			continue
+		}
+		for i, hit := range hits {
+			if hit.ID != test.want[i] {
+				t.Errorf("Search(%q) returned %v, want ids %v", test.query, hits, test.want)
+				break
+			}
+		}
+	}
+}
+
+func TestSearchLimitAndUpdate(t *testing.T) {
+	idx := NewIndex()
+	for id := uint(1); id <= 5; id++ {
+		idx.Add(id, "Gundam")
+	}
+
+	if hits := idx.Search("gundam", 2); len(hits) != 2 || hits[0].ID != 1 || hits[1].ID != 2 {
+		t.Errorf("Search with a limit of 2 returned %v", hits)
+	}
+
+	// Adding a document again replaces its titles.
+	idx.Add(3, "Macross")
+	if hits := idx.Search("gundam", 0); len(hits) != 4 {
+		t.Errorf("Search returned %d hits after retitling, want 4", len(hits))
+	}
+	if hits := idx.Search("macross", 0); len(hits) != 1 || hits[0].ID != 3 {
+		t.Errorf("Search for the new title returned %v", hits)
+	}
+	if idx.Len() != 5 {
+		t.Errorf("Len = %d, want 5", idx.Len())
+	}
+}
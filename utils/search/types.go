@@ -0,0 +1,14 @@
+package search
+
+import "sync"
+
+type Index struct {
+	mu        sync.RWMutex
+	documents map[uint][]string
+	postings  map[string]map[uint]struct{}
+}
+
+type Hit struct {
+	ID    uint
+	Score float64
+}
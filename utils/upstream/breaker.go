@@ -0,0 +1,153 @@
+package upstream
+
+import (
+	"errors"
+	"fmt"
+	"metachan/enums"
+	"metachan/types"
+	"metachan/utils/logger"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	breakerThreshold = 5
+	breakerCooldown  = 30 * time.Second
+)
+
+// ErrCircuitOpen is returned without sending anything while an upstream's
+// breaker is open.
+var ErrCircuitOpen = errors.New("circuit open")
+
+var breakers struct {
+	mu   sync.Mutex
+	list []*CircuitBreaker
+}
+
+// NewBreaker returns a closed breaker that is listed by BreakerStatuses.
+func NewBreaker(name enums.Upstream) *CircuitBreaker {
+	b := &CircuitBreaker{
+		name:  name,
+		state: enums.BreakerClosed,
+	}
+
+	breakers.mu.Lock()
+	breakers.list = append(breakers.list, b)
+	breakers.mu.Unlock()
+
+	return b
+}
+
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	switch b.state {
+	case enums.BreakerOpen:
+		if now.Sub(b.openedAt) < breakerCooldown {
+			return fmt.Errorf("%w for %s", ErrCircuitOpen, b.name)
+		}
+		b.state = enums.BreakerHalfOpen
+		b.probedAt = now
+		logger.Infof("Breaker", "Circuit for %s is half-open, sending a probe", b.name)
+	case enums.BreakerHalfOpen:
+		// Only one probe at a time. One that never reports back, because
+		// its caller gave up, is replaced after another cooldown.
+		if now.Sub(b.probedAt) < breakerCooldown {
+			return fmt.Errorf("%w for %s", ErrCircuitOpen, b.name)
+		}
+		b.probedAt = now
+	}
+
+	return nil
+}
+
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != enums.BreakerClosed {
+		logger.Successf("Breaker", "Circuit for %s closed, upstream is back", b.name)
+	}
+	b.state = enums.BreakerClosed
+	b.failures = 0
+}
+
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == enums.BreakerHalfOpen || (b.state == enums.BreakerClosed && b.failures >= breakerThreshold) {
+		b.state = enums.BreakerOpen
+		b.openedAt = time.Now()
+		logger.Warnf("Breaker", "Circuit for %s opened after %d failures, retrying in %v", b.name, b.failures, breakerCooldown)
+	}
+}
+
+func (b *CircuitBreaker) Status() types.UpstreamStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := types.UpstreamStatus{
+		Name:     b.name,
+		State:    b.state,
+		Failures: b.failures,
+	}
+	if b.state != enums.BreakerClosed {
+		openedAt := b.openedAt
+		retryAt := b.openedAt.Add(breakerCooldown)
+		status.OpenedAt = &openedAt
+		status.RetryAt = &retryAt
+	}
+	return status
+}
+
+// Available reports whether calls to an upstream would be let through, that
+// is whether its breaker is closed or ready to send a probe. An upstream
+// without a breaker is always available.
+func Available(name enums.Upstream) bool {
+	breakers.mu.Lock()
+	list := append([]*CircuitBreaker(nil), breakers.list...)
+	breakers.mu.Unlock()
+
+	for _, b := range list {
+		if b.name == name && !b.ready() {
+			return false
+		}
+	}
+	return true
+}
+
+// ready is Allow without taking the probe.
+func (b *CircuitBreaker) ready() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case enums.BreakerOpen:
+		return time.Since(b.openedAt) >= breakerCooldown
+	case enums.BreakerHalfOpen:
+		return time.Since(b.probedAt) >= breakerCooldown
+	default:
+		return true
+	}
+}
+
+// BreakerStatuses returns the state of every upstream, sorted by name.
+func BreakerStatuses() []types.UpstreamStatus {
+	breakers.mu.Lock()
+	list := append([]*CircuitBreaker(nil), breakers.list...)
+	breakers.mu.Unlock()
+
+	statuses := make([]types.UpstreamStatus, len(list))
+	for i, b := range list {
+		statuses[i] = b.Status()
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
@@ -0,0 +1,287 @@
+package upstream
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"metachan/config"
+	"metachan/types"
+	"metachan/utils/logger"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// An entry is evicted once it is this many of its TTLs old. Until then it
+	// can still be revalidated instead of downloaded again.
+	cacheEvictionTTLs = 4
+	// cacheMaxAge bounds entries stored without a TTL.
+	cacheMaxAge        = 30 * 24 * time.Hour
+	cacheSweepInterval = 6 * time.Hour
+	// Temporary files older than this were left by an interrupted write.
+	cacheTempMaxAge = time.Hour
+)
+
+var (
+	cacheOnce     sync.Once
+	responseCache *ResponseCache
+)
+
+// sharedCache opens the response cache the first time a client needs it and
+// returns nil when caching is turned off.
+func sharedCache() *ResponseCache {
+	cacheOnce.Do(func() {
+		if !config.Cache.Upstream {
+			logger.Infof("UpstreamCache", "Upstream response cache is disabled")
+			return
+		}
+
+		dir := config.Cache.UpstreamDir
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			logger.Warnf("UpstreamCache", "Failed to create cache directory %s, caching disabled: %v", dir, err)
+			return
+		}
+
+		logger.Infof("UpstreamCache", "Caching upstream responses in %s", dir)
+		responseCache = &ResponseCache{dir: dir}
+		go responseCache.sweepPeriodically()
+	})
+	return responseCache
+}
+
+func (c *ResponseCache) sweepPeriodically() {
+	ticker := time.NewTicker(cacheSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		c.sweep(time.Now())
+		<-ticker.C
+	}
+}
+
+// sweep deletes the entries that have outlived their eviction age, along with
+// bodies whose metadata is gone and leftovers of interrupted writes, and
+// measures what is left.
+func (c *ResponseCache) sweep(now time.Time) {
+	var entries, size, evicted int64
+
+	err := filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Entries can be removed by a concurrent store or remove.
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		switch filepath.Ext(path) {
+		case ".json":
+			if c.expired(path, now) {
+				os.Remove(path)
+				os.Remove(strings.TrimSuffix(path, ".json") + ".body")
+				evicted++
+				return nil
+			}
+			entries++
+		case ".body":
+			if _, err := os.Stat(strings.TrimSuffix(path, ".body") + ".json"); errors.Is(err, fs.ErrNotExist) && now.Sub(info.ModTime()) > cacheTempMaxAge {
+				os.Remove(path)
+				return nil
+			}
+		case ".tmp":
+			if now.Sub(info.ModTime()) > cacheTempMaxAge {
+				os.Remove(path)
+			}
+			return nil
+		}
+
+		size += info.Size()
+		return nil
+	})
+	if err != nil {
+		logger.Warnf("UpstreamCache", "Failed to sweep cache directory %s: %v", c.dir, err)
+		return
+	}
+
+	c.entries.Store(entries)
+	c.bytes.Store(size)
+	c.evicted.Add(evicted)
+	if evicted > 0 {
+		logger.Infof("UpstreamCache", "Evicted %d expired cached responses, %d left (%d bytes)", evicted, entries, size)
+	}
+}
+
+// expired reports whether the entry whose metadata is at path is old enough
+// to evict. Unreadable metadata is evicted too, load ignores it anyway.
+func (c *ResponseCache) expired(path string, now time.Time) bool {
+	meta, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(meta, &entry); err != nil {
+		return true
+	}
+
+	maxAge := cacheMaxAge
+	if entry.TTL > 0 {
+		maxAge = min(entry.TTL*cacheEvictionTTLs, cacheMaxAge)
+	}
+	return now.Sub(entry.StoredAt) > maxAge
+}
+
+// WithRevalidate tags ctx so that every call made with it revalidates its
+// cached response, as if it had set Request.Revalidate. Refreshes use it, a
+// response cached before the refresh would hide what it is looking for.
+func WithRevalidate(ctx context.Context) context.Context {
+	return context.WithValue(ctx, revalidateKey{}, true)
+}
+
+func revalidating(ctx context.Context, req Request) bool {
+	revalidate, _ := ctx.Value(revalidateKey{}).(bool)
+	return revalidate || req.Revalidate
+}
+
+// CacheStats returns the response cache counters, or nil when the cache is
+// off.
+func CacheStats() *types.ResponseCacheStats {
+	cache := sharedCache()
+	if cache == nil {
+		return nil
+	}
+
+	return &types.ResponseCacheStats{
+		Hits:        cache.hits.Load(),
+		Revalidated: cache.revalidated.Load(),
+		Misses:      cache.misses.Load(),
+		Evicted:     cache.evicted.Load(),
+		Entries:     cache.entries.Load(),
+		SizeBytes:   cache.bytes.Load(),
+	}
+}
+
+func cacheKey(method, target string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(target))
+	hash.Write([]byte{0})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (c *ResponseCache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key)
+}
+
+// load returns what is stored under key, or a nil entry when nothing is.
+func (c *ResponseCache) load(key string) (*cacheEntry, []byte) {
+	meta, err := os.ReadFile(c.path(key) + ".json")
+	if err != nil {
+		return nil, nil
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(meta, &entry); err != nil {
+		logger.Warnf("UpstreamCache", "Ignoring unreadable cache entry %s: %v", key, err)
+		return nil, nil
+	}
+
+	body, err := os.ReadFile(c.path(key) + ".body")
+	if err != nil {
+		return nil, nil
+	}
+
+	return &entry, body
+}
+
+// store writes entry under key, and body too unless it is nil. The metadata
+// goes last, so an entry is only ever seen with its full body.
+func (c *ResponseCache) store(key string, entry cacheEntry, body []byte) {
+	if err := os.MkdirAll(filepath.Dir(c.path(key)), 0o755); err != nil {
+		logger.Warnf("UpstreamCache", "Failed to create cache directory: %v", err)
+		return
+	}
+
+	if body != nil {
+		if err := writeFileAtomic(c.path(key)+".body", body); err != nil {
+			logger.Warnf("UpstreamCache", "Failed to cache response from %s: %v", entry.URL, err)
+			return
+		}
+	}
+
+	meta, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if err := writeFileAtomic(c.path(key)+".json", meta); err != nil {
+		logger.Warnf("UpstreamCache", "Failed to cache response from %s: %v", entry.URL, err)
+	}
+}
+
+func (c *ResponseCache) remove(key string) {
+	os.Remove(c.path(key) + ".json")
+	os.Remove(c.path(key) + ".body")
+}
+
+// conditionalHeader asks the upstream to answer 304 if the cached response is
+// still current. It returns nil when the entry has nothing to validate with.
+func (e *cacheEntry) conditionalHeader() http.Header {
+	if e.ETag == "" && e.LastModified == "" {
+		return nil
+	}
+
+	header := http.Header{}
+	if e.ETag != "" {
+		header.Set("If-None-Match", e.ETag)
+	}
+	if e.LastModified != "" {
+		header.Set("If-Modified-Since", e.LastModified)
+	}
+	return header
+}
+
+func newCacheEntry(target string, header http.Header, ttl time.Duration) cacheEntry {
+	return cacheEntry{
+		URL:          target,
+		StoredAt:     time.Now(),
+		TTL:          ttl,
+		ETag:         header.Get("ETag"),
+		LastModified: header.Get("Last-Modified"),
+	}
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
@@ -0,0 +1,59 @@
+package upstream
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSweepEvictsExpiredEntries(t *testing.T) {
+	cache := &ResponseCache{dir: t.TempDir()}
+	now := time.Now()
+
+	store := func(target string, age, ttl time.Duration) string {
+		key := cacheKey("GET", target, nil)
+		cache.store(key, cacheEntry{URL: target, StoredAt: now.Add(-age), TTL: ttl}, []byte("body"))
+		return key
+	}
+
+	fresh := store("https://example.com/fresh", 2*time.Hour, time.Hour)
+	stale := store("https://example.com/stale", 5*time.Hour, time.Hour)
+	untimed := store("https://example.com/untimed", 40*24*time.Hour, 0)
+	kept := store("https://example.com/kept", 20*24*time.Hour, 0)
+
+	orphan := filepath.Join(cache.dir, "ab", "orphan.body")
+	os.MkdirAll(filepath.Dir(orphan), 0o755)
+	os.WriteFile(orphan, []byte("body"), 0o644)
+	old := now.Add(-2 * cacheTempMaxAge)
+	os.Chtimes(orphan, old, old)
+
+	cache.sweep(now)
+
+	for _, key := range []string{fresh, kept} {
+		if entry, _ := cache.load(key); entry == nil {
+			t.Errorf("entry %s was evicted, want it kept", key)
+		}
+	}
+	for _, key := range []string{stale, untimed} {
+		if entry, _ := cache.load(key); entry != nil {
+			t.Errorf("entry %s for %s was kept, want it evicted", key, entry.URL)
+		}
+		if _, err := os.Stat(cache.path(key) + ".body"); !os.IsNotExist(err) {
+			t.Errorf("body of %s was kept, want it removed", key)
+		}
+	}
+	if _, err := os.Stat(orphan); !os.IsNotExist(err) {
+		t.Error("orphaned body was kept, want it removed")
+	}
+
+	if got := cache.entries.Load(); got != 2 {
+		t.Errorf("entries = %d, want 2", got)
+	}
+	if got := cache.evicted.Load(); got != 2 {
+		t.Errorf("evicted = %d, want 2", got)
+	}
+	if cache.bytes.Load() == 0 {
+		t.Error("bytes = 0, want the size of the kept entries")
+	}
+}
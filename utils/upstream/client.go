@@ -0,0 +1,342 @@
+package upstream
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math/rand"
+	"metachan/utils/logger"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultAttempts   = 3
+	defaultBackoff    = 1 * time.Second
+	defaultMaxBackoff = 30 * time.Second
+	jitterRatio       = 0.4
+)
+
+// ErrNotFound matches a StatusError for a 404 response.
+var ErrNotFound = errors.New("resource not found")
+
+func New(config Config) *Client {
+	if config.Retry.Attempts <= 0 {
+		config.Retry.Attempts = defaultAttempts
+	}
+	if config.Retry.Backoff <= 0 {
+		config.Retry.Backoff = defaultBackoff
+	}
+	if config.Retry.MaxBackoff <= 0 {
+		config.Retry.MaxBackoff = defaultMaxBackoff
+	}
+	if config.Retry.RetryAfter <= 0 {
+		config.Retry.RetryAfter = config.Retry.Backoff
+	}
+	if config.Decode == nil {
+		config.Decode = json.Unmarshal
+	}
+
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Timeout:   config.AttemptTimeout,
+			Transport: Transport(nil),
+		}
+	}
+
+	return &Client{
+		config:     config,
+		httpClient: httpClient,
+	}
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("status %d from %s", e.StatusCode, e.URL)
+}
+
+func (e *StatusError) Is(target error) bool {
+	return target == ErrNotFound && e.StatusCode == http.StatusNotFound
+}
+
+// URL resolves path against the client's BaseURL.
+func (c *Client) URL(path string) string {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	return c.config.BaseURL + path
+}
+
+func (c *Client) Get(ctx context.Context, path string) ([]byte, error) {
+	return c.Do(ctx, Request{Path: path})
+}
+
+func (c *Client) GetJSON(ctx context.Context, path string, v any) error {
+	return c.Fetch(ctx, Request{Path: path}, v)
+}
+
+// PostJSON sends body encoded as JSON and decodes the response into v.
+func (c *Client) PostJSON(ctx context.Context, path string, body any, v any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request body: %w", err)
+	}
+
+	return c.Fetch(ctx, Request{
+		Method: http.MethodPost,
+		Path:   path,
+		Header: http.Header{"Content-Type": {"application/json"}},
+		Body:   data,
+	}, v)
+}
+
+// Fetch is Do followed by the client's Decode.
+func (c *Client) Fetch(ctx context.Context, req Request, v any) error {
+	data, err := c.Do(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if err := c.config.Decode(data, v); err != nil {
+		// Keep a body that could not be decoded from being served again.
+		if cache := c.cache(req); cache != nil {
+			cache.remove(cacheKey(requestMethod(req), c.URL(req.Path), req.Body))
+		}
+		return fmt.Errorf("failed to decode response from %s: %w", c.URL(req.Path), err)
+	}
+
+	return nil
+}
+
+// Do sends req and returns the response body, retrying by the client's
+// RetryPolicy. Every attempt waits for the limiter and asks the breaker first.
+// A response younger than the client's CacheTTL is returned from the response
+// cache without sending anything, unless the call revalidates, and an older
+// one is revalidated with its ETag or Last-Modified when the upstream gave one.
+func (c *Client) Do(ctx context.Context, req Request) ([]byte, error) {
+	if c.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.config.Timeout)
+		defer cancel()
+	}
+
+	target := c.URL(req.Path)
+	policy := c.config.Retry
+
+	var key string
+	var cached *cacheEntry
+	var cachedBody []byte
+	cache := c.cache(req)
+	if cache != nil {
+		key = cacheKey(requestMethod(req), target, req.Body)
+		cached, cachedBody = cache.load(key)
+		if cached != nil && time.Since(cached.StoredAt) < c.config.CacheTTL && !revalidating(ctx, req) {
+			cache.hits.Add(1)
+			logger.Debugf(c.config.Name, "Serving %s from cache", target)
+			return cachedBody, nil
+		}
+		if cached != nil {
+			if header := cached.conditionalHeader(); header != nil {
+				req.Header = req.Header.Clone()
+				if req.Header == nil {
+					req.Header = http.Header{}
+				}
+				maps.Copy(req.Header, header)
+			}
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		if err := c.wait(ctx); err != nil {
+			return nil, err
+		}
+
+		result, err := c.send(ctx, target, req)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		c.record(err, result)
+		if err == nil && result.notModified {
+			cache.revalidated.Add(1)
+			logger.Debugf(c.config.Name, "Cached response for %s is still current", target)
+			entry := *cached
+			entry.StoredAt = time.Now()
+			cache.store(key, entry, nil)
+			return cachedBody, nil
+		}
+		if err == nil {
+			if cache != nil {
+				cache.misses.Add(1)
+				cache.store(key, newCacheEntry(target, result.header, c.config.CacheTTL), result.body)
+			}
+			return result.body, nil
+		}
+		if !result.retry {
+			return nil, err
+		}
+		if attempt >= policy.Attempts {
+			logger.Errorf(c.config.Name, "All retries exhausted for %s: %v", target, err)
+			return nil, err
+		}
+
+		delay := max(c.backoff(attempt), result.retryAfter)
+		logger.Warnf(c.config.Name, "%v (attempt %d/%d), retrying in %v", err, attempt, policy.Attempts, delay.Round(time.Millisecond))
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (c *Client) wait(ctx context.Context) error {
+	if c.config.Breaker != nil {
+		if err := c.config.Breaker.Allow(); err != nil {
+			return err
+		}
+	}
+
+	if c.config.Limiter != nil {
+		if err := c.config.Limiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	if c.config.Jitter > 0 {
+		return sleep(ctx, addJitter(c.config.Jitter))
+	}
+
+	return nil
+}
+
+// send makes one attempt. It reports whether a failure is worth retrying and,
+// for a 429, how long the upstream asked us to wait.
+func (c *Client) send(ctx context.Context, target string, req Request) (attempt, error) {
+	var body io.Reader
+	if req.Body != nil {
+		body = bytes.NewReader(req.Body)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, requestMethod(req), target, body)
+	if err != nil {
+		return attempt{}, fmt.Errorf("failed to create request for %s: %w", target, err)
+	}
+
+	maps.Copy(request.Header, c.config.Header)
+	if c.config.UserAgent != "" {
+		request.Header.Set("User-Agent", c.config.UserAgent)
+	}
+	maps.Copy(request.Header, req.Header)
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return attempt{retry: true}, fmt.Errorf("request to %s failed: %w", target, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		data, err := io.ReadAll(response.Body)
+		if err != nil {
+			return attempt{retry: true}, fmt.Errorf("failed to read response from %s: %w", target, err)
+		}
+		return attempt{body: data, header: response.Header}, nil
+	}
+
+	conditional := request.Header.Get("If-None-Match") != "" || request.Header.Get("If-Modified-Since") != ""
+	if response.StatusCode == http.StatusNotModified && conditional {
+		return attempt{notModified: true}, nil
+	}
+
+	statusErr := &StatusError{URL: target, StatusCode: response.StatusCode}
+	switch {
+	case response.StatusCode == http.StatusTooManyRequests:
+		retryAfter := c.retryAfter(response)
+		if c.config.Limiter != nil {
+			c.config.Limiter.Throttle(retryAfter)
+		}
+		return attempt{retryAfter: retryAfter, retry: true, throttled: true}, statusErr
+	case response.StatusCode >= 500, slices.Contains(c.config.Retry.RetryStatuses, response.StatusCode):
+		return attempt{retry: true}, statusErr
+	default:
+		return attempt{}, statusErr
+	}
+}
+
+// cache returns the response cache when req may use it.
+func (c *Client) cache(req Request) *ResponseCache {
+	if c.config.CacheTTL <= 0 || req.NoCache {
+		return nil
+	}
+	return sharedCache()
+}
+
+// record tells the breaker how an attempt went. Responses the upstream gave
+// on purpose, such as a 404, count as successes. A 429 counts as neither: the
+// upstream is up but rate limiting us, which the limiter already deals with.
+func (c *Client) record(err error, result attempt) {
+	if c.config.Breaker == nil || result.throttled {
+		return
+	}
+
+	if err != nil && result.retry {
+		c.config.Breaker.Failure()
+	} else {
+		c.config.Breaker.Success()
+	}
+}
+
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.config.Retry.Backoff
+	for i := 1; i < attempt && delay < c.config.Retry.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return addJitter(min(delay, c.config.Retry.MaxBackoff))
+}
+
+// retryAfter reads the Retry-After header, which is either a number of
+// seconds or an HTTP date.
+func (c *Client) retryAfter(response *http.Response) time.Duration {
+	value := response.Header.Get("Retry-After")
+	if value == "" {
+		return c.config.Retry.RetryAfter
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0)
+	}
+
+	return c.config.Retry.RetryAfter
+}
+
+func requestMethod(req Request) string {
+	if req.Method == "" {
+		return http.MethodGet
+	}
+	return req.Method
+}
+
+func addJitter(delay time.Duration) time.Duration {
+	jitterRange := float64(delay) * jitterRatio
+	return delay + time.Duration(rand.Float64()*jitterRange-jitterRange/2)
+}
+
+func sleep(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,44 @@
+package upstream
+
+import (
+	"context"
+	"metachan/enums"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRateLimitedAttemptsLeaveBreakerClosed(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusTooManyRequests)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer server.Close()
+
+	breaker := NewBreaker(enums.Upstream("client-test"))
+	client := New(Config{
+		Name:    "ClientTest",
+		BaseURL: server.URL,
+		Retry:   RetryPolicy{Attempts: 1},
+		Breaker: breaker,
+	})
+
+	for range breakerThreshold * 2 {
+		if _, err := client.Get(context.Background(), "/"); err == nil {
+			t.Fatal("Get succeeded on a 429")
+		}
+	}
+	if got := breaker.Status().State; got != enums.BreakerClosed {
+		t.Fatalf("breaker is %s after only 429s, want closed", got)
+	}
+
+	status.Store(http.StatusServiceUnavailable)
+	for range breakerThreshold {
+		client.Get(context.Background(), "/")
+	}
+	if got := breaker.Status().State; got != enums.BreakerOpen {
+		t.Errorf("breaker is %s after %d server errors, want open", got, breakerThreshold)
+	}
+}
@@ -0,0 +1,146 @@
+package upstream
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"metachan/config"
+	"metachan/enums"
+	"metachan/utils/cassette"
+	"metachan/utils/logger"
+	"net/http"
+	"sync"
+)
+
+var modeOnce sync.Once
+
+// Transport returns base wrapped for the configured HTTP_MODE: recording
+// every exchange as a cassette, answering only from cassettes, or, in live
+// mode, passing requests to base itself. The mode is read on every request,
+// so that tests can switch to replay after the clients are built. A nil base
+// means http.DefaultTransport.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &cassetteTransport{base: base}
+}
+
+func (t *cassetteTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	mode, dir := enums.HTTPMode(config.Upstream.Mode), config.Upstream.CassetteDir
+	if mode != enums.HTTPRecord && mode != enums.HTTPReplay {
+		return t.base.RoundTrip(request)
+	}
+
+	modeOnce.Do(func() {
+		if mode == enums.HTTPRecord {
+			logger.Infof("Cassette", "Recording upstream traffic to %s", dir)
+		} else {
+			logger.Infof("Cassette", "Replaying upstream traffic from %s", dir)
+		}
+	})
+
+	body, err := requestBody(request)
+	if err != nil {
+		return nil, err
+	}
+
+	path := cassette.Path(dir, request.Method, request.URL.String(), body)
+	if mode == enums.HTTPReplay {
+		return t.replay(request, path)
+	}
+	return t.record(request, path, body)
+}
+
+// replay answers from the cassette at path. A request that was never recorded
+// gets a 404, which providers already treat as missing data, rather than an
+// error that would be retried.
+func (t *cassetteTransport) replay(request *http.Request, path string) (*http.Response, error) {
+	recorded, err := cassette.Load(path)
+	if err != nil {
+		logger.Warnf("Cassette", "No cassette for %s %s", request.Method, request.URL)
+		return newResponse(request, http.StatusNotFound, http.Header{}, nil), nil
+	}
+
+	body, err := recorded.ResponseBody()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode cassette body %s: %w", path, err)
+	}
+
+	header := recorded.Response.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+
+	logger.Debugf("Cassette", "Replaying %s %s", request.Method, request.URL)
+	return newResponse(request, recorded.Response.Status, header, body), nil
+}
+
+// record sends the request and saves what comes back. Rate limit and server
+// errors are passed on without being saved, a replay should never repeat
+// them.
+func (t *cassetteTransport) record(request *http.Request, path string, body []byte) (*http.Response, error) {
+	response, err := t.base.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500 {
+		return response, nil
+	}
+
+	responseBody, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response from %s: %w", request.URL, err)
+	}
+	response.Body = io.NopCloser(bytes.NewReader(responseBody))
+
+	recorded := cassette.New(request.Method, request.URL.String(), body, response.StatusCode, response.Header, responseBody)
+	if err := cassette.Save(path, recorded); err != nil {
+		logger.Warnf("Cassette", "Failed to record %s %s: %v", request.Method, request.URL, err)
+	} else {
+		logger.Debugf("Cassette", "Recorded %s %s", request.Method, request.URL)
+	}
+
+	return response, nil
+}
+
+// requestBody reads the body of request without consuming it.
+func requestBody(request *http.Request) ([]byte, error) {
+	if request.Body == nil || request.Body == http.NoBody {
+		return nil, nil
+	}
+
+	if request.GetBody != nil {
+		reader, err := request.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		return io.ReadAll(reader)
+	}
+
+	body, err := io.ReadAll(request.Body)
+	request.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	request.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+func newResponse(request *http.Request, status int, header http.Header, body []byte) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       request,
+	}
+}
@@ -0,0 +1,130 @@
+package upstream
+
+import (
+	"metachan/enums"
+	"metachan/utils/ratelimit"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Breaker decides whether a call may be sent and learns from how calls went.
+// Allow returns an error while the upstream is considered down.
+type Breaker interface {
+	Allow() error
+	Success()
+	Failure()
+}
+
+// RetryPolicy controls how failed attempts are retried. Network errors, 429
+// and 5xx responses are retried, as are the statuses in RetryStatuses.
+// Backoff doubles with every attempt up to MaxBackoff. RetryAfter is how long
+// to wait on a 429 that carries no Retry-After header and defaults to Backoff.
+type RetryPolicy struct {
+	Attempts      int
+	Backoff       time.Duration
+	MaxBackoff    time.Duration
+	RetryAfter    time.Duration
+	RetryStatuses []int
+}
+
+// Config describes one upstream. Timeout bounds a whole call including its
+// retries and AttemptTimeout a single attempt. HTTPClient, when set, is used
+// as is and AttemptTimeout is ignored; its transport should come from
+// Transport. Jitter adds a random pause of around that length before every
+// attempt. Decode defaults to json.Unmarshal.
+// CacheTTL is how long a response is served from the response cache before
+// the upstream is asked again; zero turns caching off for the client.
+type Config struct {
+	Name           string
+	BaseURL        string
+	Timeout        time.Duration
+	AttemptTimeout time.Duration
+	UserAgent      string
+	Header         http.Header
+	Limiter        *ratelimit.Limiter
+	Retry          RetryPolicy
+	Breaker        Breaker
+	Jitter         time.Duration
+	HTTPClient     *http.Client
+	Decode         func(data []byte, v any) error
+	CacheTTL       time.Duration
+}
+
+// Request is one call to an upstream. Path is appended to the BaseURL unless
+// it is an absolute URL. Method defaults to GET. NoCache keeps the call out of
+// the response cache, for requests such as logins that must always be sent.
+// Revalidate asks the upstream even when the cached response is still fresh,
+// sending its ETag or Last-Modified so that an unchanged one costs little.
+type Request struct {
+	Method     string
+	Path       string
+	Header     http.Header
+	Body       []byte
+	NoCache    bool
+	Revalidate bool
+}
+
+type revalidateKey struct{}
+
+// attempt is the outcome of sending a request once.
+type attempt struct {
+	body        []byte
+	header      http.Header
+	notModified bool
+	retryAfter  time.Duration
+	retry       bool
+	throttled   bool
+}
+
+type Client struct {
+	config     Config
+	httpClient *http.Client
+}
+
+// StatusError is returned for a response outside the 2xx range.
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+// CircuitBreaker is the Breaker each provider uses. It opens after a run of
+// failed attempts and, once the cooldown has passed, lets a single probe
+// through to find out whether the upstream is back.
+type CircuitBreaker struct {
+	name     enums.Upstream
+	mu       sync.Mutex
+	state    enums.BreakerState
+	failures int
+	openedAt time.Time
+	probedAt time.Time
+}
+
+// ResponseCache keeps upstream responses on disk, one body and one metadata
+// file per request, named by the hash of the method, URL and request body.
+type ResponseCache struct {
+	dir string
+
+	hits        atomic.Int64
+	revalidated atomic.Int64
+	misses      atomic.Int64
+
+	// Measured by the last sweep.
+	entries atomic.Int64
+	bytes   atomic.Int64
+	evicted atomic.Int64
+}
+
+// cassetteTransport is the RoundTripper returned by Transport.
+type cassetteTransport struct {
+	base http.RoundTripper
+}
+
+type cacheEntry struct {
+	URL          string        `json:"url"`
+	StoredAt     time.Time     `json:"stored_at"`
+	TTL          time.Duration `json:"ttl,omitempty"`
+	ETag         string        `json:"etag,omitempty"`
+	LastModified string        `json:"last_modified,omitempty"`
+}